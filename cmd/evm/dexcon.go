@@ -0,0 +1,81 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"math/big"
+	"os"
+
+	"github.com/dexon-foundation/dexon/cmd/utils"
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/core/vm/runtime"
+	cli "gopkg.in/urfave/cli.v1"
+)
+
+// readGovState reads the governance state to seed from the given JSON file.
+func readGovState(path string) *runtime.GovernanceState {
+	file, err := os.Open(path)
+	if err != nil {
+		utils.Fatalf("Failed to read governance state file: %v", err)
+	}
+	defer file.Close()
+
+	gov := new(runtime.GovernanceState)
+	if err := json.NewDecoder(file).Decode(gov); err != nil {
+		utils.Fatalf("invalid governance state file: %v", err)
+	}
+	return gov
+}
+
+// setDexconConfig sets the DEXON specific execution context of the flags.
+func setDexconConfig(ctx *cli.Context, cfg *runtime.Config) {
+	cfg.Randomness = common.FromHex(ctx.GlobalString(RandomnessFlag.Name))
+	cfg.BlockProposer = ctx.GlobalBool(BlockProposerFlag.Name)
+	if ctx.GlobalIsSet(RoundFlag.Name) {
+		cfg.Round = new(big.Int).SetUint64(ctx.GlobalUint64(RoundFlag.Name))
+	}
+	if path := ctx.GlobalString(GovStateFlag.Name); path != "" {
+		cfg.GovernanceState = readGovState(path)
+	}
+}
+
+// dexconHook returns the state test hook setting the DEXON specific execution
+// context of the flags.
+func dexconHook(ctx *cli.Context) func(*vm.Context, *state.StateDB) {
+	var cfg runtime.Config
+	setDexconConfig(ctx, &cfg)
+
+	return func(context *vm.Context, statedb *state.StateDB) {
+		context.Randomness = cfg.Randomness
+		context.StateAtNumber = func(uint64) (*state.StateDB, error) { return statedb, nil }
+		context.GetRoundHeight = func(round uint64) (uint64, bool) {
+			return runtime.RoundHeight(statedb, round)
+		}
+		if cfg.GovernanceState != nil {
+			if err := cfg.GovernanceState.Apply(statedb); err != nil {
+				utils.Fatalf("Failed to apply governance state: %v", err)
+			}
+		}
+		if cfg.Round != nil {
+			runtime.AdvanceRound(statedb, nil, cfg.Round, context.BlockNumber)
+		}
+	}
+}
@@ -110,6 +110,22 @@ var (
 		Name:  "nostack",
 		Usage: "disable stack output",
 	}
+	RandomnessFlag = cli.StringFlag{
+		Name:  "randomness",
+		Usage: "block randomness (hex) for the RAND opcode",
+	}
+	RoundFlag = cli.Uint64Flag{
+		Name:  "round",
+		Usage: "governance round, the governance state is advanced to it",
+	}
+	BlockProposerFlag = cli.BoolFlag{
+		Name:  "blockproposer",
+		Usage: "execute as a block proposer",
+	}
+	GovStateFlag = cli.StringFlag{
+		Name:  "govstate",
+		Usage: "JSON file with governance state (nodes, config, CRS)",
+	}
 )
 
 func init() {
@@ -133,6 +149,10 @@ func init() {
 		ReceiverFlag,
 		DisableMemoryFlag,
 		DisableStackFlag,
+		RandomnessFlag,
+		RoundFlag,
+		BlockProposerFlag,
+		GovStateFlag,
 	}
 	app.Commands = []cli.Command{
 		compileCommand,
@@ -175,6 +175,7 @@ func runCmd(ctx *cli.Context) error {
 			Debug:  ctx.GlobalBool(DebugFlag.Name) || ctx.GlobalBool(MachineFlag.Name),
 		},
 	}
+	setDexconConfig(ctx, &runtimeConfig)
 
 	if cpuProfilePath := ctx.GlobalString(CPUProfileFlag.Name); cpuProfilePath != "" {
 		f, err := os.Create(cpuProfilePath)
@@ -151,6 +151,24 @@ The first argument must be the directory containing the blockchain to download f
 		Category: "BLOCKCHAIN COMMANDS",
 		Description: `
 Remove blockchain and state databases`,
+	}
+	pruneStateCommand = cli.Command{
+		Action:    utils.MigrateFlags(pruneState),
+		Name:      "prune-state",
+		Usage:     "Prune historical states not at round boundaries",
+		ArgsUsage: " ",
+		Flags: []cli.Flag{
+			utils.DataDirFlag,
+			utils.CacheFlag,
+			utils.SyncModeFlag,
+			utils.StateRetentionFlag,
+		},
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+The prune-state command deletes the historical states of the chain, except
+the states at round heights, which the governance looks up the configurations
+from, and the states of the most recent blocks (--state.retention). The node
+must not be running.`,
 	}
 	dumpCommand = cli.Command{
 		Action:    utils.MigrateFlags(dump),
@@ -438,6 +456,20 @@ func removeDB(ctx *cli.Context) error {
 	return nil
 }
 
+func pruneState(ctx *cli.Context) error {
+	stack, _ := makeConfigNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+
+	start := time.Now()
+	pruned, size, err := core.PruneState(db, ctx.Uint64(utils.StateRetentionFlag.Name))
+	if err != nil {
+		utils.Fatalf("Prune error: %v", err)
+	}
+	fmt.Printf("Pruned %d state entries (%v) in %v\n", pruned, size, time.Since(start))
+	return nil
+}
+
 func dump(ctx *cli.Context) error {
 	stack := makeFullNode(ctx)
 	chain, chainDb := utils.MakeChain(ctx, stack)
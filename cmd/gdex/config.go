@@ -129,6 +129,10 @@ func makeConfigNode(ctx *cli.Context) (*node.Node, gethConfig) {
 	if err != nil {
 		utils.Fatalf("Failed to create the protocol stack: %v", err)
 	}
+	if ctx.GlobalBool(utils.DeveloperFlag.Name) {
+		// The consensus core signs with the node key.
+		cfg.Dex.PrivateKey = cfg.Node.P2P.PrivateKey
+	}
 	utils.SetDexConfig(ctx, stack, &cfg.Dex)
 	if ctx.GlobalIsSet(utils.EthStatsURLFlag.Name) {
 		cfg.Ethstats.URL = ctx.GlobalString(utils.EthStatsURLFlag.Name)
@@ -154,6 +158,9 @@ func makeFullNode(ctx *cli.Context) *node.Node {
 	stack, cfg := makeConfigNode(ctx)
 
 	utils.RegisterDexService(stack, &cfg.Dex)
+	if ctx.GlobalBool(utils.DeveloperFlag.Name) {
+		utils.RegisterDeveloperSigners(stack, &cfg.Dex)
+	}
 
 	if ctx.GlobalBool(utils.DashboardEnabledFlag.Name) {
 		utils.RegisterDashboardService(stack, &cfg.Dashboard, gitCommit)
@@ -0,0 +1,181 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	coreDb "github.com/dexon-foundation/dexon-consensus/core/db"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/cmd/utils"
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/dex/db"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	coredbRoundFlag = cli.Int64Flag{
+		Name:  "round",
+		Usage: "Only include lattice blocks of the given round",
+		Value: -1,
+	}
+	coredbChainFlag = cli.Int64Flag{
+		Name:  "chain",
+		Usage: "Only include lattice blocks of the given chain",
+		Value: -1,
+	}
+
+	coredbCommand = cli.Command{
+		Name:     "coredb",
+		Usage:    "Inspect the lattice blocks of the consensus core",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Inspect the lattice blocks the consensus core stored in the chain database.`,
+		Subcommands: []cli.Command{
+			{
+				Name:   "list",
+				Usage:  "List lattice blocks ordered by position",
+				Action: utils.MigrateFlags(coredbList),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.SyncModeFlag,
+					coredbRoundFlag,
+					coredbChainFlag,
+				},
+				Description: `
+    gdex coredb list [--round N] [--chain N]
+
+Prints the position, hash and finalization height of every lattice block.`,
+			},
+			{
+				Name:      "dump",
+				Usage:     "Dump lattice blocks in JSON",
+				Action:    utils.MigrateFlags(coredbDump),
+				ArgsUsage: "<blockHash> (<blockHash 2> ... <blockHash N>)",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.SyncModeFlag,
+				},
+			},
+			{
+				Name:   "count",
+				Usage:  "Count lattice blocks by round and chain",
+				Action: utils.MigrateFlags(coredbCount),
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.SyncModeFlag,
+					coredbRoundFlag,
+					coredbChainFlag,
+				},
+			},
+		},
+	}
+)
+
+// iterateLatticeBlocks calls fn with the lattice blocks matching the round
+// and chain filters, ordered by position.
+func iterateLatticeBlocks(ctx *cli.Context, fn func(block *coreTypes.Block)) {
+	stack, _ := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	it, err := db.NewDatabase(chainDb).GetAllBlocks()
+	if err != nil {
+		utils.Fatalf("Failed to iterate lattice blocks: %v", err)
+	}
+	round, chain := ctx.Int64(coredbRoundFlag.Name), ctx.Int64(coredbChainFlag.Name)
+	for {
+		block, err := it.NextBlock()
+		if err == coreDb.ErrIterationFinished {
+			return
+		}
+		if err != nil {
+			utils.Fatalf("Failed to iterate lattice blocks: %v", err)
+		}
+		if round >= 0 && block.Position.Round != uint64(round) {
+			continue
+		}
+		if chain >= 0 && block.Position.ChainID != uint32(chain) {
+			continue
+		}
+		fn(&block)
+	}
+}
+
+func coredbList(ctx *cli.Context) error {
+	iterateLatticeBlocks(ctx, func(block *coreTypes.Block) {
+		fmt.Printf("round=%d chain=%d height=%d hash=%s finalized=%d\n",
+			block.Position.Round, block.Position.ChainID, block.Position.Height,
+			common.Hash(block.Hash).Hex(), block.Finalization.Height)
+	})
+	return nil
+}
+
+func coredbDump(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	stack, _ := makeConfigNode(ctx)
+	chainDb := utils.MakeChainDatabase(ctx, stack)
+	defer chainDb.Close()
+
+	coreDB := db.NewDatabase(chainDb)
+	encoder := json.NewEncoder(os.Stdout)
+	encoder.SetIndent("", "  ")
+	for _, arg := range ctx.Args() {
+		hash := common.HexToHash(arg)
+		block, err := coreDB.GetBlock(coreCommon.Hash(hash))
+		if err != nil {
+			utils.Fatalf("Failed to get lattice block %s: %v", hash.Hex(), err)
+		}
+		if err := encoder.Encode(&block); err != nil {
+			utils.Fatalf("Failed to encode lattice block %s: %v", hash.Hex(), err)
+		}
+	}
+	return nil
+}
+
+func coredbCount(ctx *cli.Context) error {
+	type roundChain struct {
+		round uint64
+		chain uint32
+	}
+	var (
+		keys   []roundChain
+		counts = make(map[roundChain]int)
+		total  int
+	)
+	iterateLatticeBlocks(ctx, func(block *coreTypes.Block) {
+		key := roundChain{block.Position.Round, block.Position.ChainID}
+		if _, ok := counts[key]; !ok {
+			keys = append(keys, key)
+		}
+		counts[key]++
+		total++
+	})
+	// Keys are already ordered as the blocks are iterated by position.
+	for _, key := range keys {
+		fmt.Printf("round=%d chain=%d blocks=%d\n", key.round, key.chain, counts[key])
+	}
+	fmt.Printf("total=%d\n", total)
+	return nil
+}
@@ -0,0 +1,298 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"strings"
+
+	"github.com/dexon-foundation/dexon/cmd/utils"
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/params"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	defaultDexcon = params.TestnetChainConfig.Dexcon
+
+	genesisOutputFlag = cli.StringFlag{
+		Name:  "output",
+		Usage: "File to write the genesis to (default = stdout)",
+	}
+	genesisChainIDFlag = cli.Uint64Flag{
+		Name:  "chainid",
+		Usage: "Chain ID of the network (mandatory)",
+	}
+	genesisOwnerFlag = cli.StringFlag{
+		Name:  "owner",
+		Usage: "Account owning the governance contract (mandatory)",
+	}
+	genesisCRSFlag = cli.StringFlag{
+		Name:  "crs",
+		Usage: "Text the genesis CRS is derived from",
+		Value: defaultDexcon.GenesisCRSText,
+	}
+	genesisChainsFlag = cli.Uint64Flag{
+		Name:  "chains",
+		Usage: "Number of lattice chains",
+		Value: uint64(defaultDexcon.NumChains),
+	}
+	genesisNotarySetFlag = cli.Uint64Flag{
+		Name:  "notaryset",
+		Usage: "Size of the notary set",
+		Value: uint64(defaultDexcon.NotarySetSize),
+	}
+	genesisDKGSetFlag = cli.Uint64Flag{
+		Name:  "dkgset",
+		Usage: "Size of the DKG set",
+		Value: uint64(defaultDexcon.DKGSetSize),
+	}
+	genesisRoundFlag = cli.Uint64Flag{
+		Name:  "round",
+		Usage: "Round interval in milliseconds",
+		Value: defaultDexcon.RoundInterval,
+	}
+	genesisLambdaBAFlag = cli.Uint64Flag{
+		Name:  "lambda.ba",
+		Usage: "Lambda of BA in milliseconds",
+		Value: defaultDexcon.LambdaBA,
+	}
+	genesisLambdaDKGFlag = cli.Uint64Flag{
+		Name:  "lambda.dkg",
+		Usage: "Lambda of DKG in milliseconds",
+		Value: defaultDexcon.LambdaDKG,
+	}
+	genesisBlockIntervalFlag = cli.Uint64Flag{
+		Name:  "blockinterval",
+		Usage: "Minimum block interval in milliseconds",
+		Value: defaultDexcon.MinBlockInterval,
+	}
+	genesisMinStakeFlag = cli.StringFlag{
+		Name:  "minstake",
+		Usage: "Minimum stake of nodes in DXN",
+		Value: new(big.Int).Div(defaultDexcon.MinStake, big.NewInt(params.Ether)).String(),
+	}
+	genesisStakeFlag = cli.StringFlag{
+		Name:  "stake",
+		Usage: "Stake of nodes without an explicit one in DXN (default = minimum stake)",
+	}
+	genesisBalanceFlag = cli.StringFlag{
+		Name:  "balance",
+		Usage: "Balance of nodes besides their stake in DXN",
+		Value: "0",
+	}
+	genesisAllocFlag = cli.StringFlag{
+		Name:  "alloc",
+		Usage: "Comma separated accounts to pre-fund (address=DXN)",
+	}
+
+	genesisCommand = cli.Command{
+		Name:     "genesis",
+		Usage:    "Create and check Dexcon genesis files",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Create and check genesis files for networks running the DEXON consensus.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "new",
+				Usage:     "Create a genesis staking the given nodes",
+				Action:    utils.MigrateFlags(genesisNew),
+				ArgsUsage: "<node> (<node 2> ... <node N>)",
+				Flags: []cli.Flag{
+					genesisOutputFlag,
+					genesisChainIDFlag,
+					genesisOwnerFlag,
+					genesisCRSFlag,
+					genesisChainsFlag,
+					genesisNotarySetFlag,
+					genesisDKGSetFlag,
+					genesisRoundFlag,
+					genesisLambdaBAFlag,
+					genesisLambdaDKGFlag,
+					genesisBlockIntervalFlag,
+					genesisMinStakeFlag,
+					genesisStakeFlag,
+					genesisBalanceFlag,
+					genesisAllocFlag,
+				},
+				Description: `
+    gdex genesis new --chainid 1234 --owner 0x... [node1.key=100000 0x04...]
+
+Every node is given as a hex encoded public key or a node key file,
+optionally followed by =<DXN> to override the stake of the node. The
+genesis is validated the same way as genesis check before written.`,
+			},
+			{
+				Name:      "check",
+				Usage:     "Check a Dexcon genesis for inconsistencies",
+				Action:    utils.MigrateFlags(genesisCheck),
+				ArgsUsage: "<genesisPath>",
+				Description: `
+    gdex genesis check genesis.json
+
+Builds the genesis state in memory, reads the governance configuration back
+and reports every inconsistency which would stall the network, like a DKG
+set larger than the staked nodes or staked accounts without public key.`,
+			},
+		},
+	}
+)
+
+// parseDXN parses an amount of DXN into wei.
+func parseDXN(s string) (*big.Int, error) {
+	amount, ok := new(big.Int).SetString(s, 10)
+	if !ok || amount.Sign() < 0 {
+		return nil, fmt.Errorf("invalid DXN amount %q", s)
+	}
+	return amount.Mul(amount, big.NewInt(params.Ether)), nil
+}
+
+// parseNodeKey parses a hex encoded public key or loads it from a node key
+// file.
+func parseNodeKey(s string) (*ecdsa.PublicKey, error) {
+	if _, err := os.Stat(s); err == nil {
+		key, err := crypto.LoadECDSA(s)
+		if err != nil {
+			return nil, err
+		}
+		return &key.PublicKey, nil
+	}
+	return crypto.UnmarshalPubkey(common.FromHex(s))
+}
+
+func genesisNew(ctx *cli.Context) error {
+	if len(ctx.Args()) == 0 {
+		utils.Fatalf("No staked nodes given")
+	}
+	if !ctx.IsSet(genesisChainIDFlag.Name) {
+		utils.Fatalf("--%s is mandatory", genesisChainIDFlag.Name)
+	}
+	owner := ctx.String(genesisOwnerFlag.Name)
+	if !common.IsHexAddress(owner) {
+		utils.Fatalf("Invalid --%s %q", genesisOwnerFlag.Name, owner)
+	}
+	minStake, err := parseDXN(ctx.String(genesisMinStakeFlag.Name))
+	if err != nil {
+		utils.Fatalf("Invalid --%s: %v", genesisMinStakeFlag.Name, err)
+	}
+	stake := minStake
+	if ctx.IsSet(genesisStakeFlag.Name) {
+		if stake, err = parseDXN(ctx.String(genesisStakeFlag.Name)); err != nil {
+			utils.Fatalf("Invalid --%s: %v", genesisStakeFlag.Name, err)
+		}
+	}
+	balance, err := parseDXN(ctx.String(genesisBalanceFlag.Name))
+	if err != nil {
+		utils.Fatalf("Invalid --%s: %v", genesisBalanceFlag.Name, err)
+	}
+
+	config := *params.TestnetChainConfig
+	dexcon := *config.Dexcon
+	config.Dexcon = &dexcon
+	config.ChainID = new(big.Int).SetUint64(ctx.Uint64(genesisChainIDFlag.Name))
+
+	dexcon.Owner = common.HexToAddress(owner)
+	dexcon.GenesisCRSText = ctx.String(genesisCRSFlag.Name)
+	dexcon.NumChains = uint32(ctx.Uint64(genesisChainsFlag.Name))
+	dexcon.NotarySetSize = uint32(ctx.Uint64(genesisNotarySetFlag.Name))
+	dexcon.DKGSetSize = uint32(ctx.Uint64(genesisDKGSetFlag.Name))
+	dexcon.RoundInterval = ctx.Uint64(genesisRoundFlag.Name)
+	dexcon.LambdaBA = ctx.Uint64(genesisLambdaBAFlag.Name)
+	dexcon.LambdaDKG = ctx.Uint64(genesisLambdaDKGFlag.Name)
+	dexcon.MinBlockInterval = ctx.Uint64(genesisBlockIntervalFlag.Name)
+	dexcon.MinStake = minStake
+
+	nodes := make([]core.DexconNode, len(ctx.Args()))
+	for i, arg := range ctx.Args() {
+		node := core.DexconNode{
+			Staked:  stake,
+			Balance: balance,
+			Info:    core.NodeInfo{Name: fmt.Sprintf("node%d", i)},
+		}
+		if idx := strings.LastIndex(arg, "="); idx >= 0 {
+			if node.Staked, err = parseDXN(arg[idx+1:]); err != nil {
+				utils.Fatalf("Node %d: %v", i, err)
+			}
+			arg = arg[:idx]
+		}
+		if node.PublicKey, err = parseNodeKey(arg); err != nil {
+			utils.Fatalf("Node %d: invalid key %q: %v", i, arg, err)
+		}
+		nodes[i] = node
+	}
+	genesis, err := core.NewDexconGenesis(&config, nodes)
+	if err != nil {
+		utils.Fatalf("Invalid genesis: %v", err)
+	}
+	if alloc := ctx.String(genesisAllocFlag.Name); alloc != "" {
+		for _, entry := range strings.Split(alloc, ",") {
+			parts := strings.SplitN(entry, "=", 2)
+			if len(parts) != 2 || !common.IsHexAddress(parts[0]) {
+				utils.Fatalf("Invalid --%s entry %q", genesisAllocFlag.Name, entry)
+			}
+			amount, err := parseDXN(parts[1])
+			if err != nil {
+				utils.Fatalf("Invalid --%s entry %q: %v", genesisAllocFlag.Name, entry, err)
+			}
+			genesis.Fund(common.HexToAddress(parts[0]), amount)
+		}
+	}
+
+	out, err := json.MarshalIndent(genesis, "", "  ")
+	if err != nil {
+		utils.Fatalf("Failed to encode genesis: %v", err)
+	}
+	if path := ctx.String(genesisOutputFlag.Name); path != "" {
+		return ioutil.WriteFile(path, out, 0644)
+	}
+	fmt.Println(string(out))
+	return nil
+}
+
+func genesisCheck(ctx *cli.Context) error {
+	genesisPath := ctx.Args().First()
+	if len(genesisPath) == 0 {
+		utils.Fatalf("Must supply path to genesis JSON file")
+	}
+	file, err := os.Open(genesisPath)
+	if err != nil {
+		utils.Fatalf("Failed to read genesis file: %v", err)
+	}
+	defer file.Close()
+
+	genesis := new(core.Genesis)
+	if err := json.NewDecoder(file).Decode(genesis); err != nil {
+		utils.Fatalf("Invalid genesis file: %v", err)
+	}
+	errs := core.ValidateDexconGenesis(genesis)
+	for _, err := range errs {
+		fmt.Println(err)
+	}
+	if len(errs) > 0 {
+		utils.Fatalf("Found %d problems in %s", len(errs), genesisPath)
+	}
+	fmt.Println("Genesis is valid")
+	return nil
+}
@@ -34,6 +34,7 @@ import (
 	"github.com/dexon-foundation/dexon/dex"
 	"github.com/dexon-foundation/dexon/eth"
 	"github.com/dexon-foundation/dexon/ethclient"
+	_ "github.com/dexon-foundation/dexon/indexer/sqlindexer" // Registers the SQL indexer
 	"github.com/dexon-foundation/dexon/internal/debug"
 	"github.com/dexon-foundation/dexon/log"
 	"github.com/dexon-foundation/dexon/metrics"
@@ -85,6 +86,7 @@ var (
 		utils.TxPoolLifetimeFlag,
 		utils.SyncModeFlag,
 		utils.GCModeFlag,
+		utils.StateRetentionFlag,
 		utils.LightServFlag,
 		utils.LightPeersFlag,
 		utils.LightKDFFlag,
@@ -99,6 +101,10 @@ var (
 		utils.MaxPendingPeersFlag,
 		utils.BlockProposerEnabledFlag,
 		utils.ConsensusDMomentFlag,
+		utils.PeerSetGracePeriodFlag,
+		utils.DKGKeyPasswordFileFlag,
+		utils.DKGKeyRetentionFlag,
+		utils.CoreBlockRetentionFlag,
 		utils.MiningEnabledFlag,
 		utils.MinerThreadsFlag,
 		utils.MinerLegacyThreadsFlag,
@@ -121,7 +127,8 @@ var (
 		utils.NodeKeyFileFlag,
 		utils.NodeKeyHexFlag,
 		utils.DeveloperFlag,
-		utils.DeveloperPeriodFlag,
+		utils.DeveloperChainsFlag,
+		utils.DeveloperLegacyPeriodFlag,
 		utils.TestnetFlag,
 		utils.TaipeiFlag,
 		utils.VMEnableDebugFlag,
@@ -140,6 +147,7 @@ var (
 		utils.IndexerEnableFlag,
 		utils.IndexerPluginFlag,
 		utils.IndexerPluginFlagsFlag,
+		utils.IndexerNamesFlag,
 		configFileFlag,
 	}
 
@@ -188,7 +196,14 @@ func init() {
 		exportPreimagesCommand,
 		copydbCommand,
 		removedbCommand,
+		pruneStateCommand,
 		dumpCommand,
+		// See coredbcmd.go:
+		coredbCommand,
+		// See genesiscmd.go:
+		genesisCommand,
+		// See snapshotcmd.go:
+		snapshotCommand,
 		// See monitorcmd.go:
 		monitorCommand,
 		// See accountcmd.go:
@@ -337,7 +352,7 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 		}
 	}()
 	// Start auxiliary services if enabled
-	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) || ctx.GlobalBool(utils.DeveloperFlag.Name) {
+	if ctx.GlobalBool(utils.MiningEnabledFlag.Name) {
 		// Mining only makes sense if a full Ethereum node is running
 		if ctx.GlobalString(utils.SyncModeFlag.Name) == "light" {
 			utils.Fatalf("Light clients do not support mining")
@@ -362,7 +377,7 @@ func startNode(ctx *cli.Context, stack *node.Node) {
 		}
 	}
 
-	if ctx.GlobalBool(utils.BlockProposerEnabledFlag.Name) {
+	if ctx.GlobalBool(utils.BlockProposerEnabledFlag.Name) || ctx.GlobalBool(utils.DeveloperFlag.Name) {
 		if ctx.GlobalString(utils.SyncModeFlag.Name) == "light" {
 			utils.Fatalf("Light clients do not support proposing")
 		}
@@ -0,0 +1,139 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/dexon-foundation/dexon/cmd/utils"
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core"
+	"gopkg.in/urfave/cli.v1"
+)
+
+var (
+	snapshotRoundFlag = cli.Uint64Flag{
+		Name:  "round",
+		Usage: "Round whose first block the snapshot is taken at",
+	}
+	snapshotHashFlag = cli.StringFlag{
+		Name:  "hash",
+		Usage: "Trusted hash of the snapshot head block",
+	}
+
+	snapshotCommand = cli.Command{
+		Name:     "snapshot",
+		Usage:    "Export and import state snapshots",
+		Category: "BLOCKCHAIN COMMANDS",
+		Description: `
+Snapshots bootstrap a new node from the state at a round boundary instead of
+syncing the whole chain.
+
+A snapshot holds the full state at the first block of a round, the governance
+states of the rounds whose configurations are still in use, the compaction
+chain tip and the blocks of the recent rounds, which carry the core blocks
+the consensus core syncs from.`,
+		Subcommands: []cli.Command{
+			{
+				Name:      "export",
+				Usage:     "Export the state at a round boundary into a file",
+				Action:    utils.MigrateFlags(exportSnapshot),
+				ArgsUsage: "<filename>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+					snapshotRoundFlag,
+				},
+				Description: `
+    gdex snapshot export --round N <filename>
+
+Exports the snapshot at the first block of round N. If the file name ends
+with .gz, the output is gzipped.`,
+			},
+			{
+				Name:      "import",
+				Usage:     "Import a snapshot into an empty database",
+				Action:    utils.MigrateFlags(importSnapshot),
+				ArgsUsage: "<filename>",
+				Flags: []cli.Flag{
+					utils.DataDirFlag,
+					utils.CacheFlag,
+					utils.SyncModeFlag,
+					snapshotHashFlag,
+				},
+				Description: `
+    gdex snapshot import --hash <blockHash> <filename>
+
+Imports a snapshot into a database initialized with the genesis block only.
+The snapshot head must match the trusted block hash, everything else in the
+snapshot is verified against it. The node syncs from the snapshot head once
+started.
+
+The blocks between the genesis block and the first block of the snapshot are
+not imported and are never synced afterwards, so the node can't serve them,
+nor the states and receipts of them, to its peers or over RPC.`,
+			},
+		},
+	}
+)
+
+// exportSnapshot exports the state at a round boundary into a file.
+func exportSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	if !ctx.IsSet(snapshotRoundFlag.Name) {
+		utils.Fatalf("The --%s flag is required.", snapshotRoundFlag.Name)
+	}
+	stack := makeFullNode(ctx)
+	chain, _ := utils.MakeChain(ctx, stack)
+	defer chain.Stop()
+
+	start := time.Now()
+	if err := utils.ExportSnapshot(chain, ctx.Args().First(), ctx.Uint64(snapshotRoundFlag.Name)); err != nil {
+		utils.Fatalf("Export error: %v\n", err)
+	}
+	fmt.Printf("Export done in %v\n", time.Since(start))
+	return nil
+}
+
+// importSnapshot imports a snapshot verified against a trusted block hash.
+func importSnapshot(ctx *cli.Context) error {
+	if len(ctx.Args()) < 1 {
+		utils.Fatalf("This command requires an argument.")
+	}
+	hash := ctx.String(snapshotHashFlag.Name)
+	if len(common.FromHex(hash)) != common.HashLength {
+		utils.Fatalf("The --%s flag must be a block hash.", snapshotHashFlag.Name)
+	}
+	stack := makeFullNode(ctx)
+	db := utils.MakeChainDatabase(ctx, stack)
+	defer db.Close()
+	if _, _, err := core.SetupGenesisBlock(db, utils.MakeGenesis(ctx)); err != nil {
+		utils.Fatalf("Failed to set up genesis block: %v", err)
+	}
+
+	start := time.Now()
+	if err := utils.ImportSnapshot(db, ctx.Args().First(), common.HexToHash(hash)); err != nil {
+		utils.Fatalf("Import error: %v\n", err)
+	}
+	fmt.Printf("Import done in %v\n", time.Since(start))
+	return nil
+}
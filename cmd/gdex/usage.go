@@ -76,6 +76,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.TaipeiFlag,
 			utils.SyncModeFlag,
 			utils.GCModeFlag,
+			utils.StateRetentionFlag,
 			utils.EthStatsURLFlag,
 			utils.IdentityFlag,
 			utils.LightServFlag,
@@ -88,7 +89,7 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "DEVELOPER CHAIN",
 		Flags: []cli.Flag{
 			utils.DeveloperFlag,
-			utils.DeveloperPeriodFlag,
+			utils.DeveloperChainsFlag,
 		},
 	},
 	{
@@ -187,6 +188,11 @@ var AppHelpFlagGroups = []flagGroup{
 		Name: "BLOCK PROPOSER",
 		Flags: []cli.Flag{
 			utils.BlockProposerEnabledFlag,
+			utils.ConsensusDMomentFlag,
+			utils.PeerSetGracePeriodFlag,
+			utils.DKGKeyPasswordFileFlag,
+			utils.DKGKeyRetentionFlag,
+			utils.CoreBlockRetentionFlag,
 		},
 	},
 	{
@@ -244,6 +250,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.IndexerEnableFlag,
 			utils.IndexerPluginFlag,
 			utils.IndexerPluginFlagsFlag,
+			utils.IndexerNamesFlag,
 		},
 	},
 	{
@@ -258,6 +265,7 @@ var AppHelpFlagGroups = []flagGroup{
 			utils.MinerLegacyGasPriceFlag,
 			utils.MinerLegacyEtherbaseFlag,
 			utils.MinerLegacyExtraDataFlag,
+			utils.DeveloperLegacyPeriodFlag,
 		},
 	},
 	{
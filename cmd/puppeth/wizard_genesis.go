@@ -18,6 +18,7 @@ package main
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -54,18 +55,24 @@ func (w *wizard) makeGenesis() {
 	}
 	// Figure out which consensus engine to choose
 	fmt.Println()
-	fmt.Println("Which consensus engine to use? (default = clique)")
+	fmt.Println("Which consensus engine to use? (default = dexcon)")
 	fmt.Println(" 1. Ethash - proof-of-work")
 	fmt.Println(" 2. Clique - proof-of-authority")
+	fmt.Println(" 3. Dexcon - DEXON consensus")
 
 	choice := w.read()
 	switch {
+	case choice == "" || choice == "3":
+		// Dexcon genesis stakes the nodes and funds accounts differently
+		w.makeDexconGenesis()
+		return
+
 	case choice == "1":
 		// In case of ethash, we're pretty much done
 		genesis.Config.Ethash = new(params.EthashConfig)
 		genesis.ExtraData = make([]byte, 32)
 
-	case choice == "" || choice == "2":
+	case choice == "2":
 		// In the case of clique, configure the consensus parameters
 		genesis.Difficulty = big.NewInt(1)
 		genesis.Config.Clique = &params.CliqueConfig{
@@ -139,6 +146,112 @@ func (w *wizard) makeGenesis() {
 	w.conf.flush()
 }
 
+// makeDexconGenesis creates a new Dexcon genesis based on some user input.
+func (w *wizard) makeDexconGenesis() {
+	ether := big.NewInt(params.Ether)
+
+	config := *params.TestnetChainConfig
+	dexcon := *config.Dexcon
+	config.Dexcon = &dexcon
+
+	fmt.Println()
+	fmt.Printf("What should the genesis CRS text be? (default = %q)\n", dexcon.GenesisCRSText)
+	dexcon.GenesisCRSText = w.readDefaultString(dexcon.GenesisCRSText)
+
+	fmt.Println()
+	fmt.Println("Which account owns the governance contract? (mandatory)")
+	for {
+		if owner := w.readAddress(); owner != nil {
+			dexcon.Owner = *owner
+			break
+		}
+	}
+	fmt.Println()
+	fmt.Printf("How many lattice chains should run? (default = %d)\n", dexcon.NumChains)
+	dexcon.NumChains = uint32(w.readDefaultInt(int(dexcon.NumChains)))
+
+	fmt.Println()
+	fmt.Printf("How many nodes should be in the notary set? (default = %d)\n", dexcon.NotarySetSize)
+	dexcon.NotarySetSize = uint32(w.readDefaultInt(int(dexcon.NotarySetSize)))
+
+	fmt.Println()
+	fmt.Printf("How many nodes should be in the DKG set? (default = %d)\n", dexcon.DKGSetSize)
+	dexcon.DKGSetSize = uint32(w.readDefaultInt(int(dexcon.DKGSetSize)))
+
+	fmt.Println()
+	fmt.Printf("How many milliseconds should a round take? (default = %d)\n", dexcon.RoundInterval)
+	dexcon.RoundInterval = uint64(w.readDefaultInt(int(dexcon.RoundInterval)))
+
+	fmt.Println()
+	fmt.Printf("How many milliseconds should lambda BA be? (default = %d)\n", dexcon.LambdaBA)
+	dexcon.LambdaBA = uint64(w.readDefaultInt(int(dexcon.LambdaBA)))
+
+	fmt.Println()
+	fmt.Printf("How many milliseconds should lambda DKG be? (default = %d)\n", dexcon.LambdaDKG)
+	dexcon.LambdaDKG = uint64(w.readDefaultInt(int(dexcon.LambdaDKG)))
+
+	fmt.Println()
+	fmt.Printf("How many milliseconds should blocks take at least? (default = %d)\n", dexcon.MinBlockInterval)
+	dexcon.MinBlockInterval = uint64(w.readDefaultInt(int(dexcon.MinBlockInterval)))
+
+	fmt.Println()
+	minStake := new(big.Int).Div(dexcon.MinStake, ether)
+	fmt.Printf("How many DXN should nodes stake at least? (default = %v)\n", minStake)
+	dexcon.MinStake = new(big.Int).Mul(w.readDefaultBigInt(minStake), ether)
+
+	// Collect the public keys of the staked nodes
+	fmt.Println()
+	fmt.Println("Which nodes are staked? (public keys, mandatory at least one)")
+	var keys []*ecdsa.PublicKey
+	for {
+		if key := w.readPublicKey(); key != nil {
+			keys = append(keys, key)
+			continue
+		}
+		if len(keys) > 0 {
+			break
+		}
+	}
+	nodes := make([]core.DexconNode, len(keys))
+	for i, key := range keys {
+		fmt.Println()
+		fmt.Printf("How many DXN should node %d stake? (default = %v)\n", i, minStake)
+		nodes[i] = core.DexconNode{
+			PublicKey: key,
+			Staked:    new(big.Int).Mul(w.readDefaultBigInt(minStake), ether),
+		}
+		fmt.Println()
+		fmt.Printf("What's the name of node %d? (default = node%d)\n", i, i)
+		nodes[i].Info.Name = w.readDefaultString(fmt.Sprintf("node%d", i))
+	}
+	genesis, err := core.NewDexconGenesis(&config, nodes)
+	if err != nil {
+		log.Error("Invalid Dexcon genesis", "err", err)
+		return
+	}
+	// Consensus all set, just ask for initial funds and go
+	fmt.Println()
+	fmt.Println("Which accounts should be pre-funded? (advisable at least one)")
+	for {
+		address := w.readAddress()
+		if address == nil {
+			break
+		}
+		fmt.Println()
+		fmt.Println("How many DXN should the account get? (default = 1000000)")
+		genesis.Fund(*address, new(big.Int).Mul(w.readDefaultBigInt(big.NewInt(1000000)), ether))
+	}
+	fmt.Println()
+	fmt.Println("Specify your chain/network ID if you want an explicit one (default = random)")
+	config.ChainID = new(big.Int).SetUint64(uint64(w.readDefaultInt(rand.Intn(65536))))
+
+	// All done, store the genesis and flush to disk
+	log.Info("Configured new Dexcon genesis block")
+
+	w.conf.Genesis = genesis
+	w.conf.flush()
+}
+
 // importGenesis imports a Geth genesis spec into puppeth.
 func (w *wizard) importGenesis() {
 	// Request the genesis JSON spec URL from the user
@@ -0,0 +1,160 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"crypto/ecdsa"
+	"fmt"
+	"path/filepath"
+
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/dex"
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/node"
+	"github.com/dexon-foundation/dexon/p2p"
+	"github.com/dexon-foundation/dexon/rpc"
+)
+
+// developerNodes is the number of nodes staked in the developer genesis.
+// The DKG threshold of a set of four is two, the least the BLS library
+// supports, so the developer chain gets randomness and changes rounds.
+const developerNodes = 4
+
+// developerSignerKeys derives the keys of the in-process signers from the
+// node key, so a developer chain kept in a data directory restarts with the
+// same signers.
+func developerSignerKeys(nodeKey *ecdsa.PrivateKey) []*ecdsa.PrivateKey {
+	keys := make([]*ecdsa.PrivateKey, developerNodes-1)
+	for i := range keys {
+		seed := crypto.Keccak256(crypto.FromECDSA(nodeKey), []byte(fmt.Sprintf("signer%d", i)))
+		key, err := crypto.ToECDSA(seed)
+		if err != nil {
+			Fatalf("Failed to derive developer signer key: %v", err)
+		}
+		keys[i] = key
+	}
+	return keys
+}
+
+// developerSigners is a service running the other nodes of the developer
+// genesis in-process, connected to the node over the loopback interface,
+// each proposing blocks with its own consensus core.
+type developerSigners struct {
+	config  *dex.Config
+	dataDir string // Directory of the signer data, empty for memory
+
+	stacks []*node.Node
+	dexes  []*dex.Dexon
+}
+
+// RegisterDeveloperSigners registers the service running the in-process
+// signers of the developer chain of the config.
+func RegisterDeveloperSigners(stack *node.Node, cfg *dex.Config) {
+	err := stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		s := &developerSigners{config: cfg}
+		if dir := stack.InstanceDir(); dir != "" {
+			s.dataDir = filepath.Join(dir, "signers")
+		}
+		return s, nil
+	})
+	if err != nil {
+		Fatalf("Failed to register the developer signers: %v", err)
+	}
+}
+
+func (s *developerSigners) Protocols() []p2p.Protocol { return nil }
+func (s *developerSigners) APIs() []rpc.API           { return nil }
+
+// Start starts the signers, connects them to each other and to the node and
+// starts proposing blocks.
+func (s *developerSigners) Start(srvr *p2p.Server) error {
+	for i, key := range developerSignerKeys(s.config.PrivateKey) {
+		if err := s.startSigner(i, key); err != nil {
+			s.Stop()
+			return err
+		}
+	}
+	for i, stack := range s.stacks {
+		stack.Server().AddPeer(srvr.Self())
+		for _, peer := range s.stacks[i+1:] {
+			stack.Server().AddPeer(peer.Server().Self())
+		}
+	}
+	for _, d := range s.dexes {
+		if err := d.StartProposing(); err != nil {
+			s.Stop()
+			return err
+		}
+	}
+	log.Info("Started developer signers", "count", len(s.stacks))
+	return nil
+}
+
+func (s *developerSigners) startSigner(index int, key *ecdsa.PrivateKey) error {
+	stack, err := node.New(&node.Config{
+		Name:    fmt.Sprintf("signer%d", index),
+		DataDir: s.dataDir,
+		NoUSB:   true,
+		P2P: p2p.Config{
+			PrivateKey:  key,
+			MaxPeers:    developerNodes * 2,
+			ListenAddr:  "127.0.0.1:0",
+			NoDiscovery: true,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	config := dex.DefaultConfig
+	config.Genesis = s.config.Genesis
+	config.NetworkId = s.config.NetworkId
+	config.BlockProposerEnabled = true
+	config.DMoment = s.config.DMoment
+	config.PrivateKey = key
+	config.TxPool.Journal = ""
+	var d *dex.Dexon
+	err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		fullNode, err := dex.New(ctx, &config)
+		if err != nil {
+			return nil, err
+		}
+		d = fullNode
+		return fullNode, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := stack.Start(); err != nil {
+		return err
+	}
+	s.stacks = append(s.stacks, stack)
+	s.dexes = append(s.dexes, d)
+	return nil
+}
+
+// Stop stops the signers.
+func (s *developerSigners) Stop() error {
+	for i, stack := range s.stacks {
+		s.dexes[i].StopProposing()
+		if err := stack.Stop(); err != nil {
+			log.Error("Failed to stop developer signer", "index", i, "err", err)
+		}
+	}
+	s.stacks, s.dexes = nil, nil
+	return nil
+}
@@ -0,0 +1,47 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"testing"
+
+	"github.com/dexon-foundation/dexon/crypto"
+)
+
+func TestDeveloperSignerKeys(t *testing.T) {
+	nodeKey, _ := crypto.GenerateKey()
+	keys := developerSignerKeys(nodeKey)
+	if len(keys) != developerNodes-1 {
+		t.Fatalf("signer count mismatch: have %d, want %d", len(keys), developerNodes-1)
+	}
+	addrs := map[string]bool{crypto.PubkeyToAddress(nodeKey.PublicKey).Hex(): true}
+	for i, key := range keys {
+		addr := crypto.PubkeyToAddress(key.PublicKey).Hex()
+		if addrs[addr] {
+			t.Errorf("signer %d: duplicate key", i)
+		}
+		addrs[addr] = true
+	}
+
+	// The signers of a node key are the same on restart.
+	for i, key := range developerSignerKeys(nodeKey) {
+		if key.D.Cmp(keys[i].D) != 0 {
+			t.Errorf("signer %d: key mismatch", i)
+		}
+	}
+}
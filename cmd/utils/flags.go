@@ -45,6 +45,8 @@ import (
 	"github.com/dexon-foundation/dexon/eth/gasprice"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/ethstats"
+	"github.com/dexon-foundation/dexon/indexer"
+	"github.com/dexon-foundation/dexon/ldex"
 	"github.com/dexon-foundation/dexon/les"
 	"github.com/dexon-foundation/dexon/log"
 	"github.com/dexon-foundation/dexon/metrics"
@@ -105,6 +107,10 @@ func NewApp(gitCommit, usage string) *cli.App {
 	return app
 }
 
+// developerDMomentDelay is the delay before the consensus core starts in
+// developer mode, leaving time for the node to start.
+const developerDMomentDelay = 5 * time.Second
+
 // These are all the command line flags we support.
 // If you add to this list, please remember to include the
 // flag in the appropriate command definition.
@@ -146,11 +152,16 @@ var (
 	}
 	DeveloperFlag = cli.BoolFlag{
 		Name:  "dev",
-		Usage: "Ephemeral proof-of-authority network with a pre-funded developer account, mining enabled",
+		Usage: "Ephemeral DEXON network of in-process nodes with a pre-funded developer account, block proposing enabled",
+	}
+	DeveloperChainsFlag = cli.IntFlag{
+		Name:  "dev.chains",
+		Usage: "Number of lattice chains to run in developer mode",
+		Value: 1,
 	}
-	DeveloperPeriodFlag = cli.IntFlag{
+	DeveloperLegacyPeriodFlag = cli.IntFlag{
 		Name:  "dev.period",
-		Usage: "Block period to use in developer mode (0 = mine only if transaction pending)",
+		Usage: "Block period to use in developer mode (deprecated, blocks are proposed by the consensus core)",
 	}
 	IdentityFlag = cli.StringFlag{
 		Name:  "identity",
@@ -169,9 +180,14 @@ var (
 	}
 	GCModeFlag = cli.StringFlag{
 		Name:  "gcmode",
-		Usage: `Blockchain garbage collection mode ("full", "archive")`,
+		Usage: `Blockchain garbage collection mode ("full", "archive", "round")`,
 		Value: "full",
 	}
+	StateRetentionFlag = cli.Uint64Flag{
+		Name:  "state.retention",
+		Usage: "Number of recent blocks whose state is kept in round garbage collection mode",
+		Value: 128,
+	}
 	LightServFlag = cli.IntFlag{
 		Name:  "lightserv",
 		Usage: "Maximum percentage of time allowed for serving LES requests (0-90)",
@@ -329,6 +345,25 @@ var (
 		Name:  "dmoment",
 		Usage: "Set the DMoment of DEXON Consensus (unix timestamp)",
 	}
+	PeerSetGracePeriodFlag = cli.DurationFlag{
+		Name:  "peerset.grace",
+		Usage: "Duration to keep notary and DKG connections of the previous round",
+		Value: dex.DefaultConfig.PeerSetGracePeriod,
+	}
+	DKGKeyPasswordFileFlag = cli.StringFlag{
+		Name:  "dkgkey.password",
+		Usage: "Password file used to encrypt DKG private keys (default: derived from node key)",
+	}
+	DKGKeyRetentionFlag = cli.Uint64Flag{
+		Name:  "dkgkey.retention",
+		Usage: "Number of recent rounds whose DKG private keys are kept (0 = keep all)",
+		Value: dex.DefaultConfig.DKGKeyRetention,
+	}
+	CoreBlockRetentionFlag = cli.Uint64Flag{
+		Name:  "coreblock.retention",
+		Usage: "Number of recent rounds whose consensus core blocks are kept (0 = keep all)",
+		Value: dex.DefaultConfig.CoreBlockRetention,
+	}
 	// Miner settings
 	MiningEnabledFlag = cli.BoolFlag{
 		Name:  "mine",
@@ -659,6 +694,11 @@ var (
 		Usage: "External indexer plugin's flags if needed",
 		Value: "",
 	}
+	IndexerNamesFlag = cli.StringFlag{
+		Name:  "indexer.names",
+		Usage: "Comma separated compiled-in indexers to run, each as name or name=flags",
+		Value: "",
+	}
 )
 
 // MakeDataDir retrieves the currently requested data directory, terminating
@@ -990,9 +1030,9 @@ func SetP2PConfig(ctx *cli.Context, cfg *p2p.Config) {
 	}
 
 	if ctx.GlobalBool(DeveloperFlag.Name) {
-		// --dev mode can't use p2p networking.
-		cfg.MaxPeers = 0
-		cfg.ListenAddr = ":0"
+		// --dev mode only connects to its in-process signers.
+		cfg.MaxPeers = developerNodes * 2
+		cfg.ListenAddr = "127.0.0.1:0"
 		cfg.NoDiscovery = true
 		cfg.DiscoveryV5 = false
 	}
@@ -1008,6 +1048,11 @@ func SetNodeConfig(ctx *cli.Context, cfg *node.Config) {
 
 	setDataDir(ctx, cfg)
 
+	if ctx.GlobalBool(DeveloperFlag.Name) && cfg.P2P.PrivateKey == nil {
+		// Pin the node key, the developer genesis stakes it.
+		cfg.P2P.PrivateKey = cfg.NodeKey()
+	}
+
 	if ctx.GlobalIsSet(KeyStoreDirFlag.Name) {
 		cfg.KeyStoreDir = ctx.GlobalString(KeyStoreDirFlag.Name)
 	}
@@ -1213,10 +1258,14 @@ func SetDexConfig(ctx *cli.Context, stack *node.Node, cfg *dex.Config) {
 	}
 	cfg.DatabaseHandles = makeDatabaseHandles()
 
-	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
-		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" && gcmode != "round" {
+		Fatalf("--%s must be either 'full', 'archive' or 'round'", GCModeFlag.Name)
 	}
 	cfg.NoPruning = ctx.GlobalString(GCModeFlag.Name) == "archive"
+	cfg.RoundPruning = ctx.GlobalString(GCModeFlag.Name) == "round"
+	if ctx.GlobalIsSet(StateRetentionFlag.Name) {
+		cfg.StateRetention = ctx.GlobalUint64(StateRetentionFlag.Name)
+	}
 
 	if ctx.GlobalIsSet(CacheFlag.Name) || ctx.GlobalIsSet(CacheTrieFlag.Name) {
 		cfg.TrieCleanCache = ctx.GlobalInt(CacheFlag.Name) * ctx.GlobalInt(CacheTrieFlag.Name) / 100
@@ -1273,8 +1322,25 @@ func SetDexConfig(ctx *cli.Context, stack *node.Node, cfg *dex.Config) {
 			Fatalf("Failed to unlock developer account: %v", err)
 		}
 		log.Info("Using developer account", "address", developer.Address)
+		if ctx.GlobalIsSet(DeveloperLegacyPeriodFlag.Name) {
+			log.Warn("The flag --dev.period is deprecated and has no effect")
+		}
 
-		cfg.Genesis = core.DeveloperGenesisBlock(uint64(ctx.GlobalInt(DeveloperPeriodFlag.Name)), developer.Address)
+		// The node stakes with the node key, the consensus core signs with,
+		// along with the in-process signers.
+		if cfg.PrivateKey == nil {
+			Fatalf("Developer mode requires the node key")
+		}
+		chains := ctx.GlobalInt(DeveloperChainsFlag.Name)
+		if chains < 1 {
+			Fatalf("Option %q: must be positive", DeveloperChainsFlag.Name)
+		}
+		nodes := []*ecdsa.PublicKey{&cfg.PrivateKey.PublicKey}
+		for _, key := range developerSignerKeys(cfg.PrivateKey) {
+			nodes = append(nodes, &key.PublicKey)
+		}
+		cfg.Genesis = core.DeveloperDexconGenesisBlock(developer.Address, nodes, uint32(chains))
+		cfg.BlockProposerEnabled = true
 	}
 	// TODO(fjl): move trie cache generations into config
 	if gen := ctx.GlobalInt(TrieCacheGenFlag.Name); gen > 0 {
@@ -1282,6 +1348,8 @@ func SetDexConfig(ctx *cli.Context, stack *node.Node, cfg *dex.Config) {
 	}
 	if ctx.GlobalIsSet(ConsensusDMomentFlag.Name) {
 		cfg.DMoment = int64(ctx.GlobalUint64(ConsensusDMomentFlag.Name))
+	} else if ctx.GlobalBool(DeveloperFlag.Name) {
+		cfg.DMoment = time.Now().Add(developerDMomentDelay).Unix()
 	} else {
 		// TODO(jimmy): default DMoment should be set based on networkId.
 		now := time.Now()
@@ -1291,6 +1359,23 @@ func SetDexConfig(ctx *cli.Context, stack *node.Node, cfg *dex.Config) {
 			0, now.Location()).Unix()
 	}
 
+	if ctx.GlobalIsSet(PeerSetGracePeriodFlag.Name) {
+		cfg.PeerSetGracePeriod = ctx.GlobalDuration(PeerSetGracePeriodFlag.Name)
+	}
+	if ctx.GlobalIsSet(DKGKeyPasswordFileFlag.Name) {
+		text, err := ioutil.ReadFile(ctx.GlobalString(DKGKeyPasswordFileFlag.Name))
+		if err != nil {
+			Fatalf("Failed to read DKG key password file: %v", err)
+		}
+		cfg.DKGKeyPassphrase = strings.TrimRight(strings.Split(string(text), "\n")[0], "\r")
+	}
+	if ctx.GlobalIsSet(DKGKeyRetentionFlag.Name) {
+		cfg.DKGKeyRetention = ctx.GlobalUint64(DKGKeyRetentionFlag.Name)
+	}
+	if ctx.GlobalIsSet(CoreBlockRetentionFlag.Name) {
+		cfg.CoreBlockRetention = ctx.GlobalUint64(CoreBlockRetentionFlag.Name)
+	}
+
 	// Set indexer config.
 	setIndexerConfig(ctx, cfg)
 }
@@ -1303,6 +1388,20 @@ func setIndexerConfig(ctx *cli.Context, cfg *dex.Config) {
 
 	cfg.Indexer.Plugin = ctx.GlobalString(IndexerPluginFlag.Name)
 	cfg.Indexer.PluginFlags = ctx.GlobalString(IndexerPluginFlagsFlag.Name)
+	if ctx.GlobalIsSet(IndexerNamesFlag.Name) {
+		cfg.Indexer.Indexers = nil
+		for _, entry := range strings.Split(ctx.GlobalString(IndexerNamesFlag.Name), ",") {
+			if entry = strings.TrimSpace(entry); entry == "" {
+				continue
+			}
+			parts := strings.SplitN(entry, "=", 2)
+			ic := indexer.IndexerConfig{Name: parts[0]}
+			if len(parts) == 2 {
+				ic.Flags = parts[1]
+			}
+			cfg.Indexer.Indexers = append(cfg.Indexer.Indexers, ic)
+		}
+	}
 }
 
 // SetDashboardConfig applies dashboard related command line flags to the config.
@@ -1316,9 +1415,14 @@ func SetDashboardConfig(ctx *cli.Context, cfg *dashboard.Config) {
 func RegisterDexService(stack *node.Node, cfg *dex.Config) {
 	var err error
 	if cfg.SyncMode == downloader.LightSync {
-		//err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
-		//	return les.New(ctx, cfg)
-		//})
+		err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+			return ldex.New(ctx, &ldex.Config{
+				Genesis:         cfg.Genesis,
+				NetworkId:       cfg.NetworkId,
+				DatabaseCache:   cfg.DatabaseCache,
+				DatabaseHandles: cfg.DatabaseHandles,
+			})
+		})
 	} else {
 		err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
 			cfg.PrivateKey = ctx.ServerConfig.PrivateKey
@@ -1443,11 +1547,13 @@ func MakeChain(ctx *cli.Context, stack *node.Node) (chain *core.BlockChain, chai
 			}, nil, false)
 		}
 	}
-	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" {
-		Fatalf("--%s must be either 'full' or 'archive'", GCModeFlag.Name)
+	if gcmode := ctx.GlobalString(GCModeFlag.Name); gcmode != "full" && gcmode != "archive" && gcmode != "round" {
+		Fatalf("--%s must be either 'full', 'archive' or 'round'", GCModeFlag.Name)
 	}
 	cache := &core.CacheConfig{
 		Disabled:       ctx.GlobalString(GCModeFlag.Name) == "archive",
+		RoundPruning:   ctx.GlobalString(GCModeFlag.Name) == "round",
+		StateRetention: ctx.GlobalUint64(StateRetentionFlag.Name),
 		TrieCleanLimit: eth.DefaultConfig.TrieCleanCache,
 		TrieDirtyLimit: eth.DefaultConfig.TrieDirtyCache,
 		TrieTimeLimit:  eth.DefaultConfig.TrieTimeout,
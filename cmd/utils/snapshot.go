@@ -0,0 +1,451 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"strings"
+	"time"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	dexCore "github.com/dexon-foundation/dexon-consensus/core"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/verifier"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/rlp"
+	"github.com/dexon-foundation/dexon/trie"
+)
+
+// snapshotVersion is the version of the snapshot file format.
+const snapshotVersion = 1
+
+var (
+	errSnapshotVersion   = errors.New("unsupported snapshot version")
+	errSnapshotUntrusted = errors.New("snapshot head does not match the trusted hash")
+	errSnapshotGenesis   = errors.New("snapshot belongs to a different genesis")
+	errSnapshotNotEmpty  = errors.New("database already contains blocks beyond genesis")
+)
+
+// snapshotHeader is the first item of a snapshot file. It is followed by
+// Blocks snapshotBlock items, GovStates governance states and the nodes of
+// the state trie at the head block until the end of the file.
+type snapshotHeader struct {
+	Version   uint64
+	Genesis   common.Hash
+	Round     uint64
+	Number    uint64
+	Hash      common.Hash
+	TipHash   coreCommon.Hash // Compaction chain tip at the head block
+	TipHeight uint64
+	Blocks    uint64
+	GovStates uint64
+}
+
+// snapshotBlock is a block of the snapshot along with the data a node
+// needs to continue the chain from it.
+type snapshotBlock struct {
+	Block    *types.Block
+	Td       *big.Int
+	Receipts []*types.ReceiptForStorage
+}
+
+// snapshotNode is a state trie node or a contract code keyed by its hash.
+type snapshotNode struct {
+	Hash common.Hash
+	Blob []byte
+}
+
+// ExportSnapshot exports the state at the first block of the given round
+// into the specified file. Besides the full state trie, the snapshot carries
+// the blocks from the first block of the round ConfigRoundShift rounds ago,
+// which contain the core blocks the consensus core syncs from, and the
+// governance states of the rounds whose configurations are still in use.
+func ExportSnapshot(bc *core.BlockChain, fn string, round uint64) error {
+	if round == 0 {
+		return errors.New("snapshot of round 0 is the genesis")
+	}
+	gov := core.NewGovernance(core.NewGovernanceStateDB(bc))
+	number := gov.GetRoundHeight(round)
+	head := bc.GetBlockByNumber(number)
+	if number == 0 || head == nil {
+		return fmt.Errorf("round %d not reached", round)
+	}
+	statedb, err := bc.StateAt(head.Root())
+	if err != nil {
+		return fmt.Errorf("state of block #%d missing: %v", number, err)
+	}
+	var tip coreTypes.Block
+	if err := rlp.DecodeBytes(head.Header().DexconMeta, &tip); err != nil {
+		return fmt.Errorf("invalid core block in block #%d: %v", number, err)
+	}
+
+	// Collect the round heights whose governance states are exported.
+	var heights []uint64
+	first := round
+	if first > dexCore.ConfigRoundShift {
+		first -= dexCore.ConfigRoundShift
+	} else {
+		first = 1
+	}
+	for r := first; r <= round; r++ {
+		heights = append(heights, gov.GetRoundHeight(r))
+	}
+	from := heights[0]
+
+	log.Info("Exporting snapshot", "file", fn, "round", round, "number", number, "hash", head.Hash())
+
+	fh, err := os.OpenFile(fn, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, os.ModePerm)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var writer io.Writer = fh
+	if strings.HasSuffix(fn, ".gz") {
+		writer = gzip.NewWriter(writer)
+		defer writer.(*gzip.Writer).Close()
+	}
+	header := &snapshotHeader{
+		Version:   snapshotVersion,
+		Genesis:   bc.Genesis().Hash(),
+		Round:     round,
+		Number:    number,
+		Hash:      head.Hash(),
+		TipHash:   tip.Hash,
+		TipHeight: tip.Finalization.Height,
+		Blocks:    number - from + 1,
+		GovStates: uint64(len(heights)),
+	}
+	if err := rlp.Encode(writer, header); err != nil {
+		return err
+	}
+	for n := from; n <= number; n++ {
+		block := bc.GetBlockByNumber(n)
+		if block == nil {
+			return fmt.Errorf("block #%d missing", n)
+		}
+		receipts := bc.GetReceiptsByHash(block.Hash())
+		entry := &snapshotBlock{
+			Block:    block,
+			Td:       bc.GetTd(block.Hash(), n),
+			Receipts: make([]*types.ReceiptForStorage, len(receipts)),
+		}
+		for i, receipt := range receipts {
+			entry.Receipts[i] = (*types.ReceiptForStorage)(receipt)
+		}
+		if err := rlp.Encode(writer, entry); err != nil {
+			return err
+		}
+	}
+	for _, height := range heights {
+		header := bc.GetHeaderByNumber(height)
+		s, err := bc.StateAt(header.Root)
+		if err != nil {
+			return fmt.Errorf("state of block #%d missing: %v", height, err)
+		}
+		govState, err := state.GetGovState(s, header, vm.GovernanceContractAddress)
+		if err != nil {
+			return err
+		}
+		if err := rlp.Encode(writer, govState); err != nil {
+			return err
+		}
+	}
+
+	var (
+		triedb          = bc.StateCache().TrieDB()
+		nodes           int
+		start, reported = time.Now(), time.Now()
+	)
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+		if it.Hash == (common.Hash{}) {
+			continue
+		}
+		blob, err := triedb.Node(it.Hash)
+		if err != nil {
+			return fmt.Errorf("state node %x missing: %v", it.Hash, err)
+		}
+		if err := rlp.Encode(writer, &snapshotNode{Hash: it.Hash, Blob: blob}); err != nil {
+			return err
+		}
+		nodes++
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Exporting state nodes", "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	if it.Error != nil {
+		return it.Error
+	}
+	log.Info("Exported snapshot", "file", fn, "blocks", header.Blocks, "nodes", nodes,
+		"elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// ImportSnapshot imports a snapshot into a database initialized with the
+// genesis block only. The head of the snapshot must match the trusted hash,
+// every other piece of the snapshot is verified against the head. After the
+// import the node continues syncing from the head of the snapshot.
+//
+// Only the blocks of the snapshot are imported. The blocks from number 1 up
+// to the first block of the snapshot stay missing from the database, they
+// are neither synced later nor served to peers, and lookups of them return
+// nothing.
+func ImportSnapshot(db ethdb.Database, fn string, trusted common.Hash) error {
+	log.Info("Importing snapshot", "file", fn, "trusted", trusted)
+
+	genesis := rawdb.ReadCanonicalHash(db, 0)
+	if genesis == (common.Hash{}) {
+		return errors.New("database not initialized with a genesis block")
+	}
+	if head := rawdb.ReadHeadBlockHash(db); head != genesis {
+		return errSnapshotNotEmpty
+	}
+
+	fh, err := os.Open(fn)
+	if err != nil {
+		return err
+	}
+	defer fh.Close()
+
+	var reader io.Reader = fh
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			return err
+		}
+	}
+	stream := rlp.NewStream(reader, 0)
+
+	var header snapshotHeader
+	if err := stream.Decode(&header); err != nil {
+		return fmt.Errorf("invalid snapshot header: %v", err)
+	}
+	switch {
+	case header.Version != snapshotVersion:
+		return errSnapshotVersion
+	case header.Hash != trusted:
+		return errSnapshotUntrusted
+	case header.Genesis != genesis:
+		return errSnapshotGenesis
+	case header.Blocks == 0 || header.Blocks > header.Number:
+		return fmt.Errorf("invalid number of blocks: %d", header.Blocks)
+	}
+
+	// Verify the blocks are linked and end at the trusted head before
+	// writing any of them, as nothing else can be trusted otherwise.
+	blocks := make([]*snapshotBlock, header.Blocks)
+	for i := range blocks {
+		entry := new(snapshotBlock)
+		if err := stream.Decode(entry); err != nil {
+			return fmt.Errorf("invalid block at index %d: %v", i, err)
+		}
+		if err := verifySnapshotBlock(entry); err != nil {
+			return err
+		}
+		if i > 0 && entry.Block.ParentHash() != blocks[i-1].Block.Hash() {
+			return fmt.Errorf("block #%d not linked to its parent", entry.Block.NumberU64())
+		}
+		blocks[i] = entry
+	}
+	head := blocks[len(blocks)-1].Block
+	if head.Hash() != trusted || head.NumberU64() != header.Number {
+		return errSnapshotUntrusted
+	}
+	if blocks[0].Block.NumberU64() == 1 && blocks[0].Block.ParentHash() != genesis {
+		return errSnapshotGenesis
+	}
+	var tip coreTypes.Block
+	if err := rlp.DecodeBytes(head.Header().DexconMeta, &tip); err != nil {
+		return err
+	}
+	if tip.Hash != header.TipHash || tip.Finalization.Height != header.TipHeight {
+		return errors.New("compaction chain tip mismatch")
+	}
+
+	govStates := make([]*types.GovState, header.GovStates)
+	for i := range govStates {
+		govState := new(types.GovState)
+		if err := stream.Decode(govState); err != nil {
+			return fmt.Errorf("invalid governance state at index %d: %v", i, err)
+		}
+		number := govState.Number.Uint64()
+		if number < blocks[0].Block.NumberU64() || number > head.NumberU64() {
+			return fmt.Errorf("governance state of block #%d out of range", number)
+		}
+		block := blocks[number-blocks[0].Block.NumberU64()].Block
+		if err := verifier.VerifyGovState(block.Header(), govState); err != nil {
+			return fmt.Errorf("invalid governance state of block #%d: %v", number, err)
+		}
+		govStates[i] = govState
+	}
+
+	// Write the state nodes, each of them must hash to its key.
+	var (
+		batch           = db.NewBatch()
+		nodes           int
+		start, reported = time.Now(), time.Now()
+	)
+	for {
+		var node snapshotNode
+		if err := stream.Decode(&node); err == io.EOF {
+			break
+		} else if err != nil {
+			return fmt.Errorf("invalid state node at index %d: %v", nodes, err)
+		}
+		if crypto.Keccak256Hash(node.Blob) != node.Hash {
+			return fmt.Errorf("state node %x corrupted", node.Hash)
+		}
+		batch.Put(node.Hash[:], node.Blob)
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		nodes++
+		if time.Since(reported) >= 8*time.Second {
+			log.Info("Importing state nodes", "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	batch.Reset()
+
+	// Make sure the state of the head is complete.
+	statedb, err := state.New(head.Root(), state.NewDatabase(db))
+	if err != nil {
+		return fmt.Errorf("state of block #%d missing: %v", head.NumberU64(), err)
+	}
+	it := state.NewNodeIterator(statedb)
+	for it.Next() {
+	}
+	if it.Error != nil {
+		return fmt.Errorf("state of block #%d incomplete: %v", head.NumberU64(), it.Error)
+	}
+	for _, govState := range govStates {
+		if err := writeGovState(db, govState); err != nil {
+			return err
+		}
+	}
+
+	// Write the blocks along with the core blocks the consensus core syncs
+	// the compaction chain from, then move the heads to the snapshot.
+	for _, entry := range blocks {
+		block := entry.Block
+		receipts := make(types.Receipts, len(entry.Receipts))
+		for i, receipt := range entry.Receipts {
+			receipts[i] = (*types.Receipt)(receipt)
+		}
+		rawdb.WriteBlock(batch, block)
+		rawdb.WriteTd(batch, block.Hash(), block.NumberU64(), entry.Td)
+		rawdb.WriteReceipts(batch, block.Hash(), block.NumberU64(), receipts)
+		rawdb.WriteCanonicalHash(batch, block.Hash(), block.NumberU64())
+		rawdb.WriteTxLookupEntries(batch, block)
+
+		var coreBlock coreTypes.Block
+		if err := rlp.DecodeBytes(block.Header().DexconMeta, &coreBlock); err != nil {
+			return fmt.Errorf("invalid core block in block #%d: %v", block.NumberU64(), err)
+		}
+		rawdb.WriteCoreBlock(batch, common.Hash(coreBlock.Hash), &coreBlock)
+
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+	}
+	if err := rawdb.WriteCoreCompactionChainTip(batch, tip.Hash, tip.Finalization.Height); err != nil {
+		return err
+	}
+	if err := rawdb.WriteLastRoundNumber(batch, head.Round()); err != nil {
+		return err
+	}
+	rawdb.WriteHeadHeaderHash(batch, head.Hash())
+	rawdb.WriteHeadFastBlockHash(batch, head.Hash())
+	rawdb.WriteHeadBlockHash(batch, head.Hash())
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Info("Imported snapshot", "number", head.NumberU64(), "hash", head.Hash(),
+		"blocks", len(blocks), "nodes", nodes, "elapsed", common.PrettyDuration(time.Since(start)))
+	return nil
+}
+
+// verifySnapshotBlock checks the body and the receipts of a snapshot block
+// match its header.
+func verifySnapshotBlock(entry *snapshotBlock) error {
+	block := entry.Block
+	if entry.Td == nil {
+		return fmt.Errorf("block #%d missing total difficulty", block.NumberU64())
+	}
+	if hash := types.DeriveSha(block.Transactions()); hash != block.TxHash() {
+		return fmt.Errorf("block #%d transaction root mismatch", block.NumberU64())
+	}
+	receipts := make(types.Receipts, len(entry.Receipts))
+	for i, receipt := range entry.Receipts {
+		receipts[i] = (*types.Receipt)(receipt)
+	}
+	if hash := types.DeriveSha(receipts); hash != block.ReceiptHash() {
+		return fmt.Errorf("block #%d receipt root mismatch", block.NumberU64())
+	}
+	return nil
+}
+
+// writeGovState writes the governance account proof and the governance
+// contract storage of a verified governance state, so that the governance
+// configuration at its block can be read without the full state.
+func writeGovState(db ethdb.Database, s *types.GovState) error {
+	for _, node := range s.Proof {
+		if err := db.Put(crypto.Keccak256(node), node); err != nil {
+			return err
+		}
+	}
+	triedb := trie.NewDatabase(db)
+	t, err := trie.New(common.Hash{}, triedb)
+	if err != nil {
+		return err
+	}
+	for _, kv := range s.Storage {
+		if err := t.TryUpdate(kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	root, err := t.Commit(nil)
+	if err != nil {
+		return err
+	}
+	return triedb.Commit(root, false)
+}
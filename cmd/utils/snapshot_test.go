@@ -0,0 +1,357 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package utils
+
+import (
+	"compress/gzip"
+	"crypto/ecdsa"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/consensus/ethash"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/verifier"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/params"
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+var (
+	snapshotTestKey, _  = crypto.HexToECDSA("b71c71a67e1177ad4e901695e1b4b9ee17ae16c6668d313eac2f96dbcda3f291")
+	snapshotTestAddr    = crypto.PubkeyToAddress(snapshotTestKey.PublicKey)
+	snapshotTestNodeKey = []string{
+		"3cf5bdee098cc34536a7b0e80d85e07a380efca76fc12136299b9e5ba24193c8",
+		"96c9f1435d53577db18d45411326311529a0e8affb19218e27f65769a482c0fb",
+		"b25e955e30dd87cbaec83287beea6ec9c4c72498bc66905590756bf48da5d1fc",
+		"35577f65312f4a5e0b5391f5385043a6bc7b51fa4851a579e845b5fea33efded",
+	}
+)
+
+// newSnapshotTestChain creates a chain of n blocks whose round 1 begins at
+// block height and returns it along with its genesis specification.
+func newSnapshotTestChain(t *testing.T, height uint64, n int) (*core.BlockChain, *core.Genesis) {
+	ether := big.NewInt(1e18)
+	gspec := &core.Genesis{
+		Config: params.TestnetChainConfig,
+		Alloc: core.GenesisAlloc{
+			snapshotTestAddr: {
+				Balance: new(big.Int).Mul(big.NewInt(1000), ether),
+				Staked:  big.NewInt(0),
+			},
+		},
+	}
+	var nodekeys []*ecdsa.PrivateKey
+	for _, hex := range snapshotTestNodeKey {
+		key, _ := crypto.HexToECDSA(hex)
+		nodekeys = append(nodekeys, key)
+		gspec.Alloc[crypto.PubkeyToAddress(key.PublicKey)] = core.GenesisAccount{
+			Balance:   new(big.Int).Mul(big.NewInt(1000), ether),
+			Staked:    new(big.Int).Mul(big.NewInt(500), ether),
+			PublicKey: crypto.FromECDSAPub(&key.PublicKey),
+		}
+	}
+	db := ethdb.NewMemDatabase()
+	genesis := gspec.MustCommit(db)
+	signer := types.NewEIP155Signer(gspec.Config.ChainID)
+	crs := crypto.Keccak256Hash([]byte(gspec.Config.Dexcon.GenesisCRSText))
+	nodeSet := core.NewNodeSet(0, crs, signer, nodekeys)
+
+	method := vm.GovernanceContractName2Method["snapshotRound"]
+	input, err := method.Inputs.Pack(big.NewInt(1), new(big.Int).SetUint64(height))
+	if err != nil {
+		t.Fatalf("failed to pack input: %v", err)
+	}
+	snapshotRound := append(method.Id(), input...)
+
+	// The round interval is longer than the chain so that the generator
+	// never runs the DKG, round 1 is snapshotted explicitly instead.
+	blocks, _ := core.GenerateChainWithRoundChange(gspec.Config, genesis, ethash.NewFaker(), db, n,
+		func(i int, gen *core.BlockGen) {
+			tx := types.NewTransaction(gen.TxNonce(snapshotTestAddr), common.Address{byte(i + 1)},
+				big.NewInt(1000), params.TxGas, big.NewInt(1), nil)
+			if gen.Number().Uint64() == height {
+				tx = types.NewTransaction(gen.TxNonce(snapshotTestAddr), vm.GovernanceContractAddress,
+					big.NewInt(0), 100000, big.NewInt(1), snapshotRound)
+			}
+			tx, err := types.SignTx(tx, signer, snapshotTestKey)
+			if err != nil {
+				t.Fatalf("failed to sign tx: %v", err)
+			}
+			gen.AddTx(tx)
+		}, nodeSet, 2*n+2)
+
+	bc, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create chain: %v", err)
+	}
+	if i, err := bc.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert block #%d: %v", blocks[i].NumberU64(), err)
+	}
+	return bc, gspec
+}
+
+// exportTestSnapshot exports the snapshot of round 1 of a test chain.
+func exportTestSnapshot(t *testing.T) (*core.BlockChain, *core.Genesis, string) {
+	bc, gspec := newSnapshotTestChain(t, 6, 10)
+	dir, err := ioutil.TempDir("", "snapshot-test")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fn := filepath.Join(dir, "snapshot.rlp.gz")
+	if err := ExportSnapshot(bc, fn, 1); err != nil {
+		t.Fatalf("failed to export snapshot: %v", err)
+	}
+	return bc, gspec, fn
+}
+
+func TestSnapshotRoundTrip(t *testing.T) {
+	bc, gspec, fn := exportTestSnapshot(t)
+	defer bc.Stop()
+	defer os.RemoveAll(filepath.Dir(fn))
+
+	gov := core.NewGovernance(core.NewGovernanceStateDB(bc))
+	head := bc.GetBlockByNumber(gov.GetRoundHeight(1))
+	if head == nil || head.NumberU64() == 0 {
+		t.Fatalf("round 1 not reached")
+	}
+
+	db := ethdb.NewMemDatabase()
+	gspec.MustCommit(db)
+	if err := ImportSnapshot(db, fn, common.Hash{1}); err != errSnapshotUntrusted {
+		t.Fatalf("error mismatch: got %v, want %v", err, errSnapshotUntrusted)
+	}
+	if err := ImportSnapshot(db, fn, head.Hash()); err != nil {
+		t.Fatalf("failed to import snapshot: %v", err)
+	}
+	if err := ImportSnapshot(db, fn, head.Hash()); err != errSnapshotNotEmpty {
+		t.Fatalf("error mismatch: got %v, want %v", err, errSnapshotNotEmpty)
+	}
+
+	imported, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to open imported chain: %v", err)
+	}
+	defer imported.Stop()
+	if hash := imported.CurrentBlock().Hash(); hash != head.Hash() {
+		t.Fatalf("head mismatch: got %x, want %x", hash, head.Hash())
+	}
+	// Blocks before the first exported round are not part of the snapshot.
+	if block := imported.GetBlockByNumber(1); block != nil {
+		t.Errorf("block #1 imported")
+	}
+
+	want, err := bc.StateAt(head.Root())
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := imported.StateAt(head.Root())
+	if err != nil {
+		t.Fatalf("state of head missing: %v", err)
+	}
+	for i := 0; i < 10; i++ {
+		addr := common.Address{byte(i + 1)}
+		if got.GetBalance(addr).Cmp(want.GetBalance(addr)) != 0 {
+			t.Errorf("balance of %x mismatch: got %v, want %v", addr,
+				got.GetBalance(addr), want.GetBalance(addr))
+		}
+	}
+	if got.GetNonce(snapshotTestAddr) != want.GetNonce(snapshotTestAddr) {
+		t.Errorf("nonce mismatch: got %d, want %d",
+			got.GetNonce(snapshotTestAddr), want.GetNonce(snapshotTestAddr))
+	}
+
+	// The governance state of the round heights can be proven from the
+	// imported database alone.
+	header := imported.GetHeaderByNumber(head.NumberU64())
+	s, err := state.New(header.Root, state.NewDatabase(db))
+	if err != nil {
+		t.Fatal(err)
+	}
+	govState, err := state.GetGovState(s, header, vm.GovernanceContractAddress)
+	if err != nil {
+		t.Fatalf("failed to get governance state: %v", err)
+	}
+	if err := verifier.VerifyGovState(header, govState); err != nil {
+		t.Errorf("invalid governance state: %v", err)
+	}
+	if height := core.NewGovernance(core.NewGovernanceStateDB(imported)).GetRoundHeight(1); height != head.NumberU64() {
+		t.Errorf("round height mismatch: got %d, want %d", height, head.NumberU64())
+	}
+
+	var tip coreTypes.Block
+	if err := rlp.DecodeBytes(head.Header().DexconMeta, &tip); err != nil {
+		t.Fatal(err)
+	}
+	tipHash, tipHeight := rawdb.ReadCoreCompactionChainTip(db)
+	if tipHash != tip.Hash || tipHeight != tip.Finalization.Height {
+		t.Errorf("compaction chain tip mismatch: got %x %d, want %x %d",
+			tipHash, tipHeight, tip.Hash, tip.Finalization.Height)
+	}
+	if rawdb.ReadCoreBlock(db, common.Hash(tip.Hash)) == nil {
+		t.Errorf("core block of head missing")
+	}
+}
+
+// rewriteSnapshot decodes a snapshot, lets tamper modify its items and
+// writes them to a new file.
+func rewriteSnapshot(t *testing.T, fn string, tamper func(header *snapshotHeader,
+	blocks []*snapshotBlock, govStates []*types.GovState, nodes []*snapshotNode)) string {
+	src, err := os.Open(fn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer src.Close()
+	var reader io.Reader = src
+	if strings.HasSuffix(fn, ".gz") {
+		if reader, err = gzip.NewReader(reader); err != nil {
+			t.Fatal(err)
+		}
+	}
+	stream := rlp.NewStream(reader, 0)
+
+	var header snapshotHeader
+	if err := stream.Decode(&header); err != nil {
+		t.Fatal(err)
+	}
+	blocks := make([]*snapshotBlock, header.Blocks)
+	for i := range blocks {
+		blocks[i] = new(snapshotBlock)
+		if err := stream.Decode(blocks[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	govStates := make([]*types.GovState, header.GovStates)
+	for i := range govStates {
+		govStates[i] = new(types.GovState)
+		if err := stream.Decode(govStates[i]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	var nodes []*snapshotNode
+	for {
+		node := new(snapshotNode)
+		if err := stream.Decode(node); err == io.EOF {
+			break
+		} else if err != nil {
+			t.Fatal(err)
+		}
+		nodes = append(nodes, node)
+	}
+
+	tamper(&header, blocks, govStates, nodes)
+
+	out := filepath.Join(filepath.Dir(fn), "tampered.rlp")
+	dst, err := os.Create(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer dst.Close()
+	items := []interface{}{&header}
+	for _, block := range blocks {
+		items = append(items, block)
+	}
+	for _, govState := range govStates {
+		items = append(items, govState)
+	}
+	for _, node := range nodes {
+		items = append(items, node)
+	}
+	for _, item := range items {
+		if err := rlp.Encode(dst, item); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return out
+}
+
+func TestSnapshotTampered(t *testing.T) {
+	bc, gspec, fn := exportTestSnapshot(t)
+	defer bc.Stop()
+	defer os.RemoveAll(filepath.Dir(fn))
+
+	gov := core.NewGovernance(core.NewGovernanceStateDB(bc))
+	trusted := bc.GetBlockByNumber(gov.GetRoundHeight(1)).Hash()
+
+	tests := []struct {
+		name   string
+		tamper func(*snapshotHeader, []*snapshotBlock, []*types.GovState, []*snapshotNode)
+		err    string
+	}{
+		{
+			name: "untampered",
+			tamper: func(*snapshotHeader, []*snapshotBlock, []*types.GovState, []*snapshotNode) {
+			},
+		},
+		{
+			name: "receipt",
+			tamper: func(_ *snapshotHeader, blocks []*snapshotBlock, _ []*types.GovState, _ []*snapshotNode) {
+				blocks[0].Receipts[0].CumulativeGasUsed++
+			},
+			err: "receipt root mismatch",
+		},
+		{
+			name: "governance state",
+			tamper: func(_ *snapshotHeader, _ []*snapshotBlock, govStates []*types.GovState, _ []*snapshotNode) {
+				kv := govStates[0].Storage[0]
+				govStates[0].Storage[0] = [2][]byte{kv[0], append(common.CopyBytes(kv[1]), 0x01)}
+			},
+			err: "invalid governance state",
+		},
+		{
+			name: "state node",
+			tamper: func(_ *snapshotHeader, _ []*snapshotBlock, _ []*types.GovState, nodes []*snapshotNode) {
+				nodes[len(nodes)/2].Blob[0] ^= 0xff
+			},
+			err: "corrupted",
+		},
+		{
+			name: "missing state node",
+			tamper: func(_ *snapshotHeader, _ []*snapshotBlock, _ []*types.GovState, nodes []*snapshotNode) {
+				nodes[len(nodes)-1] = nodes[0]
+			},
+			err: "incomplete",
+		},
+	}
+	for _, tt := range tests {
+		out := rewriteSnapshot(t, fn, tt.tamper)
+		db := ethdb.NewMemDatabase()
+		gspec.MustCommit(db)
+		err := ImportSnapshot(db, out, trusted)
+		switch {
+		case tt.err == "" && err != nil:
+			t.Errorf("%s: failed to import snapshot: %v", tt.name, err)
+		case tt.err != "" && (err == nil || !strings.Contains(err.Error(), tt.err)):
+			t.Errorf("%s: error mismatch: got %v, want %q", tt.name, err, tt.err)
+		case tt.err != "" && rawdb.ReadHeadBlockHash(db) != rawdb.ReadCanonicalHash(db, 0):
+			t.Errorf("%s: head moved by rejected snapshot", tt.name)
+		}
+	}
+}
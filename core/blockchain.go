@@ -81,6 +81,9 @@ type CacheConfig struct {
 	TrieCleanLimit int           // Memory allowance (MB) to use for caching trie nodes in memory
 	TrieDirtyLimit int           // Memory limit (MB) at which to start flushing dirty trie nodes to disk
 	TrieTimeLimit  time.Duration // Time limit after which to flush the current in-memory trie to disk
+
+	RoundPruning   bool   // Whether to only flush the states at round heights to disk (round pruning node)
+	StateRetention uint64 // Number of recent block states kept alive, triesInMemory if zero
 }
 
 // BlockChain represents the canonical chain given a database with a genesis
@@ -373,6 +376,33 @@ func (bc *BlockChain) RemoveConfirmedBlock(chainID uint32, hash coreCommon.Hash)
 	delete(bc.confirmedBlocks[chainID], hash)
 }
 
+// RemoveConfirmedBlocksBefore removes the confirmed blocks of the chain
+// proposed before the given round, which are no longer to be delivered. It
+// returns the number of blocks removed.
+func (bc *BlockChain) RemoveConfirmedBlocksBefore(chainID uint32, round uint64) int {
+	var removed int
+	for hash, blockInfo := range bc.confirmedBlocks[chainID] {
+		if blockInfo.block.Position.Round < round {
+			bc.RemoveConfirmedBlock(chainID, hash)
+			removed++
+		}
+	}
+	return removed
+}
+
+// ConfirmedBlockChainIDs returns the IDs of the chains having confirmed
+// blocks cached.
+func (bc *BlockChain) ConfirmedBlockChainIDs() []uint32 {
+	bc.confirmedBlockInitMu.Lock()
+	defer bc.confirmedBlockInitMu.Unlock()
+
+	chainIDs := make([]uint32, 0, len(bc.confirmedBlocks))
+	for chainID := range bc.confirmedBlocks {
+		chainIDs = append(chainIDs, chainID)
+	}
+	return chainIDs
+}
+
 func (bc *BlockChain) GetConfirmedBlockByHash(chainID uint32, hash coreCommon.Hash) (*coreTypes.Block, types.Transactions) {
 	return bc.confirmedBlocks[chainID][hash].block, bc.confirmedBlocks[chainID][hash].txs
 }
@@ -910,10 +940,13 @@ func (bc *BlockChain) Stop() {
 	if !bc.cacheConfig.Disabled {
 		triedb := bc.stateCache.TrieDB()
 
-		for _, offset := range []uint64{0, 1, triesInMemory - 1} {
+		for _, offset := range []uint64{0, 1, bc.stateRetention() - 1} {
 			if number := bc.CurrentBlock().NumberU64(); number > offset {
 				recent := bc.GetBlockByNumber(number - offset)
-
+				if recent == nil {
+					// Blocks before an imported snapshot are not available.
+					continue
+				}
 				log.Info("Writing cached state to disk", "block", recent.Number(), "hash", recent.Hash(), "root", recent.Root())
 				if err := triedb.Commit(recent.Root(), true); err != nil {
 					log.Error("Failed to commit recent state trie", "err", err)
@@ -930,6 +963,14 @@ func (bc *BlockChain) Stop() {
 	log.Info("Blockchain manager stopped")
 }
 
+// stateRetention returns the number of recent block states kept alive.
+func (bc *BlockChain) stateRetention() uint64 {
+	if bc.cacheConfig.StateRetention > 0 {
+		return bc.cacheConfig.StateRetention
+	}
+	return triesInMemory
+}
+
 func (bc *BlockChain) procFutureBlocks() {
 	blocks := make([]*types.Block, 0, bc.futureBlocks.Len())
 	for _, hash := range bc.futureBlocks.Keys() {
@@ -1177,7 +1218,8 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 		triedb.Reference(root, common.Hash{}) // metadata reference to keep trie alive
 		bc.triegc.Push(root, -int64(block.NumberU64()))
 
-		if current := block.NumberU64(); current > triesInMemory {
+		retention := bc.stateRetention()
+		if current := block.NumberU64(); current > retention {
 			// If we exceeded our memory allowance, flush matured singleton nodes to disk
 			var (
 				nodes, imgs = triedb.Size()
@@ -1187,15 +1229,17 @@ func (bc *BlockChain) WriteBlockWithState(block *types.Block, receipts []*types.
 				triedb.Cap(limit - ethdb.IdealBatchSize)
 			}
 			// Find the next state trie we need to commit
-			header := bc.GetHeaderByNumber(current - triesInMemory)
+			header := bc.GetHeaderByNumber(current - retention)
 			chosen := header.Number.Uint64()
 
-			// If we exceeded out time allowance, flush an entire trie to disk
-			if bc.gcproc > bc.cacheConfig.TrieTimeLimit {
+			// If we exceeded out time allowance, flush an entire trie to disk.
+			// Round pruning nodes only keep the states at round heights and
+			// let the rest be garbage collected.
+			if !bc.cacheConfig.RoundPruning && bc.gcproc > bc.cacheConfig.TrieTimeLimit {
 				// If we're exceeding limits but haven't reached a large enough memory gap,
 				// warn the user that the system is becoming unstable.
-				if chosen < lastWrite+triesInMemory && bc.gcproc >= 2*bc.cacheConfig.TrieTimeLimit {
-					log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", bc.cacheConfig.TrieTimeLimit, "optimum", float64(chosen-lastWrite)/triesInMemory)
+				if chosen < lastWrite+retention && bc.gcproc >= 2*bc.cacheConfig.TrieTimeLimit {
+					log.Info("State in memory for too long, committing", "time", bc.gcproc, "allowance", bc.cacheConfig.TrieTimeLimit, "optimum", float64(chosen-lastWrite)/float64(retention))
 				}
 				// Flush an entire trie and restart the counters
 				triedb.Commit(header.Root, true)
@@ -18,6 +18,7 @@ package core
 
 import (
 	"bytes"
+	"crypto/ecdsa"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
@@ -445,6 +446,54 @@ func DeveloperGenesisBlock(period uint64, faucet common.Address) *Genesis {
 	}
 }
 
+// DeveloperDexconGenesisBlock returns the 'gdex --dev' genesis block, with
+// the faucet pre-funded and the nodes of the given public keys staked as the
+// notary and DKG sets, running numChains lattice chains. The DKG threshold
+// is DKGSetSize/3+1 and the BLS library needs a threshold of at least two,
+// so rounds advance with randomness only with four nodes or more.
+func DeveloperDexconGenesisBlock(faucet common.Address, nodes []*ecdsa.PublicKey, numChains uint32) *Genesis {
+	ether := big.NewInt(1e18)
+
+	dexcon := *params.TestnetChainConfig.Dexcon
+	dexcon.Owner = faucet
+	dexcon.MinStake = new(big.Int).Set(ether)
+	dexcon.NumChains = numChains
+	dexcon.LambdaBA = 250
+	dexcon.LambdaDKG = 1000
+	dexcon.NotarySetSize = uint32(len(nodes))
+	dexcon.DKGSetSize = uint32(len(nodes))
+	dexcon.RoundInterval = 60000
+	dexcon.MinBlockInterval = 500
+
+	config := *params.TestnetChainConfig
+	config.ChainID = big.NewInt(1337)
+	config.GovernanceMeteringBlock = big.NewInt(0)
+	config.GovernanceRevertReasonBlock = big.NewInt(0)
+	config.Dexcon = &dexcon
+
+	alloc := GenesisAlloc{
+		faucet: {
+			Balance: new(big.Int).Mul(ether, big.NewInt(1e9)),
+			Staked:  big.NewInt(0),
+		},
+	}
+	for i, node := range nodes {
+		alloc[crypto.PubkeyToAddress(*node)] = GenesisAccount{
+			Balance:   new(big.Int).Mul(ether, big.NewInt(1e6)),
+			Staked:    dexcon.MinStake,
+			PublicKey: crypto.FromECDSAPub(node),
+			NodeInfo:  NodeInfo{Name: fmt.Sprintf("dev%d", i)},
+		}
+	}
+	return &Genesis{
+		Config:     &config,
+		Nonce:      0x42,
+		GasLimit:   dexcon.BlockGasLimit,
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
+	}
+}
+
 func decodePrealloc(data string) GenesisAlloc {
 	type accountData struct {
 		Balance   *big.Int
@@ -0,0 +1,236 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/params"
+)
+
+var (
+	errNoDexconConfig = errors.New("no Dexcon config")
+	errNoStakedNodes  = errors.New("no staked nodes")
+)
+
+// DexconNode is a node staked in a Dexcon genesis.
+type DexconNode struct {
+	PublicKey *ecdsa.PublicKey
+	Staked    *big.Int // Stake, at least the minimum stake of the config
+	Balance   *big.Int // Balance besides the stake, nil for none
+	Info      NodeInfo
+}
+
+// NewDexconGenesis creates a genesis of the chain config staking the nodes,
+// returning the first problem ValidateDexconGenesis finds in it. Forks the
+// config leaves unscheduled are activated at genesis.
+func NewDexconGenesis(config *params.ChainConfig, nodes []DexconNode) (*Genesis, error) {
+	if config == nil || config.Dexcon == nil {
+		return nil, errNoDexconConfig
+	}
+	if len(nodes) == 0 {
+		return nil, errNoStakedNodes
+	}
+	dexcon := config.Dexcon
+
+	// A new chain has no history to stay compatible with.
+	if config.GovernanceMeteringBlock == nil {
+		config.GovernanceMeteringBlock = big.NewInt(0)
+	}
+	if config.GovernanceRevertReasonBlock == nil {
+		config.GovernanceRevertReasonBlock = big.NewInt(0)
+	}
+
+	alloc := make(GenesisAlloc)
+	for i, node := range nodes {
+		if node.PublicKey == nil {
+			return nil, fmt.Errorf("node %d: no public key", i)
+		}
+		if node.Staked == nil || node.Staked.Sign() <= 0 {
+			return nil, fmt.Errorf("node %d: no stake", i)
+		}
+		addr := crypto.PubkeyToAddress(*node.PublicKey)
+		if _, exist := alloc[addr]; exist {
+			return nil, fmt.Errorf("node %d: duplicate public key", i)
+		}
+		balance := new(big.Int).Set(node.Staked)
+		if node.Balance != nil {
+			balance.Add(balance, node.Balance)
+		}
+		alloc[addr] = GenesisAccount{
+			Balance:   balance,
+			Staked:    new(big.Int).Set(node.Staked),
+			PublicKey: crypto.FromECDSAPub(node.PublicKey),
+			NodeInfo:  node.Info,
+		}
+	}
+	genesis := &Genesis{
+		Config:     config,
+		Nonce:      0x42,
+		GasLimit:   dexcon.BlockGasLimit,
+		Difficulty: big.NewInt(1),
+		Alloc:      alloc,
+	}
+	if errs := ValidateDexconGenesis(genesis); len(errs) > 0 {
+		return nil, errs[0]
+	}
+	return genesis, nil
+}
+
+// Fund pre-funds the account with the balance, accounts of Dexcon genesis
+// need an explicit zero stake.
+func (g *Genesis) Fund(addr common.Address, balance *big.Int) {
+	account := g.Alloc[addr]
+	if account.Balance == nil {
+		account.Balance = new(big.Int)
+	}
+	if account.Staked == nil {
+		account.Staked = new(big.Int)
+	}
+	account.Balance = new(big.Int).Add(account.Balance, balance)
+	g.Alloc[addr] = account
+}
+
+// ValidateDexconGenesis checks a Dexcon genesis for problems stalling the
+// network after dMoment. The genesis state is built in memory and the
+// governance configuration read back from it, so values lost in the
+// conversion to governance state are caught too. All problems found are
+// returned, none for a valid genesis.
+func ValidateDexconGenesis(g *Genesis) []error {
+	if g.Config == nil || g.Config.Dexcon == nil {
+		return []error{errNoDexconConfig}
+	}
+	var errs []error
+	report := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	// Check the accounts first, building the state panics on broken ones so
+	// they are left out of it, which the remaining checks then account for.
+	alloc := make(GenesisAlloc, len(g.Alloc))
+	staked := 0
+	for _, addr := range sortedAllocKeys(g.Alloc) {
+		account := g.Alloc[addr]
+		if account.Balance == nil {
+			report("account %x: no balance", addr)
+			continue
+		}
+		if account.Staked == nil {
+			report("account %x: no stake, need an explicit zero", addr)
+			continue
+		}
+		if account.Balance.Cmp(account.Staked) < 0 {
+			report("account %x: stake %v larger than balance %v",
+				addr, account.Staked, account.Balance)
+			continue
+		}
+		if account.Staked.Sign() > 0 {
+			if len(account.PublicKey) == 0 {
+				report("account %x: staked without public key", addr)
+				continue
+			}
+			if _, err := crypto.UnmarshalPubkey(account.PublicKey); err != nil {
+				report("account %x: invalid public key: %v", addr, err)
+				continue
+			}
+			staked++
+		}
+		alloc[addr] = account
+	}
+	if g.Config.Dexcon.MinStake == nil || g.Config.Dexcon.BlockReward == nil {
+		report("minimum stake and block reward are mandatory")
+		return errs
+	}
+
+	db := ethdb.NewMemDatabase()
+	checked := *g
+	checked.Alloc = alloc
+	block := checked.ToBlock(db)
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	if err != nil {
+		return append(errs, fmt.Errorf("failed to open genesis state: %v", err))
+	}
+	helper := &vm.GovernanceStateHelper{StateDB: statedb}
+	config := helper.Configuration()
+
+	nodes := helper.Nodes()
+	if len(nodes) != staked {
+		report("%d staked accounts but %d nodes in governance state",
+			staked, len(nodes))
+	}
+	for _, node := range nodes {
+		if node.Staked.Cmp(config.MinStake) < 0 {
+			report("node %x: stake %v below minimum stake %v",
+				node.Owner, node.Staked, config.MinStake)
+		}
+	}
+	if helper.Owner() == (common.Address{}) {
+		report("no governance owner")
+	}
+	if config.NumChains == 0 {
+		report("number of chains is zero")
+	}
+	if config.PhiRatio < 0.5 || config.PhiRatio > 1 {
+		report("phi ratio %v out of range [0.5, 1]", config.PhiRatio)
+	}
+	if config.NotarySetSize == 0 {
+		report("notary set size is zero")
+	} else if int(config.NotarySetSize) > len(nodes) {
+		report("notary set size %d larger than %d staked nodes",
+			config.NotarySetSize, len(nodes))
+	}
+	if config.DKGSetSize == 0 {
+		report("DKG set size is zero")
+	} else if int(config.DKGSetSize) > len(nodes) {
+		report("DKG set size %d larger than %d staked nodes",
+			config.DKGSetSize, len(nodes))
+	}
+	if config.LambdaBA == 0 || config.LambdaDKG == 0 {
+		report("lambda BA %d and lambda DKG %d must be positive",
+			config.LambdaBA, config.LambdaDKG)
+	}
+	if config.RoundInterval == 0 {
+		report("round interval is zero")
+	}
+	if config.BlockGasLimit == 0 {
+		report("block gas limit is zero")
+	}
+	if len(config.FineValues) < vm.NumReportTypes {
+		report("%d fine values for %d report types",
+			len(config.FineValues), vm.NumReportTypes)
+	}
+	return errs
+}
+
+func sortedAllocKeys(alloc GenesisAlloc) AllocKey {
+	keys := make(AllocKey, 0, len(alloc))
+	for addr := range alloc {
+		keys = append(keys, addr)
+	}
+	sort.Sort(keys)
+	return keys
+}
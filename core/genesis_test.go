@@ -17,6 +17,7 @@
 package core
 
 import (
+	"crypto/ecdsa"
 	"math/big"
 	"reflect"
 	"testing"
@@ -25,7 +26,9 @@ import (
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/consensus/ethash"
 	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/params"
 )
@@ -162,3 +165,108 @@ func TestSetupGenesis(t *testing.T) {
 		}
 	}
 }
+
+func TestDeveloperDexconGenesisBlock(t *testing.T) {
+	var keys []*ecdsa.PublicKey
+	for i := 0; i < 4; i++ {
+		key, _ := crypto.GenerateKey()
+		keys = append(keys, &key.PublicKey)
+	}
+	faucet := common.Address{1}
+	db := ethdb.NewMemDatabase()
+	genesis := DeveloperDexconGenesisBlock(faucet, keys, 3)
+	block := genesis.MustCommit(db)
+
+	statedb, err := state.New(block.Root(), state.NewDatabase(db))
+	if err != nil {
+		t.Fatalf("failed to open genesis state: %v", err)
+	}
+	helper := &vm.GovernanceStateHelper{StateDB: statedb}
+	nodes := helper.Nodes()
+	if len(nodes) != len(keys) {
+		t.Fatalf("staked nodes mismatch: %v", spew.Sdump(nodes))
+	}
+	for _, key := range keys {
+		if offset := helper.NodesOffsetByAddress(crypto.PubkeyToAddress(*key)); offset.Sign() < 0 {
+			t.Errorf("node %x not staked", crypto.PubkeyToAddress(*key))
+		}
+	}
+	config := helper.Configuration()
+	if config.NumChains != 3 || config.NotarySetSize != 4 || config.DKGSetSize != 4 {
+		t.Errorf("configuration mismatch: %v", config)
+	}
+	if !genesis.Config.IsGovernanceMetering(common.Big0) {
+		t.Error("governance metering not activated at genesis")
+	}
+	if !genesis.Config.IsGovernanceRevertReason(common.Big0) {
+		t.Error("governance revert reason not activated at genesis")
+	}
+	if owner := helper.Owner(); owner != faucet {
+		t.Errorf("owner mismatch: got %x, want %x", owner, faucet)
+	}
+}
+
+func TestNewDexconGenesis(t *testing.T) {
+	config := *params.TestnetChainConfig
+	dexcon := *config.Dexcon
+	config.Dexcon = &dexcon
+	dexcon.NotarySetSize = 3
+	dexcon.DKGSetSize = 2
+
+	nodes := make([]DexconNode, 3)
+	for i := range nodes {
+		key, _ := crypto.GenerateKey()
+		nodes[i] = DexconNode{PublicKey: &key.PublicKey, Staked: dexcon.MinStake}
+	}
+	genesis, err := NewDexconGenesis(&config, nodes)
+	if err != nil {
+		t.Fatalf("failed to create genesis: %v", err)
+	}
+	if len(genesis.Alloc) != 3 {
+		t.Errorf("alloc size mismatch: have %d, want 3", len(genesis.Alloc))
+	}
+	if !genesis.Config.IsGovernanceMetering(common.Big0) {
+		t.Error("governance metering not activated at genesis")
+	}
+	if !genesis.Config.IsGovernanceRevertReason(common.Big0) {
+		t.Error("governance revert reason not activated at genesis")
+	}
+	if _, err := NewDexconGenesis(&config, nodes[:2]); err == nil {
+		t.Error("expected error for notary set larger than staked nodes")
+	}
+	if _, err := NewDexconGenesis(&config, append(nodes, nodes[0])); err == nil {
+		t.Error("expected error for duplicate nodes")
+	}
+	lowStake := append([]DexconNode{}, nodes...)
+	lowStake[0].Staked = big.NewInt(1)
+	if _, err := NewDexconGenesis(&config, lowStake); err == nil {
+		t.Error("expected error for stake below minimum")
+	}
+}
+
+func TestValidateDexconGenesis(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	genesis := DeveloperDexconGenesisBlock(common.Address{1}, []*ecdsa.PublicKey{&key.PublicKey}, 1)
+	if errs := ValidateDexconGenesis(genesis); len(errs) != 0 {
+		t.Fatalf("developer genesis invalid: %v", errs)
+	}
+
+	dexcon := *genesis.Config.Dexcon
+	dexcon.NumChains = 0
+	dexcon.PhiRatio = 1.5
+	dexcon.DKGSetSize = 2
+	dexcon.FineValues = dexcon.FineValues[:1]
+	config := *genesis.Config
+	config.Dexcon = &dexcon
+	genesis.Config = &config
+
+	addr := crypto.PubkeyToAddress(key.PublicKey)
+	account := genesis.Alloc[addr]
+	account.PublicKey = nil
+	genesis.Alloc[addr] = account
+
+	// The node without public key leaves the notary set unfilled too.
+	if errs := ValidateDexconGenesis(genesis); len(errs) != 6 {
+		t.Errorf("problem count mismatch: have %d, want 6: %v", len(errs), errs)
+	}
+}
@@ -1,15 +1,20 @@
 package core
 
 import (
+	"errors"
 	"fmt"
 	"math/big"
 	"time"
 
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
 	dexCore "github.com/dexon-foundation/dexon-consensus/core"
+	coreCrypto "github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
 	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
 	dkgTypes "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
 
 	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
 	"github.com/dexon-foundation/dexon/core/vm"
 	"github.com/dexon-foundation/dexon/log"
 	"github.com/dexon-foundation/dexon/rlp"
@@ -136,3 +141,86 @@ func (g *Governance) IsDKGFinal(round uint64) bool {
 	count := headHelper.DKGFinalizedsCount(big.NewInt(int64(round))).Uint64()
 	return count >= threshold
 }
+
+var errNoRandomness = errors.New("block has no randomness")
+
+// RandomnessProof is the material proving the randomness of a block is the
+// threshold signature of the DKG set of its round on the consensus block.
+type RandomnessProof struct {
+	Round          uint64
+	ConsensusHash  coreCommon.Hash // Hash of the consensus block signed
+	Randomness     []byte          // Threshold signature of the DKG set
+	GroupPublicKey []byte          // Group public key of the DKG set, nil if DKG is not final
+	Threshold      int
+	Verified       bool
+}
+
+// RandomnessProof returns the proof of the randomness of the given header
+// and verifies the randomness against the DKG group public key of its round.
+func (g *Governance) RandomnessProof(header *types.Header) (*RandomnessProof, error) {
+	if len(header.DexconMeta) == 0 || len(header.Randomness) == 0 {
+		return nil, errNoRandomness
+	}
+	var dexconMeta coreTypes.Block
+	if err := rlp.DecodeBytes(header.DexconMeta, &dexconMeta); err != nil {
+		return nil, err
+	}
+	proof := &RandomnessProof{
+		Round:         header.Round,
+		ConsensusHash: dexconMeta.Hash,
+		Randomness:    header.Randomness,
+	}
+	if !g.IsDKGFinal(header.Round) {
+		return proof, nil
+	}
+
+	// Same threshold as the TSig verifier of the consensus core.
+	proof.Threshold = int(g.Configuration(header.Round).DKGSetSize/3) + 1
+	mpks := g.DKGMasterPublicKeys(header.Round)
+	complaints := g.DKGComplaints(header.Round)
+	gpk, err := dexCore.NewDKGGroupPublicKey(
+		header.Round, mpks, complaints, proof.Threshold)
+	if err != nil {
+		return nil, err
+	}
+	proof.GroupPublicKey = qualifiedGroupPublicKey(
+		mpks, complaints, proof.Threshold).Bytes()
+	proof.Verified = gpk.VerifySignature(dexconMeta.Hash, coreCrypto.Signature{
+		Type:      "bls",
+		Signature: header.Randomness,
+	})
+	return proof, nil
+}
+
+// qualifiedGroupPublicKey recovers the group public key from the master
+// public keys of the qualified DKG set members, disqualifying members the
+// same way NewDKGGroupPublicKey of the consensus core does.
+func qualifiedGroupPublicKey(mpks []*dkgTypes.MasterPublicKey,
+	complaints []*dkgTypes.Complaint, threshold int) *dkg.PublicKey {
+	disqualified := make(map[coreTypes.NodeID]struct{})
+	nacks := make(map[coreTypes.NodeID]map[coreTypes.NodeID]struct{})
+	for _, complaint := range complaints {
+		proposer := complaint.PrivateShare.ProposerID
+		if !complaint.IsNack() {
+			disqualified[proposer] = struct{}{}
+			continue
+		}
+		if _, exist := nacks[proposer]; !exist {
+			nacks[proposer] = make(map[coreTypes.NodeID]struct{})
+		}
+		nacks[proposer][complaint.ProposerID] = struct{}{}
+	}
+	for nID, complainers := range nacks {
+		if len(complainers) > threshold {
+			disqualified[nID] = struct{}{}
+		}
+	}
+	pubShares := make([]*dkg.PublicKeyShares, 0, len(mpks))
+	for _, mpk := range mpks {
+		if _, exist := disqualified[mpk.ProposerID]; exist {
+			continue
+		}
+		pubShares = append(pubShares, &mpk.PublicKeyShares)
+	}
+	return dkg.RecoverGroupPublicKey(pubShares)
+}
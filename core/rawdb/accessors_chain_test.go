@@ -278,6 +278,7 @@ func TestBlockReceiptStorage(t *testing.T) {
 		TxHash:          common.BytesToHash([]byte{0x11, 0x11}),
 		ContractAddress: common.BytesToAddress([]byte{0x01, 0x11, 0x11}),
 		GasUsed:         111111,
+		RevertReason:    "not delegated",
 	}
 	receipt2 := &types.Receipt{
 		PostState:         common.Hash{2}.Bytes(),
@@ -309,6 +310,9 @@ func TestBlockReceiptStorage(t *testing.T) {
 			if !bytes.Equal(rlpHave, rlpWant) {
 				t.Fatalf("receipt #%d: receipt mismatch: have %v, want %v", i, rs[i], receipts[i])
 			}
+			if rs[i].RevertReason != receipts[i].RevertReason {
+				t.Fatalf("receipt #%d: revert reason mismatch: have %q, want %q", i, rs[i].RevertReason, receipts[i].RevertReason)
+			}
 		}
 	}
 	// Delete the receipt slice and check purge
@@ -49,3 +49,31 @@ func WriteCoreBlock(db DatabaseWriter, hash common.Hash, block *coreTypes.Block)
 	}
 	WriteCoreBlockRLP(db, hash, data)
 }
+
+func DeleteCoreBlock(db DatabaseDeleter, hash common.Hash) error {
+	return db.Delete(coreBlockKey(hash))
+}
+
+// IterateCoreBlocks calls fn with every core block stored in the database
+// along with its encoded size, in the order of their hashes. The iteration
+// stops at the first error returned by fn.
+func IterateCoreBlocks(db DatabaseIteratee, fn func(block *coreTypes.Block, size int) error) error {
+	it := db.NewIteratorWithPrefix(coreBlockPrefix)
+	defer it.Release()
+
+	for it.Next() {
+		// Other keys sharing the prefix, e.g. the DKG private keys, are
+		// not core blocks.
+		if len(it.Key()) != len(coreBlockPrefix)+common.HashLength {
+			continue
+		}
+		block := new(coreTypes.Block)
+		if err := rlp.DecodeBytes(it.Value(), block); err != nil {
+			return err
+		}
+		if err := fn(block, len(it.Value())); err != nil {
+			return err
+		}
+	}
+	return it.Error()
+}
@@ -2,6 +2,7 @@ package rawdb
 
 import (
 	"bytes"
+	"encoding/binary"
 
 	coreDKG "github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
 	"github.com/dexon-foundation/dexon/log"
@@ -46,3 +47,44 @@ func WriteCoreDKGPrivateKey(db DatabaseWriter, round uint64, key *coreDKG.Privat
 	}
 	return WriteCoreDKGPrivateKeyRLP(db, round, data)
 }
+
+func DeleteCoreDKGPrivateKey(db DatabaseDeleter, round uint64) error {
+	return db.Delete(coreDKGPrivateKeyKey(round))
+}
+
+func ReadCoreDKGEncryptedPrivateKey(db DatabaseReader, round uint64) []byte {
+	data, _ := db.Get(coreDKGEncryptedPrivateKeyKey(round))
+	return data
+}
+
+func WriteCoreDKGEncryptedPrivateKey(db DatabaseWriter, round uint64, data []byte) error {
+	err := db.Put(coreDKGEncryptedPrivateKeyKey(round), data)
+	if err != nil {
+		log.Crit("Failed to store encrypted core DKG private key", "err", err, "round", round)
+	}
+	return err
+}
+
+func HasCoreDKGEncryptedPrivateKey(db DatabaseReader, round uint64) (bool, error) {
+	return db.Has(coreDKGEncryptedPrivateKeyKey(round))
+}
+
+func DeleteCoreDKGEncryptedPrivateKey(db DatabaseDeleter, round uint64) error {
+	return db.Delete(coreDKGEncryptedPrivateKeyKey(round))
+}
+
+// ReadCoreDKGPrivateKeyPrunedRound returns the lowest round whose DKG private
+// key has not been pruned yet.
+func ReadCoreDKGPrivateKeyPrunedRound(db DatabaseReader) uint64 {
+	data, _ := db.Get(coreDKGPrivateKeyPrunedRoundKey)
+	if len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+// WriteCoreDKGPrivateKeyPrunedRound stores the lowest round whose DKG private
+// key has not been pruned yet.
+func WriteCoreDKGPrivateKeyPrunedRound(db DatabaseWriter, round uint64) error {
+	return db.Put(coreDKGPrivateKeyPrunedRoundKey, encodeBlockNumber(round))
+}
@@ -57,6 +57,9 @@ var (
 	coreDKGPrivateKeyPrefix   = []byte("DPK")
 	coreCompactionChainTipKey = []byte("CoreChainTip")
 
+	coreDKGEncryptedPrivateKeyPrefix = []byte("EDPK") // coreDKGEncryptedPrivateKeyPrefix + round -> encrypted DKG private key
+	coreDKGPrivateKeyPrunedRoundKey  = []byte("CoreDKGPrunedRound")
+
 	preimagePrefix = []byte("secure-key-")      // preimagePrefix + hash -> preimage
 	configPrefix   = []byte("ethereum-config-") // config prefix for the db
 
@@ -132,6 +135,14 @@ func coreDKGPrivateKeyKey(round uint64) []byte {
 	return ret
 }
 
+// coreDKGEncryptedPrivateKeyKey = coreDKGEncryptedPrivateKeyPrefix + round
+func coreDKGEncryptedPrivateKeyKey(round uint64) []byte {
+	ret := make([]byte, len(coreDKGEncryptedPrivateKeyPrefix)+8)
+	copy(ret, coreDKGEncryptedPrivateKeyPrefix)
+	binary.LittleEndian.PutUint64(ret[len(coreDKGEncryptedPrivateKeyPrefix):], round)
+	return ret
+}
+
 // bloomBitsKey = bloomBitsPrefix + bit (uint16 big endian) + section (uint64 big endian) + hash
 func bloomBitsKey(bit uint, section uint64, hash common.Hash) []byte {
 	key := append(append(bloomBitsPrefix, make([]byte, 10)...), hash.Bytes()...)
@@ -0,0 +1,237 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package core
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/rlp"
+	"github.com/dexon-foundation/dexon/trie"
+)
+
+var emptyCodeHash = crypto.Keccak256Hash(nil)
+
+// stateDatabase is a database whose content can be iterated for pruning.
+type stateDatabase interface {
+	ethdb.Database
+	rawdb.DatabaseIteratee
+}
+
+// PruneState deletes the states of the chain in db other than the states at
+// round heights, which the governance looks up the configurations from, and
+// the states of the most recent retention blocks. The database must not be
+// in use by a running node. It returns the number of trie nodes deleted and
+// their size.
+func PruneState(db ethdb.Database, retention uint64) (int, common.StorageSize, error) {
+	sdb, ok := db.(stateDatabase)
+	if !ok {
+		return 0, 0, errors.New("database does not support iteration")
+	}
+	if retention == 0 {
+		retention = triesInMemory
+	}
+	head := rawdb.ReadHeadBlockHash(db)
+	number := rawdb.ReadHeaderNumber(db, head)
+	if number == nil {
+		return 0, 0, errors.New("head block missing")
+	}
+	header := rawdb.ReadHeader(db, head, *number)
+	if header == nil {
+		return 0, 0, errors.New("head block missing")
+	}
+	statedb := state.NewDatabase(db)
+	headState, err := state.New(header.Root, statedb)
+	if err != nil {
+		return 0, 0, fmt.Errorf("head state missing: %v", err)
+	}
+
+	// Collect the states to keep, the states of the recent blocks may not
+	// all be on disk.
+	var (
+		start   = time.Now()
+		marked  = make(map[common.Hash]struct{})
+		heights = make(map[uint64]bool)
+	)
+	for n := uint64(0); n < retention && n <= *number; n++ {
+		heights[*number-n] = false
+	}
+	// Round 0 starts at the genesis, whose height is not recorded by the
+	// governance contract.
+	heights[0] = true
+	helper := &vm.GovernanceStateHelper{StateDB: headState}
+	for round := int64(1); round < helper.LenRoundHeight().Int64(); round++ {
+		heights[helper.RoundHeight(big.NewInt(round)).Uint64()] = true
+	}
+	for height, atRound := range heights {
+		header := rawdb.ReadHeader(db, rawdb.ReadCanonicalHash(db, height), height)
+		if header == nil {
+			continue
+		}
+		if err := markState(statedb, header.Root, marked); err != nil {
+			if _, ok := err.(*trie.MissingNodeError); !ok {
+				return 0, 0, err
+			}
+			if !atRound {
+				log.Debug("Skipped missing recent state", "number", height, "err", err)
+				continue
+			}
+			// States at round heights may only hold the governance state
+			// if they are synced by fast sync or imported from a snapshot.
+			if err := markGovState(statedb, header, marked); err != nil {
+				return 0, 0, fmt.Errorf("state at round height %d missing: %v", height, err)
+			}
+		}
+	}
+	log.Info("Marked states to keep", "blocks", len(heights), "nodes", len(marked),
+		"elapsed", common.PrettyDuration(time.Since(start)))
+
+	// Delete the trie nodes and contract codes not marked, both of which are
+	// keyed by their hashes.
+	var (
+		batch    = db.NewBatch()
+		pruned   int
+		size     common.StorageSize
+		reported = time.Now()
+		it       = sdb.NewIteratorWithPrefix(nil)
+	)
+	defer it.Release()
+
+	for it.Next() {
+		key := it.Key()
+		if len(key) != common.HashLength {
+			continue
+		}
+		if _, ok := marked[common.BytesToHash(key)]; ok {
+			continue
+		}
+		if err := batch.Delete(common.CopyBytes(key)); err != nil {
+			return pruned, size, err
+		}
+		pruned++
+		size += common.StorageSize(len(key) + len(it.Value()))
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return pruned, size, err
+			}
+			batch.Reset()
+		}
+		if time.Since(reported) >= statsReportLimit {
+			log.Info("Pruning state", "nodes", pruned, "size", size,
+				"elapsed", common.PrettyDuration(time.Since(start)))
+			reported = time.Now()
+		}
+	}
+	if err := it.Error(); err != nil {
+		return pruned, size, err
+	}
+	if err := batch.Write(); err != nil {
+		return pruned, size, err
+	}
+	log.Info("Pruned state", "nodes", pruned, "size", size,
+		"elapsed", common.PrettyDuration(time.Since(start)))
+	return pruned, size, nil
+}
+
+// markState marks the nodes of the state trie at root, along with the
+// storage tries and the codes of the accounts. Subtries already marked are
+// not visited again.
+func markState(db state.Database, root common.Hash, marked map[common.Hash]struct{}) error {
+	t, err := db.OpenTrie(root)
+	if err != nil {
+		return err
+	}
+	it := t.NodeIterator(nil)
+	for descend := true; it.Next(descend); {
+		descend = true
+		if it.Leaf() {
+			var account state.Account
+			if err := rlp.DecodeBytes(it.LeafBlob(), &account); err != nil {
+				return err
+			}
+			if err := markTrie(db, account.Root, marked); err != nil {
+				return err
+			}
+			if codeHash := common.BytesToHash(account.CodeHash); codeHash != emptyCodeHash {
+				marked[codeHash] = struct{}{}
+			}
+			continue
+		}
+		descend = mark(it.Hash(), marked)
+	}
+	return it.Error()
+}
+
+// markTrie marks the nodes of the storage trie at root.
+func markTrie(db state.Database, root common.Hash, marked map[common.Hash]struct{}) error {
+	if root == types.EmptyRootHash {
+		return nil
+	}
+	t, err := db.OpenStorageTrie(common.Hash{}, root)
+	if err != nil {
+		return err
+	}
+	it := t.NodeIterator(nil)
+	for descend := true; it.Next(descend); {
+		descend = mark(it.Hash(), marked)
+	}
+	return it.Error()
+}
+
+// mark marks a trie node, it reports whether the node is newly marked. Nodes
+// embedded in their parents have no hash and are never marked.
+func mark(hash common.Hash, marked map[common.Hash]struct{}) bool {
+	if hash == (common.Hash{}) {
+		return true
+	}
+	if _, ok := marked[hash]; ok {
+		return false
+	}
+	marked[hash] = struct{}{}
+	return true
+}
+
+// markGovState marks the account proof and the storage trie of the
+// governance contract at the header.
+func markGovState(db state.Database, header *types.Header, marked map[common.Hash]struct{}) error {
+	statedb, err := state.New(header.Root, db)
+	if err != nil {
+		return err
+	}
+	proof, err := statedb.GetProof(vm.GovernanceContractAddress)
+	if err != nil {
+		return err
+	}
+	for _, node := range proof {
+		marked[crypto.Keccak256Hash(node)] = struct{}{}
+	}
+	if t := statedb.StorageTrie(vm.GovernanceContractAddress); t != nil {
+		return markTrie(db, t.Hash(), marked)
+	}
+	return nil
+}
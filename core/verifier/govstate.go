@@ -0,0 +1,94 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package verifier
+
+import (
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/trie"
+)
+
+// govStateDB keeps the verified governance states in memory. It implements
+// core.GovernanceStateDB.
+type govStateDB struct {
+	db          ethdb.Database
+	headRoot    common.Hash
+	headHeight  uint64
+	height2Root map[uint64]common.Hash
+}
+
+func newGovStateDB() *govStateDB {
+	return &govStateDB{
+		db:          ethdb.NewMemDatabase(),
+		height2Root: make(map[uint64]common.Hash),
+	}
+}
+
+func (g *govStateDB) State() (*state.StateDB, error) {
+	return state.New(g.headRoot, state.NewDatabase(g.db))
+}
+
+func (g *govStateDB) StateAt(height uint64) (*state.StateDB, error) {
+	root, exists := g.height2Root[height]
+	if !exists {
+		return nil, newVerifyError(errGovStateNotReady, "height %d", height)
+	}
+	return state.New(root, state.NewDatabase(g.db))
+}
+
+func (g *govStateDB) has(height uint64) bool {
+	_, exists := g.height2Root[height]
+	return exists
+}
+
+// store stores a verified governance state.
+func (g *govStateDB) store(s *types.GovState) error {
+	for _, node := range s.Proof {
+		if err := g.db.Put(crypto.Keccak256(node), node); err != nil {
+			return err
+		}
+	}
+	triedb := trie.NewDatabase(g.db)
+	t, err := trie.New(common.Hash{}, triedb)
+	if err != nil {
+		return err
+	}
+	for _, kv := range s.Storage {
+		if err := t.TryUpdate(kv[0], kv[1]); err != nil {
+			return err
+		}
+	}
+	root, err := t.Commit(nil)
+	if err != nil {
+		return err
+	}
+	if err := triedb.Commit(root, false); err != nil {
+		return err
+	}
+
+	height := s.Number.Uint64()
+	g.height2Root[height] = s.Root
+	if len(g.height2Root) == 1 || height > g.headHeight {
+		g.headRoot = s.Root
+		g.headHeight = height
+	}
+	return nil
+}
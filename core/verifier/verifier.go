@@ -0,0 +1,320 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package verifier verifies DEXON headers from a trusted checkpoint without
+// a chain database. The randomness of every header is verified against the
+// threshold signature of the DKG set of its round, and the governance states
+// attached to the headers are verified against their state roots.
+package verifier
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+
+	dexCore "github.com/dexon-foundation/dexon-consensus/core"
+	coreCrypto "github.com/dexon-foundation/dexon-consensus/core/crypto"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/rlp"
+	"github.com/dexon-foundation/dexon/trie"
+)
+
+// verifierCacheSize is the number of rounds of DKG group public keys cached.
+const verifierCacheSize = 5
+
+var (
+	errEmptyCheckpoint     = errors.New("empty checkpoint")
+	errNoHeadGovState      = errors.New("no governance state of the checkpoint head")
+	errNonContiguous       = errors.New("non contiguous header")
+	errRoundMismatch       = errors.New("round mismatch")
+	errRoundDecreased      = errors.New("round decreased")
+	errInvalidRandomness   = errors.New("invalid randomness")
+	errGovStateMismatch    = errors.New("governance state does not match header")
+	errInvalidGovStorage   = errors.New("governance storage does not match account")
+	errGovStateNotReady    = errors.New("governance state not ready")
+	errDKGNotFinal         = errors.New("DKG not final")
+	errNoGovernanceAccount = errors.New("governance account not in proof")
+)
+
+// verifyError is a verification failure with the details of the header
+// failing it.
+type verifyError struct {
+	err    error // One of the errors above
+	detail string
+}
+
+func newVerifyError(err error, format string, args ...interface{}) error {
+	return &verifyError{err: err, detail: fmt.Sprintf(format, args...)}
+}
+
+func (e *verifyError) Error() string {
+	return fmt.Sprintf("%v: %s", e.err, e.detail)
+}
+
+// cause returns the error of the verification failure without the details.
+func cause(err error) error {
+	if e, ok := err.(*verifyError); ok {
+		return e.err
+	}
+	return err
+}
+
+// Checkpoint is a set of trusted headers to start verification from. The
+// governance state of the highest header is required, the governance states
+// of the heights rounds start at are needed to verify headers of later
+// rounds.
+type Checkpoint struct {
+	Headers []*types.HeaderWithGovState
+}
+
+// Verifier verifies a sequence of headers following a trusted checkpoint.
+// It is not safe for concurrent use.
+type Verifier struct {
+	head  *types.Header
+	db    *govStateDB
+	gov   *core.Governance
+	cache *dexCore.TSigVerifierCache
+
+	roundHeights map[uint64]uint64 // Height of the first header seen of each round
+}
+
+// New creates a verifier from the trusted checkpoint.
+func New(checkpoint Checkpoint) (*Verifier, error) {
+	if len(checkpoint.Headers) == 0 {
+		return nil, errEmptyCheckpoint
+	}
+	headers := make([]*types.HeaderWithGovState, len(checkpoint.Headers))
+	copy(headers, checkpoint.Headers)
+	sort.Slice(headers, func(i, j int) bool {
+		return headers[i].Number.Cmp(headers[j].Number) < 0
+	})
+	head := headers[len(headers)-1]
+	if head.GovState == nil {
+		return nil, errNoHeadGovState
+	}
+
+	v := &Verifier{
+		head:         head.Header,
+		db:           newGovStateDB(),
+		roundHeights: make(map[uint64]uint64),
+	}
+	v.gov = core.NewGovernance(v.db)
+	v.cache = dexCore.NewTSigVerifierCache(v.gov, verifierCacheSize)
+	for _, header := range headers {
+		if header.GovState == nil {
+			continue
+		}
+		if err := VerifyGovState(header.Header, header.GovState); err != nil {
+			return nil, err
+		}
+		if err := v.db.store(header.GovState); err != nil {
+			return nil, err
+		}
+	}
+	v.roundHeights[head.Round] = head.Number.Uint64()
+	return v, nil
+}
+
+// Head returns the latest verified header.
+func (v *Verifier) Head() *types.Header {
+	return v.head
+}
+
+// Round returns the round of the latest verified header.
+func (v *Verifier) Round() uint64 {
+	return v.head.Round
+}
+
+// RoundHeight returns the height of the first verified header of the round.
+// The round of the checkpoint head starts at or before the returned height.
+func (v *Verifier) RoundHeight(round uint64) (uint64, bool) {
+	height, ok := v.roundHeights[round]
+	return height, ok
+}
+
+// StoreGovState stores the governance state of the latest verified header,
+// which lets headers be verified when the governance states attached to them
+// are not enough.
+func (v *Verifier) StoreGovState(govState *types.GovState) error {
+	if err := VerifyGovState(v.head, govState); err != nil {
+		return err
+	}
+	return v.db.store(govState)
+}
+
+// NeedGovState reports whether the verification failed for lack of a
+// governance state recent enough, see StoreGovState.
+func NeedGovState(err error) bool {
+	switch cause(err) {
+	case errGovStateNotReady, errDKGNotFinal:
+		return true
+	}
+	return false
+}
+
+// Verify verifies the headers following the latest verified header. It
+// returns the index of the first header failing verification, headers
+// before it are verified and the head advances to the last of them.
+func (v *Verifier) Verify(headers []*types.HeaderWithGovState) (int, error) {
+	for i, header := range headers {
+		if err := v.verify(header); err != nil {
+			return i, err
+		}
+	}
+	return 0, nil
+}
+
+func (v *Verifier) verify(header *types.HeaderWithGovState) error {
+	if header.Header == nil {
+		return errNonContiguous
+	}
+	if header.Number.Uint64() != v.head.Number.Uint64()+1 ||
+		header.ParentHash != v.head.Hash() {
+		return newVerifyError(errNonContiguous, "#%d [%x…] after #%d [%x…]",
+			header.Number, header.Hash().Bytes()[:4],
+			v.head.Number, v.head.Hash().Bytes()[:4])
+	}
+	if header.Round < v.head.Round {
+		return errRoundDecreased
+	}
+	if header.GovState != nil {
+		if err := VerifyGovState(header.Header, header.GovState); err != nil {
+			return err
+		}
+	}
+	if err := v.verifyRandomness(header.Header); err != nil {
+		return err
+	}
+	if header.GovState != nil {
+		if err := v.db.store(header.GovState); err != nil {
+			return err
+		}
+	}
+	if header.Round != v.head.Round {
+		v.roundHeights[header.Round] = header.Number.Uint64()
+	}
+	v.head = header.Header
+	return nil
+}
+
+// verifyRandomness verifies the randomness of the header is signed by the
+// DKG set of its round, like HeaderChain does.
+func (v *Verifier) verifyRandomness(header *types.Header) error {
+	if header.Round == 0 {
+		return nil
+	}
+	var dexconMeta coreTypes.Block
+	if err := rlp.DecodeBytes(header.DexconMeta, &dexconMeta); err != nil {
+		return err
+	}
+	if dexconMeta.Position.Round != header.Round {
+		return errRoundMismatch
+	}
+	if err := v.ready(header.Round); err != nil {
+		return err
+	}
+	tsig, ok, err := v.cache.UpdateAndGet(header.Round)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return newVerifyError(errDKGNotFinal, "round %d", header.Round)
+	}
+	if !tsig.VerifySignature(dexconMeta.Hash, coreCrypto.Signature{
+		Type:      "bls",
+		Signature: header.Randomness,
+	}) {
+		return errInvalidRandomness
+	}
+	return nil
+}
+
+// ready checks the governance states needed to verify the randomness of the
+// round are known, since the governance panics on missing states.
+func (v *Verifier) ready(round uint64) error {
+	configRound := uint64(0)
+	if round >= dexCore.ConfigRoundShift {
+		configRound = round - dexCore.ConfigRoundShift
+	}
+	height := v.gov.GetHeadHelper().RoundHeight(
+		new(big.Int).SetUint64(configRound)).Uint64()
+	if configRound != 0 && height == 0 {
+		return newVerifyError(errGovStateNotReady,
+			"height of round %d unknown", configRound)
+	}
+	if !v.db.has(height) {
+		return newVerifyError(errGovStateNotReady, "height %d", height)
+	}
+	return nil
+}
+
+// VerifyGovState verifies the governance state is the state of the
+// governance contract at the header, by the Merkle proof of the governance
+// account against the state root and the storage root of the account.
+func VerifyGovState(header *types.Header, govState *types.GovState) error {
+	if govState.BlockHash != header.Hash() ||
+		govState.Number == nil || govState.Number.Cmp(header.Number) != 0 ||
+		govState.Root != header.Root {
+		return errGovStateMismatch
+	}
+	proofDB := ethdb.NewMemDatabase()
+	for _, node := range govState.Proof {
+		proofDB.Put(crypto.Keccak256(node), node)
+	}
+	key := crypto.Keccak256(vm.GovernanceContractAddress.Bytes())
+	value, _, err := trie.VerifyProof(header.Root, key, proofDB)
+	if err != nil {
+		return err
+	}
+	if value == nil {
+		return errNoGovernanceAccount
+	}
+	var account state.Account
+	if err := rlp.DecodeBytes(value, &account); err != nil {
+		return err
+	}
+	storage, err := storageTrie(govState)
+	if err != nil {
+		return err
+	}
+	if storage.Hash() != account.Root {
+		return errInvalidGovStorage
+	}
+	return nil
+}
+
+func storageTrie(govState *types.GovState) (*trie.Trie, error) {
+	t, err := trie.New(common.Hash{}, trie.NewDatabase(ethdb.NewMemDatabase()))
+	if err != nil {
+		return nil, err
+	}
+	for _, kv := range govState.Storage {
+		if err := t.TryUpdate(kv[0], kv[1]); err != nil {
+			return nil, err
+		}
+	}
+	return t, nil
+}
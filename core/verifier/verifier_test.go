@@ -0,0 +1,359 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package verifier
+
+import (
+	"math/big"
+	"testing"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+	dkgTypes "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/consensus/ethash"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/params"
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+// newTestChain generates a chain of round 0 headers with the governance
+// state of every header.
+func newTestChain(t *testing.T, n int) []*types.HeaderWithGovState {
+	return newTestChainWithAlloc(t, n, nil)
+}
+
+// newTestChainWithAlloc generates a test chain with extra genesis accounts.
+func newTestChainWithAlloc(t *testing.T, n int, alloc core.GenesisAlloc) []*types.HeaderWithGovState {
+	nodekey, _ := crypto.HexToECDSA("3cf5bdee098cc34536a7b0e80d85e07a380efca76fc12136299b9e5ba24193c8")
+	ether := big.NewInt(1e18)
+	gspec := core.Genesis{
+		Config: params.TestnetChainConfig,
+		Alloc: core.GenesisAlloc{
+			crypto.PubkeyToAddress(nodekey.PublicKey): {
+				Balance:   new(big.Int).Mul(big.NewInt(1000), ether),
+				Staked:    new(big.Int).Mul(big.NewInt(500), ether),
+				PublicKey: crypto.FromECDSAPub(&nodekey.PublicKey),
+			},
+		},
+	}
+	for addr, account := range alloc {
+		gspec.Alloc[addr] = account
+	}
+	db := ethdb.NewMemDatabase()
+	genesis := gspec.MustCommit(db)
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, n, nil)
+
+	headers := make([]*types.HeaderWithGovState, 0, n+1)
+	for _, block := range append([]*types.Block{genesis}, blocks...) {
+		statedb, err := state.New(block.Root(), state.NewDatabase(db))
+		if err != nil {
+			t.Fatalf("failed to open state: %v", err)
+		}
+		govState, err := state.GetGovState(statedb, block.Header(),
+			vm.GovernanceContractAddress)
+		if err != nil {
+			t.Fatalf("failed to get governance state: %v", err)
+		}
+		headers = append(headers, &types.HeaderWithGovState{
+			Header:   block.Header(),
+			GovState: govState,
+		})
+	}
+	return headers
+}
+
+func TestVerifyGovState(t *testing.T) {
+	headers := newTestChain(t, 1)
+	header, govState := headers[1].Header, headers[1].GovState
+
+	if err := VerifyGovState(header, govState); err != nil {
+		t.Fatalf("valid governance state rejected: %v", err)
+	}
+
+	// Governance state of another header.
+	if err := VerifyGovState(headers[0].Header, govState); err != errGovStateMismatch {
+		t.Errorf("error mismatch: got %v, want %v", err, errGovStateMismatch)
+	}
+
+	// Tampered storage.
+	tampered := *govState
+	tampered.Storage = tampered.Storage[1:]
+	if err := VerifyGovState(header, &tampered); err != errInvalidGovStorage {
+		t.Errorf("error mismatch: got %v, want %v", err, errInvalidGovStorage)
+	}
+
+	// Missing account proof.
+	tampered = *govState
+	tampered.Proof = nil
+	if err := VerifyGovState(header, &tampered); err == nil {
+		t.Errorf("governance state without proof accepted")
+	}
+}
+
+func TestVerifier(t *testing.T) {
+	headers := newTestChain(t, 4)
+
+	v, err := New(Checkpoint{Headers: headers[:1]})
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	// Headers not following the head are rejected.
+	if i, err := v.Verify(headers[2:]); i != 0 || cause(err) != errNonContiguous {
+		t.Errorf("non contiguous headers accepted: %d, %v", i, err)
+	}
+
+	// Headers without governance states only need to be linked.
+	withoutGovState := &types.HeaderWithGovState{Header: headers[1].Header}
+	if _, err := v.Verify([]*types.HeaderWithGovState{withoutGovState}); err != nil {
+		t.Fatalf("failed to verify header: %v", err)
+	}
+
+	// A tampered governance state stops the verification at the header.
+	tampered := *headers[3].GovState
+	tampered.Root = common.Hash{1}
+	chain := []*types.HeaderWithGovState{
+		headers[2], {Header: headers[3].Header, GovState: &tampered}}
+	if i, err := v.Verify(chain); i != 1 || err != errGovStateMismatch {
+		t.Errorf("tampered governance state accepted: %d, %v", i, err)
+	}
+	if v.Head().Hash() != headers[2].Hash() {
+		t.Errorf("head mismatch: got #%d, want #%d", v.Head().Number, headers[2].Number)
+	}
+	if _, err := v.Verify(headers[3:]); err != nil {
+		t.Fatalf("failed to verify header: %v", err)
+	}
+	if height, ok := v.RoundHeight(0); !ok || height != 0 {
+		t.Errorf("round height mismatch: got %d, want 0", height)
+	}
+}
+
+func TestVerifierDKGNotFinal(t *testing.T) {
+	headers := newTestChain(t, 1)
+
+	v, err := New(Checkpoint{Headers: headers[:1]})
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+
+	// A header of round 1 can't be verified before the DKG of round 1 is
+	// final in the governance state.
+	meta, err := rlp.EncodeToBytes(&coreTypes.Block{
+		Position: coreTypes.Position{Round: 1},
+	})
+	if err != nil {
+		t.Fatalf("failed to encode dexcon meta: %v", err)
+	}
+	header := types.CopyHeader(headers[1].Header)
+	header.Round = 1
+	header.DexconMeta = meta
+	_, err = v.Verify([]*types.HeaderWithGovState{{Header: header}})
+	if cause(err) != errDKGNotFinal {
+		t.Errorf("error mismatch: got %v, want %v", err, errDKGNotFinal)
+	}
+	if !NeedGovState(err) {
+		t.Errorf("verification failure not solvable by governance state: %v", err)
+	}
+	if v.Round() != 0 {
+		t.Errorf("round mismatch: got %d, want 0", v.Round())
+	}
+}
+
+func TestVerifierStoreGovState(t *testing.T) {
+	headers := newTestChain(t, 1)
+
+	v, err := New(Checkpoint{Headers: headers[:1]})
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+	if _, err := v.Verify([]*types.HeaderWithGovState{{Header: headers[1].Header}}); err != nil {
+		t.Fatalf("failed to verify header: %v", err)
+	}
+	// Only the governance state of the head is accepted.
+	if err := v.StoreGovState(headers[0].GovState); err != errGovStateMismatch {
+		t.Errorf("error mismatch: got %v, want %v", err, errGovStateMismatch)
+	}
+	if err := v.StoreGovState(headers[1].GovState); err != nil {
+		t.Fatalf("failed to store governance state: %v", err)
+	}
+	if !v.db.has(1) {
+		t.Errorf("governance state of #1 not stored")
+	}
+}
+
+// storageRecorder records the storage written to the governance contract.
+type storageRecorder struct {
+	*state.StateDB
+	storage map[common.Hash]common.Hash
+}
+
+func (r *storageRecorder) SetState(addr common.Address, key, value common.Hash) {
+	r.StateDB.SetState(addr, key, value)
+	r.storage[key] = value
+}
+
+// testDKG is a DKG of a round whose threshold signatures verify against the
+// group public key.
+type testDKG struct {
+	ids  dkg.IDs
+	keys []*dkg.PrivateKey
+}
+
+// newTestDKG runs a DKG of threshold members in the round and returns the
+// genesis governance account recording it as final.
+func newTestDKG(t *testing.T, round uint64, threshold, finalizeds int) (*testDKG, core.GenesisAccount) {
+	d := &testDKG{}
+	nodeIDs := make([]coreTypes.NodeID, threshold)
+	for i := range nodeIDs {
+		nodeIDs[i] = coreTypes.NodeID{Hash: coreCommon.NewRandomHash()}
+		d.ids = append(d.ids, dkg.NewID(nodeIDs[i].Hash[:]))
+	}
+	prvShares := make([]*dkg.PrivateKeyShares, threshold)
+	pubShares := make([]*dkg.PublicKeyShares, threshold)
+	for i := range prvShares {
+		prvShares[i], pubShares[i] = dkg.NewPrivateKeyShares(threshold)
+		prvShares[i].SetParticipants(d.ids)
+	}
+	for _, id := range d.ids {
+		own := dkg.NewEmptyPrivateKeyShares()
+		for j, shares := range prvShares {
+			share, _ := shares.Share(id)
+			if err := own.AddShare(d.ids[j], share); err != nil {
+				t.Fatalf("failed to add share: %v", err)
+			}
+		}
+		key, err := own.RecoverPrivateKey(d.ids)
+		if err != nil {
+			t.Fatalf("failed to recover private key: %v", err)
+		}
+		d.keys = append(d.keys, key)
+	}
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	recorder := &storageRecorder{StateDB: statedb, storage: make(map[common.Hash]common.Hash)}
+	helper := &vm.GovernanceStateHelper{StateDB: recorder}
+	r := new(big.Int).SetUint64(round)
+	for i, nodeID := range nodeIDs {
+		mpk, err := rlp.EncodeToBytes(&dkgTypes.MasterPublicKey{
+			ProposerID:      nodeID,
+			Round:           round,
+			DKGID:           d.ids[i],
+			PublicKeyShares: *pubShares[i],
+		})
+		if err != nil {
+			t.Fatalf("failed to encode master public key: %v", err)
+		}
+		helper.PushDKGMasterPublicKey(r, mpk)
+	}
+	for i := 0; i < finalizeds; i++ {
+		helper.IncDKGFinalizedsCount(r)
+	}
+	return d, core.GenesisAccount{
+		Balance: big.NewInt(0),
+		Staked:  big.NewInt(0),
+		Storage: recorder.storage,
+	}
+}
+
+// sign returns the threshold signature of the hash.
+func (d *testDKG) sign(t *testing.T, hash coreCommon.Hash) []byte {
+	psigs := make([]dkg.PartialSignature, len(d.keys))
+	for i, key := range d.keys {
+		sig, err := key.Sign(hash)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		psigs[i] = dkg.PartialSignature(sig)
+	}
+	sig, err := dkg.RecoverSignature(psigs, d.ids)
+	if err != nil {
+		t.Fatalf("failed to recover signature: %v", err)
+	}
+	return sig.Signature
+}
+
+func TestVerifierRandomness(t *testing.T) {
+	// The testnet DKG set of 4 has a threshold of 2, and needs 3 finalizeds.
+	d, govAccount := newTestDKG(t, 1, 2, 3)
+	headers := newTestChainWithAlloc(t, 1, core.GenesisAlloc{
+		vm.GovernanceContractAddress: govAccount,
+	})
+
+	// roundHeader returns a round 1 header following the genesis with the
+	// randomness signing hash.
+	roundHeader := func(randomness []byte, hash coreCommon.Hash) *types.HeaderWithGovState {
+		meta, err := rlp.EncodeToBytes(&coreTypes.Block{
+			Hash:     hash,
+			Position: coreTypes.Position{Round: 1},
+		})
+		if err != nil {
+			t.Fatalf("failed to encode dexcon meta: %v", err)
+		}
+		header := types.CopyHeader(headers[1].Header)
+		header.Round = 1
+		header.DexconMeta = meta
+		header.Randomness = randomness
+		return &types.HeaderWithGovState{Header: header}
+	}
+
+	v, err := New(Checkpoint{Headers: headers[:1]})
+	if err != nil {
+		t.Fatalf("failed to create verifier: %v", err)
+	}
+	hash := coreCommon.NewRandomHash()
+
+	// Randomness signing another hash.
+	invalid := roundHeader(d.sign(t, coreCommon.NewRandomHash()), hash)
+	_, err = v.Verify([]*types.HeaderWithGovState{invalid})
+	if err != errInvalidRandomness {
+		t.Errorf("error mismatch: got %v, want %v", err, errInvalidRandomness)
+	}
+	if NeedGovState(err) {
+		t.Errorf("invalid randomness solvable by governance state: %v", err)
+	}
+	// Randomness signed by a single member.
+	sig, err := d.keys[0].Sign(hash)
+	if err != nil {
+		t.Fatalf("failed to sign: %v", err)
+	}
+	partial := roundHeader(sig.Signature, hash)
+	if _, err := v.Verify([]*types.HeaderWithGovState{partial}); err != errInvalidRandomness {
+		t.Errorf("error mismatch: got %v, want %v", err, errInvalidRandomness)
+	}
+	if v.Head().Hash() != headers[0].Hash() {
+		t.Errorf("head advanced on invalid randomness: #%d", v.Head().Number)
+	}
+
+	valid := roundHeader(d.sign(t, hash), hash)
+	if _, err := v.Verify([]*types.HeaderWithGovState{valid}); err != nil {
+		t.Fatalf("valid randomness rejected: %v", err)
+	}
+	if v.Round() != 1 {
+		t.Errorf("round mismatch: got %d, want 1", v.Round())
+	}
+	if height, ok := v.RoundHeight(1); !ok || height != 1 {
+		t.Errorf("round height mismatch: got %d, want 1", height)
+	}
+}
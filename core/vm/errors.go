@@ -16,7 +16,14 @@
 
 package vm
 
-import "errors"
+import (
+	"bytes"
+	"errors"
+
+	"github.com/dexon-foundation/dexon/accounts/abi"
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/crypto"
+)
 
 // List execution errors
 var (
@@ -28,3 +35,38 @@ var (
 	ErrContractAddressCollision = errors.New("contract address collision")
 	ErrNoCompatibleInterpreter  = errors.New("no compatible interpreter")
 )
+
+// revertSelector is the selector of Solidity Error(string), which revert data
+// with a reason is encoded as.
+var revertSelector = crypto.Keccak256([]byte("Error(string)"))[:4]
+
+var revertReasonArgs = func() abi.Arguments {
+	typ, err := abi.NewType("string")
+	if err != nil {
+		panic(err)
+	}
+	return abi.Arguments{{Type: typ}}
+}()
+
+// PackRevertReason encodes the reason as revert data, the same as Solidity
+// does for revert(reason).
+func PackRevertReason(reason string) []byte {
+	data, err := revertReasonArgs.Pack(reason)
+	if err != nil {
+		panic(err)
+	}
+	return append(common.CopyBytes(revertSelector), data...)
+}
+
+// UnpackRevertReason decodes the reason of revert data, reporting whether the
+// data held one.
+func UnpackRevertReason(data []byte) (string, bool) {
+	if len(data) < 4 || !bytes.Equal(data[:4], revertSelector) {
+		return "", false
+	}
+	var reason string
+	if err := revertReasonArgs.Unpack(&reason, data[4:]); err != nil {
+		return "", false
+	}
+	return reason, true
+}
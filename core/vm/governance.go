@@ -20,12 +20,14 @@ package vm
 import (
 	"bytes"
 	"errors"
+	"fmt"
 	"math/big"
 	"sort"
 	"strings"
 
 	"github.com/dexon-foundation/dexon/accounts/abi"
 	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
 	"github.com/dexon-foundation/dexon/core/types"
 	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/params"
@@ -1030,6 +1032,10 @@ const (
 	ReportTypeInvalidDKG = iota
 	ReportTypeForkVote
 	ReportTypeForkBlock
+
+	// NumReportTypes is the number of report types, each of them needs a
+	// fine value.
+	NumReportTypes
 )
 
 func init() {
@@ -1067,13 +1073,84 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 	// Parse input.
 	method, exists := sig2Method[string(input[:4])]
 	if !exists {
-		return nil, errExecutionReverted
+		if !evm.ChainConfig().IsGovernanceRevertReason(evm.BlockNumber) {
+			return nil, errExecutionReverted
+		}
+		return PackRevertReason(errUnknownMethod.Error()), errExecutionReverted
 	}
 
 	// Dispatch method call.
 	g := newGovernanceContract(evm, contract)
-	arguments := input[4:]
+	tracer, tracing := evm.vmConfig.Tracer.(GovernanceTracer)
+	if tracing = tracing && evm.vmConfig.Debug; tracing {
+		g.storage.call = newGovernanceCall(evm, contract, method, input[4:])
+	}
+	ret, err = g.run(method, input[4:])
+
+	// Storage accesses beyond the gas of the contract are not aborted on the
+	// spot, fail the call so they get reverted.
+	if g.storage.outOfGas {
+		ret, err = nil, ErrOutOfGas
+	}
+	if tracing {
+		call := g.storage.call
+		call.GasUsed = call.Gas - contract.Gas
+		call.Output = common.CopyBytes(ret)
+		if err != nil {
+			call.Error = err.Error()
+			call.Reason, _ = UnpackRevertReason(ret)
+		}
+		tracer.CaptureGovernance(evm, call)
+	}
+	return ret, err
+}
+
+// newGovernanceCall decodes the arguments of a governance contract call for
+// tracing.
+func newGovernanceCall(evm *EVM, contract *Contract, method abi.Method, arguments []byte) *GovernanceCall {
+	call := &GovernanceCall{
+		From:   contract.Caller(),
+		Value:  (*hexutil.Big)(new(big.Int).Set(contract.Value())),
+		Depth:  evm.depth + 1,
+		Method: method.Name,
+		Args:   make(map[string]interface{}),
+		Gas:    contract.Gas,
+	}
+	// Undecodable arguments are left out, the call reverts anyway.
+	values, err := method.Inputs.UnpackValues(arguments)
+	if err != nil {
+		return call
+	}
+	for i, value := range values {
+		call.Args[argumentName(method.Inputs[i], i)] = traceValue(value)
+	}
+	return call
+}
+
+// argumentName returns the name of an ABI argument, unnamed arguments are
+// named by their position.
+func argumentName(arg abi.Argument, index int) string {
+	if arg.Name == "" {
+		return fmt.Sprintf("arg%d", index)
+	}
+	return arg.Name
+}
 
+// traceValue converts an unpacked ABI value to a type that marshals to
+// readable JSON.
+func traceValue(value interface{}) interface{} {
+	switch value := value.(type) {
+	case *big.Int:
+		return (*hexutil.Big)(value)
+	case []byte:
+		return hexutil.Bytes(value)
+	case [32]byte:
+		return common.Hash(value)
+	}
+	return value
+}
+
+func (g *GovernanceContract) run(method abi.Method, arguments []byte) ([]byte, error) {
 	switch method.Name {
 	case "addDKGComplaint":
 		args := struct {
@@ -1081,7 +1158,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 			Complaint []byte
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.addDKGComplaint(args.Round, args.Complaint)
 	case "addDKGMasterPublicKey":
@@ -1090,7 +1167,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 			PublicKey []byte
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.addDKGMasterPublicKey(args.Round, args.PublicKey)
 	case "addDKGMPKReady":
@@ -1099,7 +1176,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 			MPKReady []byte
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.addDKGMPKReady(args.Round, args.MPKReady)
 	case "addDKGFinalize":
@@ -1108,19 +1185,19 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 			Finalize []byte
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.addDKGFinalize(args.Round, args.Finalize)
 	case "delegate":
 		address := common.Address{}
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.delegate(address)
 	case "delegatorsLength":
 		address := common.Address{}
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		res, err := method.Outputs.Pack(g.state.LenDelegators(address))
 		if err != nil {
@@ -1136,7 +1213,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 	case "payFine":
 		address := common.Address{}
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.payFine(address)
 	case "proposeCRS":
@@ -1145,7 +1222,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 			SignedCRS []byte
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.proposeCRS(args.Round, args.SignedCRS)
 	case "report":
@@ -1155,7 +1232,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 			Arg2 []byte
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.report(args.Type, args.Arg1, args.Arg2)
 	case "stake":
@@ -1167,7 +1244,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 			Url       string
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.stake(args.PublicKey, args.Name, args.Email, args.Location, args.Url)
 	case "snapshotRound":
@@ -1176,19 +1253,19 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 			Height *big.Int
 		}{}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.snapshotRound(args.Round, args.Height)
 	case "transferOwnership":
 		var newOwner common.Address
 		if err := method.Inputs.Unpack(&newOwner, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.transferOwnership(newOwner)
 	case "undelegate":
 		address := common.Address{}
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.undelegate(address)
 	case "unstake":
@@ -1196,13 +1273,13 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 	case "updateConfiguration":
 		var cfg rawConfigStruct
 		if err := method.Inputs.Unpack(&cfg, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.updateConfiguration(&cfg)
 	case "withdraw":
 		address := common.Address{}
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		return g.withdraw(address)
 
@@ -1225,7 +1302,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 	case "crs":
 		round := new(big.Int)
 		if err := method.Inputs.Unpack(&round, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		res, err := method.Outputs.Pack(g.state.CRS(round))
 		if err != nil {
@@ -1236,7 +1313,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 		nodeAddr, index := common.Address{}, new(big.Int)
 		args := []interface{}{&nodeAddr, &index}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		delegator := g.state.Delegator(nodeAddr, index)
 		res, err := method.Outputs.Pack(delegator.Owner, delegator.Value, delegator.UndelegatedAt)
@@ -1248,7 +1325,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 		nodeAddr, delegatorAddr := common.Address{}, common.Address{}
 		args := []interface{}{&nodeAddr, &delegatorAddr}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		res, err := method.Outputs.Pack(g.state.DelegatorsOffset(nodeAddr, delegatorAddr))
 		if err != nil {
@@ -1259,11 +1336,11 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 		round, index := new(big.Int), new(big.Int)
 		args := []interface{}{&round, &index}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		complaints := g.state.DKGComplaints(round)
 		if int(index.Uint64()) >= len(complaints) {
-			return nil, errExecutionReverted
+			return g.revert(errIndexOutOfRange)
 		}
 		complaint := complaints[index.Uint64()]
 		res, err := method.Outputs.Pack(complaint)
@@ -1275,7 +1352,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 		round, addr := new(big.Int), common.Address{}
 		args := []interface{}{&round, &addr}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		ready := g.state.DKGMPKReady(round, addr)
 		res, err := method.Outputs.Pack(ready)
@@ -1286,7 +1363,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 	case "dkgReadysCount":
 		round := new(big.Int)
 		if err := method.Inputs.Unpack(&round, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		count := g.state.DKGMPKReadysCount(round)
 		res, err := method.Outputs.Pack(count)
@@ -1299,7 +1376,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 		round, addr := new(big.Int), common.Address{}
 		args := []interface{}{&round, &addr}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		finalized := g.state.DKGFinalized(round, addr)
 		res, err := method.Outputs.Pack(finalized)
@@ -1310,7 +1387,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 	case "dkgFinalizedsCount":
 		round := new(big.Int)
 		if err := method.Inputs.Unpack(&round, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		count := g.state.DKGFinalizedsCount(round)
 		res, err := method.Outputs.Pack(count)
@@ -1322,11 +1399,11 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 		round, index := new(big.Int), new(big.Int)
 		args := []interface{}{&round, &index}
 		if err := method.Inputs.Unpack(&args, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		mpks := g.state.DKGMasterPublicKeys(round)
 		if int(index.Uint64()) >= len(mpks) {
-			return nil, errExecutionReverted
+			return g.revert(errIndexOutOfRange)
 		}
 		mpk := mpks[index.Uint64()]
 		res, err := method.Outputs.Pack(mpk)
@@ -1343,7 +1420,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 	case "finedRecords":
 		record := Bytes32{}
 		if err := method.Inputs.Unpack(&record, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		value := g.state.FineRecords(record)
 		res, err := method.Outputs.Pack(value)
@@ -1354,7 +1431,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 	case "fineValues":
 		index := new(big.Int)
 		if err := method.Inputs.Unpack(&index, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		value := g.state.FineValue(index)
 		res, err := method.Outputs.Pack(value)
@@ -1407,7 +1484,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 	case "nodes":
 		index := new(big.Int)
 		if err := method.Inputs.Unpack(&index, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		info := g.state.Node(index)
 		res, err := method.Outputs.Pack(
@@ -1420,7 +1497,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 	case "nodesOffsetByAddress":
 		address := common.Address{}
 		if err := method.Inputs.Unpack(&address, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		res, err := method.Outputs.Pack(g.state.NodesOffsetByAddress(address))
 		if err != nil {
@@ -1430,7 +1507,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 	case "nodesOffsetByID":
 		var id Bytes32
 		if err := method.Inputs.Unpack(&id, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		res, err := method.Outputs.Pack(g.state.NodesOffsetByID(id))
 		if err != nil {
@@ -1458,7 +1535,7 @@ func RunGovernanceContract(evm *EVM, input []byte, contract *Contract) (ret []by
 	case "roundHeight":
 		round := new(big.Int)
 		if err := method.Inputs.Unpack(&round, arguments); err != nil {
-			return nil, errExecutionReverted
+			return g.revert(errInvalidArguments)
 		}
 		res, err := method.Outputs.Pack(g.state.RoundHeight(round))
 		if err != nil {
@@ -1506,6 +1583,7 @@ const (
 	minBlockIntervalLoc
 	fineValuesLoc
 	finedRecordsLoc
+	unstakedDelegatorsLoc
 )
 
 func publicKeyToNodeID(pkBytes []byte) (Bytes32, error) {
@@ -2208,6 +2286,18 @@ func (s *GovernanceStateHelper) SetFineRecords(recordHash Bytes32, status bool)
 	s.setStateBigInt(loc, big.NewInt(value))
 }
 
+// mapping(address => uint256) unstakedDelegators;
+// Number of delegators at the end of the delegators of an unstaking node
+// unstake went through already.
+func (s *GovernanceStateHelper) UnstakedDelegators(nodeAddr common.Address) *big.Int {
+	loc := s.getMapLoc(big.NewInt(unstakedDelegatorsLoc), nodeAddr.Bytes())
+	return s.getStateBigInt(loc)
+}
+func (s *GovernanceStateHelper) PutUnstakedDelegators(nodeAddr common.Address, count *big.Int) {
+	loc := s.getMapLoc(big.NewInt(unstakedDelegatorsLoc), nodeAddr.Bytes())
+	s.setStateBigInt(loc, count)
+}
+
 // Stake is a helper function for creating genesis state.
 func (s *GovernanceStateHelper) Stake(
 	addr common.Address, publicKey []byte, staked *big.Int,
@@ -2370,21 +2460,230 @@ func (s *GovernanceStateHelper) emitUndelegated(nodeAddr, delegatorAddr common.A
 	})
 }
 
+// GovernanceEventID returns the topic of the named governance contract event.
+func GovernanceEventID(name string) (common.Hash, error) {
+	event, ok := events[name]
+	if !ok {
+		return common.Hash{}, fmt.Errorf("unknown governance event %q", name)
+	}
+	return event.Id(), nil
+}
+
+// GovernanceEvent is a decoded log of the governance contract.
+type GovernanceEvent struct {
+	Name string                 `json:"name"`
+	Args map[string]interface{} `json:"args"`
+}
+
+// UnpackGovernanceEvent decodes a log emitted by the governance contract.
+func UnpackGovernanceEvent(log *types.Log) (*GovernanceEvent, error) {
+	if len(log.Topics) == 0 {
+		return nil, errors.New("anonymous governance event")
+	}
+	var event *abi.Event
+	for _, e := range events {
+		if e.Id() == log.Topics[0] {
+			e := e
+			event = &e
+			break
+		}
+	}
+	if event == nil {
+		return nil, fmt.Errorf("unknown governance event %x", log.Topics[0])
+	}
+	// Indexed arguments are static types, stored in the topics with the same
+	// encoding as in the data.
+	var indexed abi.Arguments
+	for _, arg := range event.Inputs {
+		if arg.Indexed {
+			arg.Indexed = false
+			indexed = append(indexed, arg)
+		}
+	}
+	if len(indexed) != len(log.Topics)-1 {
+		return nil, fmt.Errorf("topic count mismatch for %s", event.Name)
+	}
+	var topics []byte
+	for _, topic := range log.Topics[1:] {
+		topics = append(topics, topic.Bytes()...)
+	}
+	indexedValues, err := indexed.UnpackValues(topics)
+	if err != nil {
+		return nil, err
+	}
+	values, err := event.Inputs.UnpackValues(log.Data)
+	if err != nil {
+		return nil, err
+	}
+	decoded := &GovernanceEvent{
+		Name: event.Name,
+		Args: make(map[string]interface{}),
+	}
+	for i, arg := range event.Inputs {
+		var value interface{}
+		if arg.Indexed {
+			value, indexedValues = indexedValues[0], indexedValues[1:]
+		} else {
+			value, values = values[0], values[1:]
+		}
+		decoded.Args[argumentName(arg, i)] = traceValue(value)
+	}
+	return decoded, nil
+}
+
+// Reasons of the governance contract failures, returned to the caller as
+// Solidity Error(string) revert data.
+var (
+	errUnknownMethod         = errors.New("unknown method")
+	errInvalidArguments      = errors.New("invalid arguments")
+	errIndexOutOfRange       = errors.New("index out of range")
+	errNotOwner              = errors.New("caller is not the owner")
+	errNotStaked             = errors.New("node is not staked")
+	errAlreadyStaked         = errors.New("node is already staked")
+	errInvalidNodeInfo       = errors.New("node info is too long")
+	errInvalidPublicKey      = errors.New("invalid public key")
+	errNodeUnstaked          = errors.New("node is unstaked")
+	errNodeFined             = errors.New("node is fined")
+	errNoFund                = errors.New("no fund is sent")
+	errAlreadyDelegated      = errors.New("already delegated")
+	errNotDelegated          = errors.New("not delegated")
+	errNotUndelegated        = errors.New("not undelegated")
+	errLockupNotPassed       = errors.New("lockup period is not passed")
+	errTransferFailed        = errors.New("transfer failed")
+	errNotFined              = errors.New("node is not fined")
+	errFineExceeded          = errors.New("paid more than fined")
+	errAlreadyFined          = errors.New("already fined")
+	errInvalidRound          = errors.New("invalid round")
+	errNotInDKGSet           = errors.New("proposer is not in DKG set")
+	errInvalidSignature      = errors.New("invalid signature")
+	errInvalidComplaint      = errors.New("invalid DKG complaint")
+	errInvalidMPK            = errors.New("invalid DKG master public key")
+	errInvalidMPKReady       = errors.New("invalid DKG MPK ready")
+	errInvalidFinalize       = errors.New("invalid DKG finalize")
+	errMPKNotFound           = errors.New("DKG master public key not found")
+	errDKGMPKReady           = errors.New("DKG MPK is already ready")
+	errDKGSetMPKReady        = errors.New("DKG set is already MPK ready")
+	errDKGFinalized          = errors.New("DKG is already finalized")
+	errDKGSetFinalized       = errors.New("DKG set is already finalized")
+	errInvalidGroupPublicKey = errors.New("invalid DKG group public key")
+	errInvalidReportType     = errors.New("invalid report type")
+	errInvalidReport         = errors.New("invalid report")
+	errNoPenalty             = errors.New("reported misbehavior needs no penalty")
+	errUnknownRoundHeight    = errors.New("unknown round height")
+	errRoundHeightMismatch   = errors.New("round height mismatch")
+	errRoundSnapshotted      = errors.New("round height is already snapshotted")
+)
+
+// Gas of the governance contract methods besides their storage accesses,
+// which are charged the same as SLOAD and SSTORE after the governance
+// metering fork. Before the fork the methods are charged the fixed legacy
+// gas regardless of their storage accesses.
+const (
+	governanceActionGas uint64 = 10000
+	dkgVerifyGas        uint64 = 100000
+	dkgComplaintGas     uint64 = 5000000
+
+	legacyActionGas   uint64 = 100000
+	legacyDelegateGas uint64 = 200000
+)
+
+// unstakeDelegatorsPerCall bounds the delegators a single unstake call
+// undelegates, unstaking nodes with more delegators takes several calls.
+const unstakeDelegatorsPerCall = 100
+
+// meteredStateDB charges the storage accesses of the governance contract to
+// the gas of the contract if metered. Running out of gas is recorded instead
+// of aborting since the state helper has no way to return errors.
+type meteredStateDB struct {
+	StateDB
+	contract *Contract
+	metered  bool
+	sloadGas uint64
+	outOfGas bool
+
+	// call collects the storage writes and events while tracing.
+	call *GovernanceCall
+}
+
+func (m *meteredStateDB) useGas(gas uint64) {
+	if !m.metered {
+		return
+	}
+	if !m.contract.UseGas(gas) {
+		m.outOfGas = true
+	}
+}
+
+func (m *meteredStateDB) GetState(addr common.Address, key common.Hash) common.Hash {
+	m.useGas(m.sloadGas)
+	return m.StateDB.GetState(addr, key)
+}
+
+func (m *meteredStateDB) SetState(addr common.Address, key, value common.Hash) {
+	// Same as the legacy SSTORE metering, see gasSStore, except rewriting
+	// the current value is charged as a no-op. The state helper rewrites
+	// whole structs to update single fields.
+	current := m.StateDB.GetState(addr, key)
+	switch {
+	case current == value:
+		m.useGas(params.NetSstoreNoopGas)
+	case current == (common.Hash{}) && value != (common.Hash{}):
+		m.useGas(params.SstoreSetGas)
+	case current != (common.Hash{}) && value == (common.Hash{}):
+		m.StateDB.AddRefund(params.SstoreRefundGas)
+		m.useGas(params.SstoreClearGas)
+	default:
+		m.useGas(params.SstoreResetGas)
+	}
+	m.StateDB.SetState(addr, key, value)
+
+	if m.call != nil {
+		m.call.Storage = append(m.call.Storage, GovernanceStorage{Slot: key, Value: value})
+	}
+}
+
+func (m *meteredStateDB) AddLog(log *types.Log) {
+	m.StateDB.AddLog(log)
+
+	if m.call != nil {
+		if event, err := UnpackGovernanceEvent(log); err == nil {
+			m.call.Events = append(m.call.Events, event)
+		}
+	}
+}
+
 // GovernanceContract represents the governance contract of DEXCON.
 type GovernanceContract struct {
 	evm      *EVM
 	state    GovernanceStateHelper
+	storage  *meteredStateDB
 	contract *Contract
 }
 
 func newGovernanceContract(evm *EVM, contract *Contract) *GovernanceContract {
+	storage := &meteredStateDB{
+		StateDB:  evm.StateDB,
+		contract: contract,
+		metered:  evm.ChainConfig().IsGovernanceMetering(evm.BlockNumber),
+		sloadGas: evm.ChainConfig().GasTable(evm.BlockNumber).SLoad,
+	}
 	return &GovernanceContract{
 		evm:      evm,
-		state:    GovernanceStateHelper{evm.StateDB},
+		state:    GovernanceStateHelper{storage},
+		storage:  storage,
 		contract: contract,
 	}
 }
 
+// actionGas returns the gas of a governance action, which is the legacy
+// fixed gas before the governance metering fork.
+func (g *GovernanceContract) actionGas(legacy uint64) uint64 {
+	if !g.storage.metered {
+		return legacy
+	}
+	return governanceActionGas
+}
+
 func (g *GovernanceContract) Address() common.Address {
 	return GovernanceContractAddress
 }
@@ -2405,9 +2704,20 @@ func (g *GovernanceContract) useGas(gas uint64) ([]byte, error) {
 	return nil, nil
 }
 
-func (g *GovernanceContract) penalize() ([]byte, error) {
+// revert fails the call, returning the reason as revert data after the
+// revert reason fork. Callers see the revert data, so no data is returned
+// before the fork.
+func (g *GovernanceContract) revert(reason error) ([]byte, error) {
+	if !g.evm.ChainConfig().IsGovernanceRevertReason(g.evm.BlockNumber) {
+		return nil, errExecutionReverted
+	}
+	return PackRevertReason(reason.Error()), errExecutionReverted
+}
+
+// penalize fails the call consuming all the gas of the caller.
+func (g *GovernanceContract) penalize(reason error) ([]byte, error) {
 	g.useGas(g.contract.Gas)
-	return nil, errExecutionReverted
+	return g.revert(reason)
 }
 
 func (g *GovernanceContract) inDKGSet(round *big.Int, nodeID coreTypes.NodeID) bool {
@@ -2440,14 +2750,14 @@ func (g *GovernanceContract) inDKGSet(round *big.Int, nodeID coreTypes.NodeID) b
 
 func (g *GovernanceContract) addDKGComplaint(round *big.Int, comp []byte) ([]byte, error) {
 	if round.Cmp(g.state.Round()) != 0 {
-		return g.penalize()
+		return g.penalize(errInvalidRound)
 	}
 
 	caller := g.contract.Caller()
 
 	// Finalized caller is not allowed to propose complaint.
 	if g.state.DKGFinalized(round, caller) {
-		return g.penalize()
+		return g.penalize(errDKGFinalized)
 	}
 
 	// Calculate 2f
@@ -2457,60 +2767,60 @@ func (g *GovernanceContract) addDKGComplaint(round *big.Int, comp []byte) ([]byt
 
 	// If 2f + 1 of DKG set is finalized, one can not propose complaint anymore.
 	if g.state.DKGFinalizedsCount(round).Cmp(threshold) > 0 {
-		return nil, errExecutionReverted
+		return g.revert(errDKGSetFinalized)
 	}
 
 	var dkgComplaint dkgTypes.Complaint
 	if err := rlp.DecodeBytes(comp, &dkgComplaint); err != nil {
-		return g.penalize()
+		return g.penalize(errInvalidComplaint)
 	}
 
 	// DKGComplaint must belongs to someone in DKG set.
 	if !g.inDKGSet(round, dkgComplaint.ProposerID) {
-		return g.penalize()
+		return g.penalize(errNotInDKGSet)
 	}
 
 	verified, _ := coreUtils.VerifyDKGComplaintSignature(&dkgComplaint)
 	if !verified {
-		return g.penalize()
+		return g.penalize(errInvalidSignature)
 	}
 
 	mpk, err := g.state.GetDKGMasterPublicKeyByProposerID(
 		round, dkgComplaint.PrivateShare.ProposerID)
 	if err != nil {
-		return g.penalize()
+		return g.penalize(errMPKNotFound)
 	}
 
 	// Verify DKG complaint is correct.
 	ok, err := coreUtils.VerifyDKGComplaint(&dkgComplaint, mpk)
 	if !ok || err != nil {
-		return g.penalize()
+		return g.penalize(errInvalidComplaint)
 	}
 
 	// Fine the attacker.
 	need, err := coreUtils.NeedPenaltyDKGPrivateShare(&dkgComplaint, mpk)
 	if err != nil {
-		return g.penalize()
+		return g.penalize(errInvalidComplaint)
 	}
 	if need {
 		fineValue := g.state.FineValue(big.NewInt(ReportTypeInvalidDKG))
 		offset := g.state.NodesOffsetByID(Bytes32(dkgComplaint.PrivateShare.ProposerID.Hash))
 		node := g.state.Node(offset)
 		if err := g.fine(node.Owner, fineValue, comp, nil); err != nil {
-			return g.penalize()
+			return g.penalize(err)
 		}
 	}
 
 	g.state.PushDKGComplaint(round, comp)
 
 	// Set this to relatively high to prevent spamming
-	return g.useGas(5000000)
+	return g.useGas(dkgComplaintGas)
 }
 
 func (g *GovernanceContract) addDKGMasterPublicKey(round *big.Int, mpk []byte) ([]byte, error) {
 	// Can only add DKG master public key of current and next round.
 	if round.Cmp(new(big.Int).Add(g.state.Round(), big.NewInt(1))) > 0 {
-		return g.penalize()
+		return g.penalize(errInvalidRound)
 	}
 
 	caller := g.contract.Caller()
@@ -2518,12 +2828,12 @@ func (g *GovernanceContract) addDKGMasterPublicKey(round *big.Int, mpk []byte) (
 
 	// Can not add dkg mpk if not staked.
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert(errNotStaked)
 	}
 
 	// MPKReady caller is not allowed to propose mpk.
 	if g.state.DKGMPKReady(round, caller) {
-		return g.penalize()
+		return g.penalize(errDKGMPKReady)
 	}
 
 	// Calculate 2f
@@ -2533,49 +2843,49 @@ func (g *GovernanceContract) addDKGMasterPublicKey(round *big.Int, mpk []byte) (
 
 	// If 2f + 1 of DKG set is mpk ready, one can not propose mpk anymore.
 	if g.state.DKGMPKReadysCount(round).Cmp(threshold) > 0 {
-		return nil, errExecutionReverted
+		return g.revert(errDKGSetMPKReady)
 	}
 
 	var dkgMasterPK dkgTypes.MasterPublicKey
 	if err := rlp.DecodeBytes(mpk, &dkgMasterPK); err != nil {
-		return g.penalize()
+		return g.penalize(errInvalidMPK)
 	}
 
 	// DKGMasterPublicKey must belongs to someone in DKG set.
 	if !g.inDKGSet(round, dkgMasterPK.ProposerID) {
-		return g.penalize()
+		return g.penalize(errNotInDKGSet)
 	}
 
 	verified, _ := coreUtils.VerifyDKGMasterPublicKeySignature(&dkgMasterPK)
 	if !verified {
-		return g.penalize()
+		return g.penalize(errInvalidSignature)
 	}
 
 	g.state.PushDKGMasterPublicKey(round, mpk)
 
-	return g.useGas(100000)
+	return g.useGas(dkgVerifyGas)
 }
 
 func (g *GovernanceContract) addDKGMPKReady(round *big.Int, ready []byte) ([]byte, error) {
 	if round.Cmp(g.state.Round()) != 0 {
-		return g.penalize()
+		return g.penalize(errInvalidRound)
 	}
 
 	caller := g.contract.Caller()
 
 	var dkgReady dkgTypes.MPKReady
 	if err := rlp.DecodeBytes(ready, &dkgReady); err != nil {
-		return g.penalize()
+		return g.penalize(errInvalidMPKReady)
 	}
 
 	// DKGFInalize must belongs to someone in DKG set.
 	if !g.inDKGSet(round, dkgReady.ProposerID) {
-		return g.penalize()
+		return g.penalize(errNotInDKGSet)
 	}
 
 	verified, _ := coreUtils.VerifyDKGMPKReadySignature(&dkgReady)
 	if !verified {
-		return g.penalize()
+		return g.penalize(errInvalidSignature)
 	}
 
 	if !g.state.DKGMPKReady(round, caller) {
@@ -2583,28 +2893,28 @@ func (g *GovernanceContract) addDKGMPKReady(round *big.Int, ready []byte) ([]byt
 		g.state.IncDKGMPKReadysCount(round)
 	}
 
-	return g.useGas(100000)
+	return g.useGas(dkgVerifyGas)
 }
 func (g *GovernanceContract) addDKGFinalize(round *big.Int, finalize []byte) ([]byte, error) {
 	if round.Cmp(g.state.Round()) != 0 {
-		return g.penalize()
+		return g.penalize(errInvalidRound)
 	}
 
 	caller := g.contract.Caller()
 
 	var dkgFinalize dkgTypes.Finalize
 	if err := rlp.DecodeBytes(finalize, &dkgFinalize); err != nil {
-		return g.penalize()
+		return g.penalize(errInvalidFinalize)
 	}
 
 	// DKGFInalize must belongs to someone in DKG set.
 	if !g.inDKGSet(round, dkgFinalize.ProposerID) {
-		return g.penalize()
+		return g.penalize(errNotInDKGSet)
 	}
 
 	verified, _ := coreUtils.VerifyDKGFinalizeSignature(&dkgFinalize)
 	if !verified {
-		return g.penalize()
+		return g.penalize(errInvalidSignature)
 	}
 
 	if !g.state.DKGFinalized(round, caller) {
@@ -2612,13 +2922,13 @@ func (g *GovernanceContract) addDKGFinalize(round *big.Int, finalize []byte) ([]
 		g.state.IncDKGFinalizedsCount(round)
 	}
 
-	return g.useGas(100000)
+	return g.useGas(dkgVerifyGas)
 }
 
 func (g *GovernanceContract) delegate(nodeAddr common.Address) ([]byte, error) {
 	offset := g.state.NodesOffsetByAddress(nodeAddr)
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert(errNotStaked)
 	}
 
 	caller := g.contract.Caller()
@@ -2626,17 +2936,22 @@ func (g *GovernanceContract) delegate(nodeAddr common.Address) ([]byte, error) {
 
 	// Can not delegate if no fund was sent.
 	if value.Cmp(big.NewInt(0)) == 0 {
-		return nil, errExecutionReverted
+		return g.revert(errNoFund)
 	}
 
 	// Can not delegate if already delegated.
 	delegatorOffset := g.state.DelegatorsOffset(nodeAddr, caller)
 	if delegatorOffset.Cmp(big.NewInt(0)) >= 0 {
-		return nil, errExecutionReverted
+		return g.revert(errAlreadyDelegated)
 	}
 
-	// Add to the total staked of node.
+	// Can not delegate to unstaked node.
 	node := g.state.Node(offset)
+	if node.Unstaked && g.storage.metered {
+		return g.revert(errNodeUnstaked)
+	}
+
+	// Add to the total staked of node.
 	node.Staked = new(big.Int).Add(node.Staked, g.contract.Value())
 	g.state.UpdateNode(offset, node)
 
@@ -2650,13 +2965,13 @@ func (g *GovernanceContract) delegate(nodeAddr common.Address) ([]byte, error) {
 	g.state.PutDelegatorOffset(nodeAddr, caller, offset)
 	g.state.emitDelegated(nodeAddr, caller, value)
 
-	return g.useGas(200000)
+	return g.useGas(g.actionGas(legacyDelegateGas))
 }
 
 func (g *GovernanceContract) updateConfiguration(cfg *rawConfigStruct) ([]byte, error) {
 	// Only owner can update configuration.
 	if g.contract.Caller() != g.state.Owner() {
-		return nil, errExecutionReverted
+		return g.revert(errNotOwner)
 	}
 
 	g.state.UpdateConfigurationRaw(cfg)
@@ -2669,7 +2984,7 @@ func (g *GovernanceContract) stake(
 
 	// Reject invalid inputs.
 	if len(name) >= 32 || len(email) >= 32 || len(location) >= 32 || len(url) >= 128 {
-		return g.penalize()
+		return g.penalize(errInvalidNodeInfo)
 	}
 
 	caller := g.contract.Caller()
@@ -2677,7 +2992,7 @@ func (g *GovernanceContract) stake(
 
 	// Can not stake if already staked.
 	if offset.Cmp(big.NewInt(0)) >= 0 {
-		return nil, errExecutionReverted
+		return g.revert(errAlreadyStaked)
 	}
 
 	offset = g.state.LenNodes()
@@ -2693,7 +3008,7 @@ func (g *GovernanceContract) stake(
 	}
 	g.state.PushNode(node)
 	if err := g.state.PutNodeOffsets(node, offset); err != nil {
-		return g.penalize()
+		return g.penalize(errInvalidPublicKey)
 	}
 
 	// Delegate fund to itself.
@@ -2704,23 +3019,23 @@ func (g *GovernanceContract) stake(
 	}
 
 	g.state.emitStaked(caller)
-	return g.useGas(100000)
+	return g.useGas(g.actionGas(legacyActionGas))
 }
 
 func (g *GovernanceContract) undelegateHelper(nodeAddr, caller common.Address) ([]byte, error) {
 	nodeOffset := g.state.NodesOffsetByAddress(nodeAddr)
 	if nodeOffset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert(errNotStaked)
 	}
 
 	offset := g.state.DelegatorsOffset(nodeAddr, caller)
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert(errNotDelegated)
 	}
 
 	node := g.state.Node(nodeOffset)
 	if node.Fined.Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert(errNodeFined)
 	}
 
 	delegator := g.state.Delegator(nodeAddr, offset)
@@ -2735,7 +3050,7 @@ func (g *GovernanceContract) undelegateHelper(nodeAddr, caller common.Address) (
 
 	g.state.emitUndelegated(nodeAddr, caller)
 
-	return g.useGas(100000)
+	return g.useGas(g.actionGas(legacyActionGas))
 }
 
 func (g *GovernanceContract) undelegate(nodeAddr common.Address) ([]byte, error) {
@@ -2747,24 +3062,24 @@ func (g *GovernanceContract) withdraw(nodeAddr common.Address) ([]byte, error) {
 
 	nodeOffset := g.state.NodesOffsetByAddress(nodeAddr)
 	if nodeOffset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert(errNotStaked)
 	}
 
 	offset := g.state.DelegatorsOffset(nodeAddr, caller)
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert(errNotDelegated)
 	}
 
 	delegator := g.state.Delegator(nodeAddr, offset)
 
 	// Not yet undelegated.
 	if delegator.UndelegatedAt.Cmp(big.NewInt(0)) == 0 {
-		return g.penalize()
+		return g.penalize(errNotUndelegated)
 	}
 
 	unlockTime := new(big.Int).Add(delegator.UndelegatedAt, g.state.LockupPeriod())
 	if g.evm.Time.Cmp(unlockTime) <= 0 {
-		return g.penalize()
+		return g.penalize(errLockupNotPassed)
 	}
 
 	length := g.state.LenDelegators(nodeAddr)
@@ -2779,9 +3094,17 @@ func (g *GovernanceContract) withdraw(nodeAddr common.Address) ([]byte, error) {
 	g.state.DeleteDelegatorsOffset(nodeAddr, caller)
 	g.state.PopLastDelegator(nodeAddr)
 
+	// The delegator moved into the freed offset was gone through by unstake
+	// if any was, keep the ones gone through at the end of the list.
+	if g.storage.metered {
+		if done := g.state.UnstakedDelegators(nodeAddr); done.Sign() > 0 {
+			g.state.PutUnstakedDelegators(nodeAddr, done.Sub(done, big.NewInt(1)))
+		}
+	}
+
 	// Return the staked fund.
 	if !g.transfer(GovernanceContractAddress, delegator.Owner, delegator.Value) {
-		return nil, errExecutionReverted
+		return g.revert(errTransferFailed)
 	}
 
 	// We are the last delegator to withdraw the fund, remove the node info.
@@ -2801,22 +3124,57 @@ func (g *GovernanceContract) withdraw(nodeAddr common.Address) ([]byte, error) {
 		g.state.PopLastNode()
 	}
 
-	return g.useGas(100000)
+	return g.useGas(g.actionGas(legacyActionGas))
 }
 
 func (g *GovernanceContract) unstake() ([]byte, error) {
 	caller := g.contract.Caller()
 	offset := g.state.NodesOffsetByAddress(caller)
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert(errNotStaked)
 	}
 
 	node := g.state.Node(offset)
 	if node.Fined.Cmp(big.NewInt(0)) > 0 {
-		return nil, errExecutionReverted
+		return g.revert(errNodeFined)
+	}
+	if !g.storage.metered {
+		return g.unstakeAll(caller, offset, node)
 	}
 
-	// Undelegate all delegators.
+	// Mark node as unstaked, calls after the first one only continue
+	// undelegating.
+	if !node.Unstaked {
+		node.Unstaked = true
+		g.state.UpdateNode(offset, node)
+		g.state.emitUnstaked(caller)
+	}
+
+	// Undelegate the delegators from the end of the list, a page of them per
+	// call. Delegators which undelegated themselves are skipped.
+	done := g.state.UnstakedDelegators(caller)
+	i := new(big.Int).Sub(g.state.LenDelegators(caller), done)
+	for n := 0; n < unstakeDelegatorsPerCall && i.Sign() > 0; n++ {
+		if g.storage.outOfGas {
+			return nil, ErrOutOfGas
+		}
+		i.Sub(i, big.NewInt(1))
+		delegator := g.state.Delegator(caller, i)
+		if delegator.UndelegatedAt.Sign() == 0 {
+			if ret, err := g.undelegateHelper(caller, delegator.Owner); err != nil {
+				return ret, err
+			}
+		}
+		done.Add(done, big.NewInt(1))
+	}
+	g.state.PutUnstakedDelegators(caller, done)
+
+	return g.useGas(g.actionGas(legacyActionGas))
+}
+
+// unstakeAll undelegates all delegators of the node in a single call, as
+// unstake does before the governance metering fork.
+func (g *GovernanceContract) unstakeAll(caller common.Address, offset *big.Int, node *nodeInfo) ([]byte, error) {
 	lenDelegators := g.state.LenDelegators(caller)
 	i := new(big.Int).Sub(lenDelegators, big.NewInt(1))
 	for i.Cmp(big.NewInt(0)) >= 0 {
@@ -2833,7 +3191,7 @@ func (g *GovernanceContract) unstake() ([]byte, error) {
 
 	g.state.emitUnstaked(caller)
 
-	return g.useGas(100000)
+	return g.useGas(legacyActionGas)
 }
 
 func (g *GovernanceContract) payFine(nodeAddr common.Address) ([]byte, error) {
@@ -2841,17 +3199,20 @@ func (g *GovernanceContract) payFine(nodeAddr common.Address) ([]byte, error) {
 
 	nodeOffset := g.state.NodesOffsetByAddress(nodeAddr)
 	if nodeOffset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert(errNotStaked)
 	}
 
 	offset := g.state.DelegatorsOffset(nodeAddr, caller)
 	if offset.Cmp(big.NewInt(0)) < 0 {
-		return nil, errExecutionReverted
+		return g.revert(errNotDelegated)
 	}
 
 	node := g.state.Node(nodeOffset)
-	if node.Fined.Cmp(big.NewInt(0)) <= 0 || node.Fined.Cmp(g.contract.Value()) < 0 {
-		return nil, errExecutionReverted
+	if node.Fined.Cmp(big.NewInt(0)) <= 0 {
+		return g.revert(errNotFined)
+	}
+	if node.Fined.Cmp(g.contract.Value()) < 0 {
+		return g.revert(errFineExceeded)
 	}
 
 	node.Fined = new(big.Int).Sub(node.Fined, g.contract.Value())
@@ -2859,14 +3220,14 @@ func (g *GovernanceContract) payFine(nodeAddr common.Address) ([]byte, error) {
 
 	// TODO: paid fine should be added to award pool.
 
-	return g.useGas(100000)
+	return g.useGas(g.actionGas(legacyActionGas))
 }
 
 func (g *GovernanceContract) proposeCRS(nextRound *big.Int, signedCRS []byte) ([]byte, error) {
 	round := g.state.Round()
 
 	if nextRound.Cmp(round) <= 0 {
-		return nil, errExecutionReverted
+		return g.revert(errInvalidRound)
 	}
 
 	prevCRS := g.state.CRS(round)
@@ -2889,14 +3250,14 @@ func (g *GovernanceContract) proposeCRS(nextRound *big.Int, signedCRS []byte) ([
 	dkgGPK, err := core.NewDKGGroupPublicKey(
 		round.Uint64(), dkgMasterPKs, dkgComplaints, threshold)
 	if err != nil {
-		return nil, errExecutionReverted
+		return g.revert(errInvalidGroupPublicKey)
 	}
 	signature := coreCrypto.Signature{
 		Type:      "bls",
 		Signature: signedCRS,
 	}
 	if !dkgGPK.VerifySignature(coreCommon.Hash(prevCRS), signature) {
-		return g.penalize()
+		return g.penalize(errInvalidSignature)
 	}
 
 	// Save new CRS into state and increase round.
@@ -2930,13 +3291,13 @@ func (g *GovernanceContract) fine(nodeAddr common.Address, amount *big.Int, payl
 
 	hash := Bytes32(crypto.Keccak256Hash(payloads...))
 	if g.state.FineRecords(hash) {
-		return errors.New("already fined")
+		return errAlreadyFined
 	}
 	g.state.SetFineRecords(hash, true)
 
 	nodeOffset := g.state.NodesOffsetByAddress(nodeAddr)
 	if nodeOffset.Cmp(big.NewInt(0)) < 0 {
-		return errExecutionReverted
+		return errNotStaked
 	}
 
 	// Set fined value.
@@ -2955,33 +3316,33 @@ func (g *GovernanceContract) report(reportType *big.Int, arg1, arg2 []byte) ([]b
 	case ReportTypeForkVote:
 		vote1 := new(coreTypes.Vote)
 		if err := rlp.DecodeBytes(arg1, vote1); err != nil {
-			return g.penalize()
+			return g.penalize(errInvalidReport)
 		}
 		vote2 := new(coreTypes.Vote)
 		if err := rlp.DecodeBytes(arg2, vote2); err != nil {
-			return g.penalize()
+			return g.penalize(errInvalidReport)
 		}
 		need, err := coreUtils.NeedPenaltyForkVote(vote1, vote2)
 		if !need || err != nil {
-			return g.penalize()
+			return g.penalize(errNoPenalty)
 		}
 		reportedNodeID = vote1.ProposerID
 	case ReportTypeForkBlock:
 		block1 := new(coreTypes.Block)
 		if err := rlp.DecodeBytes(arg1, block1); err != nil {
-			return g.penalize()
+			return g.penalize(errInvalidReport)
 		}
 		block2 := new(coreTypes.Block)
 		if err := rlp.DecodeBytes(arg2, block2); err != nil {
-			return g.penalize()
+			return g.penalize(errInvalidReport)
 		}
 		need, err := coreUtils.NeedPenaltyForkBlock(block1, block2)
 		if !need || err != nil {
-			return g.penalize()
+			return g.penalize(errNoPenalty)
 		}
 		reportedNodeID = block1.ProposerID
 	default:
-		return g.penalize()
+		return g.penalize(errInvalidReportType)
 	}
 
 	offset := g.state.NodesOffsetByID(Bytes32(reportedNodeID.Hash))
@@ -2989,7 +3350,7 @@ func (g *GovernanceContract) report(reportType *big.Int, arg1, arg2 []byte) ([]b
 
 	fineValue := g.state.FineValue(reportType)
 	if err := g.fine(node.Owner, fineValue, arg1, arg2); err != nil {
-		return nil, errExecutionReverted
+		return g.revert(err)
 	}
 	return nil, nil
 }
@@ -2997,7 +3358,7 @@ func (g *GovernanceContract) report(reportType *big.Int, arg1, arg2 []byte) ([]b
 func (g *GovernanceContract) transferOwnership(newOwner common.Address) ([]byte, error) {
 	// Only owner can update configuration.
 	if g.contract.Caller() != g.state.Owner() {
-		return nil, errExecutionReverted
+		return g.revert(errNotOwner)
 	}
 	g.state.SetOwner(newOwner)
 	return nil, nil
@@ -3008,11 +3369,11 @@ func (g *GovernanceContract) snapshotRound(round, height *big.Int) ([]byte, erro
 	if g.evm.IsBlockProposer() {
 		realHeight, ok := g.evm.GetRoundHeight(round.Uint64())
 		if !ok {
-			return g.penalize()
+			return g.penalize(errUnknownRoundHeight)
 		}
 
 		if height.Cmp(new(big.Int).SetUint64(realHeight)) != 0 {
-			return g.penalize()
+			return g.penalize(errRoundHeightMismatch)
 		}
 	}
 
@@ -3021,7 +3382,7 @@ func (g *GovernanceContract) snapshotRound(round, height *big.Int) ([]byte, erro
 	if round.Cmp(nextRound) != 0 {
 		// No need to penalize, since the only possibility at this point is the
 		// round height is already snapshoted.
-		return nil, errExecutionReverted
+		return g.revert(errRoundSnapshotted)
 	}
 
 	g.state.PushRoundHeight(height)
@@ -20,6 +20,7 @@ package vm
 import (
 	"bytes"
 	"crypto/ecdsa"
+	"fmt"
 	"math/big"
 	"math/rand"
 	"sort"
@@ -33,7 +34,9 @@ import (
 	coreUtils "github.com/dexon-foundation/dexon-consensus/core/utils"
 
 	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
 	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
 	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/params"
@@ -94,10 +97,12 @@ func TestGovernanceStateHelper(t *testing.T) {
 type GovernanceContractTestSuite struct {
 	suite.Suite
 
-	config  *params.DexconConfig
-	memDB   *ethdb.MemDatabase
-	stateDB *state.StateDB
-	s       *GovernanceStateHelper
+	config      *params.DexconConfig
+	chainConfig *params.ChainConfig
+	memDB       *ethdb.MemDatabase
+	stateDB     *state.StateDB
+	s           *GovernanceStateHelper
+	tracer      Tracer
 }
 
 func (g *GovernanceContractTestSuite) SetupTest() {
@@ -114,6 +119,7 @@ func (g *GovernanceContractTestSuite) SetupTest() {
 	config.LockupPeriod = 1000
 
 	g.config = config
+	g.chainConfig = params.TestChainConfig
 
 	// Give governance contract balance so it will not be deleted because of being an empty state object.
 	stateDB.AddBalance(GovernanceContractAddress, big.NewInt(1))
@@ -146,6 +152,12 @@ func (g *GovernanceContractTestSuite) newPrefundAccount() (*ecdsa.PrivateKey, co
 }
 
 func (g *GovernanceContractTestSuite) call(caller common.Address, input []byte, value *big.Int) ([]byte, error) {
+	ret, _, err := g.callGas(caller, input, value)
+	return ret, err
+}
+
+// callGas calls the governance contract returning the gas used too.
+func (g *GovernanceContractTestSuite) callGas(caller common.Address, input []byte, value *big.Int) ([]byte, uint64, error) {
 	context := Context{
 		CanTransfer: func(db StateDB, addr common.Address, amount *big.Int) bool {
 			return db.GetBalance(addr).Cmp(amount) >= 0
@@ -169,9 +181,15 @@ func (g *GovernanceContractTestSuite) call(caller common.Address, input []byte,
 		BlockNumber: big.NewInt(0),
 	}
 
-	evm := NewEVM(context, g.stateDB, params.TestChainConfig, Config{IsBlockProposer: true})
-	ret, _, err := evm.Call(AccountRef(caller), GovernanceContractAddress, input, 10000000, value)
-	return ret, err
+	vmConfig := Config{IsBlockProposer: true}
+	if g.tracer != nil {
+		vmConfig.Debug = true
+		vmConfig.Tracer = g.tracer
+	}
+	evm := NewEVM(context, g.stateDB, g.chainConfig, vmConfig)
+	gas := uint64(10000000)
+	ret, leftOverGas, err := evm.Call(AccountRef(caller), GovernanceContractAddress, input, gas, value)
+	return ret, gas - leftOverGas, err
 }
 
 func (g *GovernanceContractTestSuite) TestTransferOwnership() {
@@ -974,6 +992,454 @@ func (g *GovernanceContractTestSuite) TestMiscVariableReading() {
 	g.Require().NoError(err)
 }
 
+// stakeWithDelegators stakes a node with the given number of extra
+// delegators, returning the address of the node and the delegators.
+func (g *GovernanceContractTestSuite) stakeWithDelegators(n int) (common.Address, []common.Address, error) {
+	privKey, addr := g.newPrefundAccount()
+	pk := crypto.FromECDSAPub(&privKey.PublicKey)
+
+	amount := new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e5))
+	input, err := abiObject.Pack("stake", pk, "Test1", "test1@dexon.org", "Taipei, Taiwan", "https://dexon.org")
+	if err != nil {
+		return addr, nil, err
+	}
+	if _, err := g.call(addr, input, amount); err != nil {
+		return addr, nil, err
+	}
+
+	input, err = abiObject.Pack("delegate", addr)
+	if err != nil {
+		return addr, nil, err
+	}
+	delegators := make([]common.Address, n)
+	for i := range delegators {
+		_, delegators[i] = g.newPrefundAccount()
+		if _, err := g.call(delegators[i], input, big.NewInt(1e18)); err != nil {
+			return addr, nil, err
+		}
+	}
+	return addr, delegators, nil
+}
+
+func (g *GovernanceContractTestSuite) TestUnstakePagination() {
+	n := unstakeDelegatorsPerCall + 5
+	addr, delegators, err := g.stakeWithDelegators(n)
+	g.Require().NoError(err)
+
+	// First page, the node itself is the first delegator and left.
+	input, err := abiObject.Pack("unstake")
+	g.Require().NoError(err)
+	_, err = g.call(addr, input, big.NewInt(0))
+	g.Require().NoError(err)
+	g.Require().True(g.s.Node(big.NewInt(0)).Unstaked)
+	g.Require().Equal(int64(unstakeDelegatorsPerCall), g.s.UnstakedDelegators(addr).Int64())
+	offset := g.s.DelegatorsOffset(addr, delegators[0])
+	g.Require().Equal(0, g.s.Delegator(addr, offset).UndelegatedAt.Sign())
+
+	// Can not delegate to unstaked node.
+	_, extra := g.newPrefundAccount()
+	delegateInput, err := abiObject.Pack("delegate", addr)
+	g.Require().NoError(err)
+	_, err = g.call(extra, delegateInput, big.NewInt(1e18))
+	g.Require().Error(err)
+
+	// Withdrawing a delegator undelegated by unstake.
+	time.Sleep(time.Second * 2)
+	withdrawInput, err := abiObject.Pack("withdraw", addr)
+	g.Require().NoError(err)
+	_, err = g.call(delegators[n-1], withdrawInput, big.NewInt(0))
+	g.Require().NoError(err)
+
+	// Second page undelegates the rest.
+	_, err = g.call(addr, input, big.NewInt(0))
+	g.Require().NoError(err)
+	length := g.s.LenDelegators(addr)
+	g.Require().Equal(int64(n), length.Int64())
+	g.Require().Equal(length, g.s.UnstakedDelegators(addr))
+	for i := int64(0); i < length.Int64(); i++ {
+		g.Require().NotEqual(0, g.s.Delegator(addr, big.NewInt(i)).UndelegatedAt.Sign())
+	}
+	g.Require().Equal(0, g.s.Node(big.NewInt(0)).Staked.Sign())
+}
+
+func (g *GovernanceContractTestSuite) TestUnstakeGas() {
+	input, err := abiObject.Pack("unstake")
+	g.Require().NoError(err)
+
+	unstakeGas := func(n int) uint64 {
+		addr, _, err := g.stakeWithDelegators(n)
+		g.Require().NoError(err)
+		_, gas, err := g.callGas(addr, input, big.NewInt(0))
+		g.Require().NoError(err)
+		return gas
+	}
+	// Every extra delegator costs at least the storage of its undelegation.
+	few, many := unstakeGas(1), unstakeGas(20)
+	g.Require().True(many-few >= 19*params.SstoreSetGas,
+		"unstake gas %d of 20 delegators not proportional to %d of 1", many, few)
+}
+
+func (g *GovernanceContractTestSuite) TestTraceGovernance() {
+	logger := NewStructLogger(nil)
+	g.tracer = logger
+	defer func() { g.tracer = nil }()
+
+	privKey, addr := g.newPrefundAccount()
+	pk := crypto.FromECDSAPub(&privKey.PublicKey)
+	amount := new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e5))
+	input, err := abiObject.Pack("stake", pk, "Test1", "test1@dexon.org", "Taipei, Taiwan", "https://dexon.org")
+	g.Require().NoError(err)
+	_, err = g.call(addr, input, amount)
+	g.Require().NoError(err)
+
+	calls := logger.GovernanceCalls()
+	g.Require().Len(calls, 1)
+	call := calls[0]
+	g.Require().Equal("stake", call.Method)
+	g.Require().Equal(addr, call.From)
+	g.Require().Equal(1, call.Depth)
+	g.Require().Equal(amount, call.Value.ToInt())
+	g.Require().Equal("Test1", call.Args["Name"])
+	g.Require().Equal(hexutil.Bytes(pk), call.Args["PublicKey"])
+	g.Require().NotEmpty(call.Storage)
+	g.Require().True(call.GasUsed > 0 && call.GasUsed <= call.Gas)
+	g.Require().Empty(call.Error)
+	g.Require().Len(call.Events, 2)
+	g.Require().Equal("Delegated", call.Events[0].Name)
+	g.Require().Equal(addr, call.Events[0].Args["DelegatorAddress"])
+	g.Require().Equal("Staked", call.Events[1].Name)
+	g.Require().Equal(addr, call.Events[1].Args["NodeAddress"])
+
+	// The last writes of the slots end up in the state.
+	written := make(map[common.Hash]common.Hash)
+	for _, write := range call.Storage {
+		written[write.Slot] = write.Value
+	}
+	for slot, value := range written {
+		g.Require().Equal(value, g.stateDB.GetState(GovernanceContractAddress, slot))
+	}
+
+	// Failed calls are traced with their error.
+	_, err = g.call(addr, input, amount)
+	g.Require().Error(err)
+	calls = logger.GovernanceCalls()
+	g.Require().Len(calls, 2)
+	g.Require().Equal(err.Error(), calls[1].Error)
+	g.Require().Empty(calls[1].Events)
+}
+
+// requireRevert calls the governance contract and requires the call to revert
+// with the given reason.
+func (g *GovernanceContractTestSuite) requireRevert(reason error, caller common.Address, value *big.Int, method string, args ...interface{}) {
+	input, err := abiObject.Pack(method, args...)
+	g.Require().NoError(err)
+	ret, err := g.call(caller, input, value)
+	g.Require().Equal(errExecutionReverted, err, method)
+	have, ok := UnpackRevertReason(ret)
+	g.Require().True(ok, "%s: no revert reason in %x", method, ret)
+	g.Require().Equal(reason.Error(), have, method)
+}
+
+func (g *GovernanceContractTestSuite) TestRevertReasons() {
+	privKey, addr := g.newPrefundAccount()
+	pk := crypto.FromECDSAPub(&privKey.PublicKey)
+	_, other := g.newPrefundAccount()
+	amount := new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e5))
+	zero := big.NewInt(0)
+
+	// Malformed calls.
+	ret, err := g.call(addr, []byte{1, 2, 3, 4}, zero)
+	g.Require().Equal(errExecutionReverted, err)
+	reason, _ := UnpackRevertReason(ret)
+	g.Require().Equal(errUnknownMethod.Error(), reason)
+	input, err := abiObject.Pack("delegate", addr)
+	g.Require().NoError(err)
+	ret, err = g.call(addr, input[:8], zero)
+	g.Require().Equal(errExecutionReverted, err)
+	reason, _ = UnpackRevertReason(ret)
+	g.Require().Equal(errInvalidArguments.Error(), reason)
+	g.requireRevert(errIndexOutOfRange, addr, zero, "dkgComplaints", zero, zero)
+	g.requireRevert(errIndexOutOfRange, addr, zero, "dkgMasterPublicKeys", zero, zero)
+
+	// Owner only methods.
+	g.requireRevert(errNotOwner, addr, zero, "transferOwnership", addr)
+	g.requireRevert(errNotOwner, addr, zero, "updateConfiguration",
+		new(big.Int).Mul(big.NewInt(1e18), big.NewInt(1e5)), big.NewInt(1000),
+		big.NewInt(1e18), big.NewInt(8000000), big.NewInt(6), big.NewInt(250), big.NewInt(2500),
+		big.NewInt(0), big.NewInt(667000), big.NewInt(4), big.NewInt(4), big.NewInt(600000), big.NewInt(900),
+		[]*big.Int{big.NewInt(1), big.NewInt(1), big.NewInt(1)})
+
+	// Not staked node.
+	g.requireRevert(errNotStaked, other, amount, "delegate", addr)
+	g.requireRevert(errNotStaked, other, zero, "undelegate", addr)
+	g.requireRevert(errNotStaked, other, zero, "withdraw", addr)
+	g.requireRevert(errNotStaked, addr, zero, "unstake")
+	g.requireRevert(errNotStaked, other, zero, "payFine", addr)
+	g.requireRevert(errNotStaked, addr, zero, "addDKGMasterPublicKey", zero, []byte{})
+
+	// Stake.
+	longName := string(make([]byte, 32))
+	g.requireRevert(errInvalidNodeInfo, addr, amount, "stake", pk, longName, "", "", "")
+	g.requireRevert(errInvalidPublicKey, addr, amount, "stake", []byte{1, 2, 3}, "Test1", "", "", "")
+	input, err = abiObject.Pack("stake", pk, "Test1", "test1@dexon.org", "Taipei, Taiwan", "https://dexon.org")
+	g.Require().NoError(err)
+	_, err = g.call(addr, input, amount)
+	g.Require().NoError(err)
+	g.requireRevert(errAlreadyStaked, addr, amount, "stake", pk, "Test1", "", "", "")
+
+	// Delegate.
+	g.requireRevert(errNoFund, other, zero, "delegate", addr)
+	g.requireRevert(errAlreadyDelegated, addr, amount, "delegate", addr)
+
+	// Undelegated delegators.
+	g.requireRevert(errNotDelegated, other, zero, "undelegate", addr)
+	g.requireRevert(errNotDelegated, other, zero, "withdraw", addr)
+	g.requireRevert(errNotDelegated, other, amount, "payFine", addr)
+	g.requireRevert(errNotUndelegated, addr, zero, "withdraw", addr)
+
+	// Fines.
+	g.requireRevert(errNotFined, addr, amount, "payFine", addr)
+	offset := g.s.NodesOffsetByAddress(addr)
+	node := g.s.Node(offset)
+	node.Fined = big.NewInt(1)
+	g.s.UpdateNode(offset, node)
+	g.requireRevert(errFineExceeded, addr, amount, "payFine", addr)
+	g.requireRevert(errNodeFined, addr, zero, "undelegate", addr)
+	g.requireRevert(errNodeFined, addr, zero, "unstake")
+	node.Fined = big.NewInt(0)
+	g.s.UpdateNode(offset, node)
+
+	// Lockup.
+	input, err = abiObject.Pack("unstake")
+	g.Require().NoError(err)
+	_, err = g.call(addr, input, zero)
+	g.Require().NoError(err)
+	g.requireRevert(errLockupNotPassed, addr, zero, "withdraw", addr)
+	g.requireRevert(errNodeUnstaked, other, amount, "delegate", addr)
+
+	// Rounds.
+	future := big.NewInt(5)
+	g.requireRevert(errInvalidRound, addr, zero, "proposeCRS", zero, []byte{})
+	g.requireRevert(errInvalidRound, addr, zero, "addDKGMasterPublicKey", future, []byte{})
+	g.requireRevert(errInvalidRound, addr, zero, "addDKGComplaint", future, []byte{})
+	g.requireRevert(errInvalidRound, addr, zero, "addDKGMPKReady", future, []byte{})
+	g.requireRevert(errInvalidRound, addr, zero, "addDKGFinalize", future, []byte{})
+	g.requireRevert(errUnknownRoundHeight, addr, zero, "snapshotRound", future, zero)
+	g.requireRevert(errRoundHeightMismatch, addr, zero, "snapshotRound", big.NewInt(1), zero)
+	g.requireRevert(errRoundSnapshotted, addr, zero, "snapshotRound", zero, zero)
+
+	// Malformed DKG messages.
+	g.requireRevert(errInvalidMPK, addr, zero, "addDKGMasterPublicKey", zero, []byte{1})
+	g.requireRevert(errInvalidComplaint, addr, zero, "addDKGComplaint", zero, []byte{1})
+	g.requireRevert(errInvalidMPKReady, addr, zero, "addDKGMPKReady", zero, []byte{1})
+	g.requireRevert(errInvalidFinalize, addr, zero, "addDKGFinalize", zero, []byte{1})
+
+	// DKG phases passed.
+	for i := uint64(0); i <= g.s.DKGSetSize().Uint64(); i++ {
+		g.s.IncDKGMPKReadysCount(zero)
+		g.s.IncDKGFinalizedsCount(zero)
+	}
+	g.requireRevert(errDKGSetMPKReady, addr, zero, "addDKGMasterPublicKey", zero, []byte{1})
+	g.requireRevert(errDKGSetFinalized, addr, zero, "addDKGComplaint", zero, []byte{1})
+	g.s.PutDKGMPKReady(zero, addr, true)
+	g.requireRevert(errDKGMPKReady, addr, zero, "addDKGMasterPublicKey", zero, []byte{1})
+	g.s.PutDKGFinalized(zero, addr, true)
+	g.requireRevert(errDKGFinalized, addr, zero, "addDKGComplaint", zero, []byte{1})
+
+	// Reports.
+	g.requireRevert(errInvalidReportType, addr, zero, "report", big.NewInt(ReportTypeInvalidDKG), []byte{}, []byte{})
+	g.requireRevert(errInvalidReport, addr, zero, "report", big.NewInt(ReportTypeForkVote), []byte{1}, []byte{})
+	g.requireRevert(errInvalidReport, addr, zero, "report", big.NewInt(ReportTypeForkBlock), []byte{1}, []byte{})
+}
+
+func TestRevertReason(t *testing.T) {
+	// Solidity revert("Not enough Ether provided.")
+	want := common.FromHex("0x08c379a0" +
+		"0000000000000000000000000000000000000000000000000000000000000020" +
+		"000000000000000000000000000000000000000000000000000000000000001a" +
+		"4e6f7420656e6f7567682045746865722070726f76696465642e000000000000")
+	if have := PackRevertReason("Not enough Ether provided."); !bytes.Equal(have, want) {
+		t.Errorf("revert data mismatch: have %x, want %x", have, want)
+	}
+	if reason, ok := UnpackRevertReason(want); !ok || reason != "Not enough Ether provided." {
+		t.Errorf("revert reason mismatch: have %q, %v", reason, ok)
+	}
+	if _, ok := UnpackRevertReason(want[4:]); ok {
+		t.Error("unpacked revert reason without selector")
+	}
+}
+
+func TestUnpackGovernanceEvent(t *testing.T) {
+	round, crs := big.NewInt(3), common.HexToHash("0x1234")
+	delegator := common.HexToAddress("0x5678")
+	amount := big.NewInt(1e18)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	s := &GovernanceStateHelper{statedb}
+	s.emitCRSProposed(round, crs)
+	s.emitDelegated(GovernanceContractAddress, delegator, amount)
+
+	logs := statedb.Logs()
+	if len(logs) != 2 {
+		t.Fatalf("log count mismatch: have %d, want 2", len(logs))
+	}
+	event, err := UnpackGovernanceEvent(logs[0])
+	if err != nil {
+		t.Fatalf("failed to unpack CRSProposed: %v", err)
+	}
+	if event.Name != "CRSProposed" {
+		t.Errorf("event name mismatch: have %s, want CRSProposed", event.Name)
+	}
+	if have := event.Args["Round"].(*hexutil.Big).ToInt(); have.Cmp(round) != 0 {
+		t.Errorf("round mismatch: have %v, want %v", have, round)
+	}
+	if have := event.Args["CRS"]; have != crs {
+		t.Errorf("crs mismatch: have %v, want %x", have, crs)
+	}
+	event, err = UnpackGovernanceEvent(logs[1])
+	if err != nil {
+		t.Fatalf("failed to unpack Delegated: %v", err)
+	}
+	if have := event.Args["DelegatorAddress"]; have != delegator {
+		t.Errorf("delegator mismatch: have %v, want %x", have, delegator)
+	}
+	if have := event.Args["Amount"].(*hexutil.Big).ToInt(); have.Cmp(amount) != 0 {
+		t.Errorf("amount mismatch: have %v, want %v", have, amount)
+	}
+	if _, err := UnpackGovernanceEvent(&types.Log{Topics: []common.Hash{{1}}}); err == nil {
+		t.Error("expected error for unknown event")
+	}
+	if id, err := GovernanceEventID("Delegated"); err != nil || id != logs[1].Topics[0] {
+		t.Errorf("Delegated topic mismatch: have %x, %v", id, err)
+	}
+	if _, err := GovernanceEventID("Unknown"); err == nil {
+		t.Error("expected error for unknown event name")
+	}
+}
+
 func TestGovernanceContract(t *testing.T) {
 	suite.Run(t, new(GovernanceContractTestSuite))
 }
+func (g *GovernanceContractTestSuite) TestBeforeMeteringFork() {
+	config := *params.TestChainConfig
+	config.GovernanceMeteringBlock = nil
+	g.chainConfig = &config
+
+	n := 20
+	addr, delegators, err := g.stakeWithDelegators(n)
+	g.Require().NoError(err)
+
+	// Delegating is charged the fixed gas.
+	_, extra := g.newPrefundAccount()
+	delegateInput, err := abiObject.Pack("delegate", addr)
+	g.Require().NoError(err)
+	_, gas, err := g.callGas(extra, delegateInput, big.NewInt(1e18))
+	g.Require().NoError(err)
+	g.Require().Equal(legacyDelegateGas, gas)
+
+	// Unstake undelegates all delegators in a single call. The node keeps
+	// its staked value, since the node read before undelegating is written
+	// back.
+	staked := g.s.Node(big.NewInt(0)).Staked
+	input, err := abiObject.Pack("unstake")
+	g.Require().NoError(err)
+	_, err = g.call(addr, input, big.NewInt(0))
+	g.Require().NoError(err)
+	g.Require().True(g.s.Node(big.NewInt(0)).Unstaked)
+	g.Require().Equal(0, g.s.UnstakedDelegators(addr).Sign())
+	for _, delegator := range append(delegators, addr, extra) {
+		offset := g.s.DelegatorsOffset(addr, delegator)
+		g.Require().NotEqual(0, g.s.Delegator(addr, offset).UndelegatedAt.Sign())
+	}
+	g.Require().Equal(staked, g.s.Node(big.NewInt(0)).Staked)
+
+	// Delegating to an unstaked node is still allowed.
+	_, late := g.newPrefundAccount()
+	_, err = g.call(late, delegateInput, big.NewInt(1e18))
+	g.Require().NoError(err)
+}
+
+func (g *GovernanceContractTestSuite) TestBeforeRevertReasonFork() {
+	config := *params.TestChainConfig
+	config.GovernanceRevertReasonBlock = nil
+	g.chainConfig = &config
+
+	_, addr := g.newPrefundAccount()
+	_, other := g.newPrefundAccount()
+	zero := big.NewInt(0)
+
+	// Failed calls revert without data.
+	ret, err := g.call(addr, []byte{1, 2, 3, 4}, zero)
+	g.Require().Equal(errExecutionReverted, err)
+	g.Require().Nil(ret)
+	input, err := abiObject.Pack("delegate", addr)
+	g.Require().NoError(err)
+	ret, err = g.call(other, input, big.NewInt(1e18))
+	g.Require().Equal(errExecutionReverted, err)
+	g.Require().Nil(ret)
+	input, err = abiObject.Pack("transferOwnership", addr)
+	g.Require().NoError(err)
+	ret, err = g.call(addr, input, zero)
+	g.Require().Equal(errExecutionReverted, err)
+	g.Require().Nil(ret)
+}
+
+func newGovernanceBenchmark() *GovernanceContractTestSuite {
+	g := new(GovernanceContractTestSuite)
+	g.SetupTest()
+	return g
+}
+
+func BenchmarkGovernanceStake(b *testing.B) {
+	g := newGovernanceBenchmark()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := g.stakeWithDelegators(0); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGovernanceDelegate(b *testing.B) {
+	g := newGovernanceBenchmark()
+	addr, _, err := g.stakeWithDelegators(0)
+	if err != nil {
+		b.Fatal(err)
+	}
+	input, err := abiObject.Pack("delegate", addr)
+	if err != nil {
+		b.Fatal(err)
+	}
+	delegators := make([]common.Address, b.N)
+	for i := range delegators {
+		_, delegators[i] = g.newPrefundAccount()
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := g.call(delegators[i], input, big.NewInt(1e18)); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkGovernanceUnstake(b *testing.B) {
+	for _, n := range []int{1, 10, unstakeDelegatorsPerCall} {
+		b.Run(fmt.Sprintf("delegators=%d", n), func(b *testing.B) {
+			g := newGovernanceBenchmark()
+			input, err := abiObject.Pack("unstake")
+			if err != nil {
+				b.Fatal(err)
+			}
+			for i := 0; i < b.N; i++ {
+				b.StopTimer()
+				addr, _, err := g.stakeWithDelegators(n)
+				if err != nil {
+					b.Fatal(err)
+				}
+				b.StartTimer()
+				if _, err := g.call(addr, input, big.NewInt(0)); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
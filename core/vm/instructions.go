@@ -414,20 +414,26 @@ func opRand(pc *uint64, interpreter *EVMInterpreter, contract *Contract, memory
 	evm := interpreter.evm
 
 	nonce := evm.StateDB.GetNonce(contract.Caller())
+	hash := RandOutput(evm.Randomness, contract.Caller(), nonce, contract.Gas)
+
+	stack.push(interpreter.intPool.get().SetBytes(hash))
+	return nil, nil
+}
+
+// RandOutput computes the value pushed by RAND from the block randomness, and
+// the caller, its nonce and the remaining gas at the time RAND is executed.
+func RandOutput(randomness []byte, caller common.Address, nonce, gas uint64) []byte {
 	binaryNonce := make([]byte, binary.MaxVarintLen64)
 	binary.PutUvarint(binaryNonce, nonce)
 
 	binaryGas := make([]byte, binary.MaxVarintLen64)
-	binary.PutUvarint(binaryGas, contract.Gas)
+	binary.PutUvarint(binaryGas, gas)
 
-	hash := crypto.Keccak256(
-		evm.Randomness,
-		contract.Caller().Bytes(),
+	return crypto.Keccak256(
+		randomness,
+		caller.Bytes(),
 		binaryNonce,
 		binaryGas)
-
-	stack.push(interpreter.intPool.get().SetBytes(hash))
-	return nil, nil
 }
 
 func opAddress(pc *uint64, interpreter *EVMInterpreter, contract *Contract, memory *Memory, stack *Stack) ([]byte, error) {
@@ -22,7 +22,9 @@ import (
 	"testing"
 
 	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/params"
 )
 
@@ -443,6 +445,36 @@ func BenchmarkOpIsZero(b *testing.B) {
 	opBenchmark(b, opIszero, x)
 }
 
+func TestOpRand(t *testing.T) {
+	stateDB, err := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	if err != nil {
+		t.Fatalf("failed to create state: %v", err)
+	}
+	caller := common.HexToAddress("0x1234")
+	stateDB.SetNonce(caller, 7)
+
+	var (
+		randomness     = []byte{1, 2, 3, 4}
+		env            = NewEVM(Context{Randomness: randomness}, stateDB, params.TestChainConfig, Config{})
+		stack          = newstack()
+		evmInterpreter = NewEVMInterpreter(env, env.vmConfig)
+		contract       = NewContract(AccountRef(caller), AccountRef(common.HexToAddress("0x5678")), new(big.Int), 1000)
+	)
+	env.interpreter = evmInterpreter
+	evmInterpreter.intPool = poolOfIntPools.get()
+	pc := uint64(0)
+	opRand(&pc, evmInterpreter, contract, nil, stack)
+
+	want := RandOutput(randomness, caller, 7, 1000)
+	if got := stack.pop().Bytes(); !bytes.Equal(common.LeftPadBytes(got, 32), want) {
+		t.Errorf("RAND mismatch: got %x, want %x", got, want)
+	}
+	if bytes.Equal(want, RandOutput(randomness, caller, 7, 999)) {
+		t.Errorf("RAND does not depend on the remaining gas")
+	}
+	poolOfIntPools.put(evmInterpreter.intPool)
+}
+
 func TestOpMstore(t *testing.T) {
 	var (
 		env            = NewEVM(Context{}, nil, params.TestChainConfig, Config{})
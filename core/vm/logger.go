@@ -104,6 +104,36 @@ type Tracer interface {
 	CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error
 }
 
+// GovernanceTracer is implemented by tracers that want to see inside the
+// governance contract. As the governance contract is a precompile, it shows
+// up as an opaque call to the opcode based Tracer methods.
+type GovernanceTracer interface {
+	CaptureGovernance(env *EVM, call *GovernanceCall) error
+}
+
+// GovernanceStorage is a storage slot written by the governance contract.
+type GovernanceStorage struct {
+	Slot  common.Hash `json:"slot"`
+	Value common.Hash `json:"value"`
+}
+
+// GovernanceCall is a decoded call into the governance contract, captured by
+// GovernanceTracer.
+type GovernanceCall struct {
+	From    common.Address         `json:"from"`
+	Value   *hexutil.Big           `json:"value"`
+	Depth   int                    `json:"depth"`
+	Method  string                 `json:"method"`
+	Args    map[string]interface{} `json:"args"`
+	Gas     uint64                 `json:"gas"`
+	GasUsed uint64                 `json:"gasUsed"`
+	Storage []GovernanceStorage    `json:"storage"`
+	Events  []*GovernanceEvent     `json:"events"`
+	Output  hexutil.Bytes          `json:"output"`
+	Error   string                 `json:"error,omitempty"`
+	Reason  string                 `json:"reason,omitempty"`
+}
+
 // StructLogger is an EVM state logger and implements Tracer.
 //
 // StructLogger can capture state based on the given Log configuration and also keeps
@@ -113,6 +143,7 @@ type StructLogger struct {
 	cfg LogConfig
 
 	logs          []StructLog
+	govCalls      []GovernanceCall
 	changedValues map[common.Address]Storage
 	output        []byte
 	err           error
@@ -203,9 +234,19 @@ func (l *StructLogger) CaptureEnd(output []byte, gasUsed uint64, t time.Duration
 	return nil
 }
 
+// CaptureGovernance implements the GovernanceTracer interface to record the
+// calls into the governance contract.
+func (l *StructLogger) CaptureGovernance(env *EVM, call *GovernanceCall) error {
+	l.govCalls = append(l.govCalls, *call)
+	return nil
+}
+
 // StructLogs returns the captured log entries.
 func (l *StructLogger) StructLogs() []StructLog { return l.logs }
 
+// GovernanceCalls returns the captured governance contract calls.
+func (l *StructLogger) GovernanceCalls() []GovernanceCall { return l.govCalls }
+
 // Error returns the VM error captured by the trace.
 func (l *StructLogger) Error() error { return l.err }
 
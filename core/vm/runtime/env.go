@@ -19,6 +19,7 @@ package runtime
 import (
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/vm"
 )
 
@@ -27,15 +28,22 @@ func NewEnv(cfg *Config) *vm.EVM {
 		CanTransfer: core.CanTransfer,
 		Transfer:    core.Transfer,
 		GetHash:     func(uint64) common.Hash { return common.Hash{} },
+		// There is no chain, every height shares the state of the config.
+		StateAtNumber:  func(uint64) (*state.StateDB, error) { return cfg.State, nil },
+		GetRoundHeight: cfg.GetRoundHeightFn,
 
 		Origin:      cfg.Origin,
 		Coinbase:    cfg.Coinbase,
 		BlockNumber: cfg.BlockNumber,
 		Time:        cfg.Time,
+		Randomness:  cfg.Randomness,
 		Difficulty:  cfg.Difficulty,
 		GasLimit:    cfg.GasLimit,
 		GasPrice:    cfg.GasPrice,
 	}
 
-	return vm.NewEVM(context, cfg.State, cfg.ChainConfig, cfg.EVMConfig)
+	vmConfig := cfg.EVMConfig
+	vmConfig.IsBlockProposer = vmConfig.IsBlockProposer || cfg.BlockProposer
+
+	return vm.NewEVM(context, cfg.State, cfg.ChainConfig, vmConfig)
 }
@@ -0,0 +1,138 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package runtime
+
+import (
+	"fmt"
+	"math/big"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/common/math"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/params"
+)
+
+// GovernanceNode is a node staked in the governance state.
+type GovernanceNode struct {
+	Owner     common.Address        `json:"owner"`
+	PublicKey hexutil.Bytes         `json:"publicKey"`
+	Staked    *math.HexOrDecimal256 `json:"staked"`
+	Info      core.NodeInfo         `json:"info"`
+}
+
+// GovernanceState is the governance contract state to seed before execution,
+// letting code calling the governance contract run without a chain.
+type GovernanceState struct {
+	Owner  common.Address       `json:"owner"`
+	Config *params.DexconConfig `json:"config"`
+	Nodes  []GovernanceNode     `json:"nodes"`
+
+	// CRS and RoundHeights are indexed by round, missing round heights
+	// are zero.
+	CRS          []common.Hash `json:"crs"`
+	RoundHeights []uint64      `json:"roundHeights"`
+}
+
+// Apply writes the governance state into the state database the same way
+// the genesis does, staked values are moved to the governance contract.
+func (g *GovernanceState) Apply(statedb *state.StateDB) error {
+	helper := &vm.GovernanceStateHelper{StateDB: statedb}
+	for i, node := range g.Nodes {
+		if _, err := crypto.UnmarshalPubkey(node.PublicKey); err != nil {
+			return fmt.Errorf("node %d: invalid public key: %v", i, err)
+		}
+		staked := new(big.Int)
+		if node.Staked != nil {
+			staked.Set((*big.Int)(node.Staked))
+		}
+		statedb.AddBalance(vm.GovernanceContractAddress, staked)
+		helper.Stake(node.Owner, node.PublicKey, staked,
+			node.Info.Name, node.Info.Email, node.Info.Location, node.Info.Url)
+	}
+	for i, crs := range g.CRS {
+		height := new(big.Int)
+		if i < len(g.RoundHeights) {
+			height.SetUint64(g.RoundHeights[i])
+		}
+		helper.PushCRS(crs)
+		helper.PushRoundHeight(height)
+	}
+	if g.Owner != (common.Address{}) {
+		helper.SetOwner(g.Owner)
+	}
+	if g.Config != nil {
+		helper.UpdateConfiguration(g.Config)
+	}
+	return nil
+}
+
+// prepareState seeds the governance state of the config and advances it to
+// the round of the config. The governance state is cleared once applied, so
+// a config reused across executions seeds its state only once.
+func prepareState(cfg *Config) error {
+	if cfg.GovernanceState != nil {
+		if err := cfg.GovernanceState.Apply(cfg.State); err != nil {
+			return err
+		}
+		cfg.GovernanceState = nil
+	}
+	if cfg.Round != nil {
+		AdvanceRound(cfg.State, cfg.ChainConfig, cfg.Round, cfg.BlockNumber)
+	}
+	return nil
+}
+
+// AdvanceRound pushes CRSs to the governance state until it reaches the
+// round, the new rounds start at the given height. The first CRS is derived
+// from the Dexcon config if the state has none.
+func AdvanceRound(statedb *state.StateDB, config *params.ChainConfig, round, height *big.Int) {
+	helper := &vm.GovernanceStateHelper{StateDB: statedb}
+	for helper.Round().Cmp(round) < 0 {
+		var crs common.Hash
+		if helper.LenCRS().Sign() > 0 {
+			crs = crypto.Keccak256Hash(helper.CurrentCRS().Bytes())
+		} else if config != nil && config.Dexcon != nil {
+			crs = crypto.Keccak256Hash([]byte(config.Dexcon.GenesisCRSText))
+		}
+		helper.PushCRS(crs)
+		helper.PushRoundHeight(new(big.Int).Set(height))
+	}
+}
+
+// roundHeightFn returns the round heights recorded in the governance state of
+// the config, standing in for the chain.
+func roundHeightFn(cfg *Config) func(uint64) (uint64, bool) {
+	return func(round uint64) (uint64, bool) {
+		return RoundHeight(cfg.State, round)
+	}
+}
+
+// RoundHeight returns the height of the round recorded in the governance
+// state, if the state reached the round.
+func RoundHeight(statedb *state.StateDB, round uint64) (uint64, bool) {
+	helper := &vm.GovernanceStateHelper{StateDB: statedb}
+	r := new(big.Int).SetUint64(round)
+	if r.Cmp(helper.Round()) > 0 {
+		return 0, false
+	}
+	return helper.RoundHeight(r).Uint64(), true
+}
@@ -44,8 +44,18 @@ type Config struct {
 	Debug       bool
 	EVMConfig   vm.Config
 
-	State     *state.StateDB
-	GetHashFn func(n uint64) common.Hash
+	// DEXON specific execution context. GovernanceState is applied to the
+	// state before the first execution, Round then advances the governance state to
+	// the round if it's behind and BlockProposer runs the code as a block
+	// proposer would.
+	Randomness      []byte
+	Round           *big.Int
+	BlockProposer   bool
+	GovernanceState *GovernanceState
+
+	State            *state.StateDB
+	GetHashFn        func(n uint64) common.Hash
+	GetRoundHeightFn func(round uint64) (uint64, bool)
 }
 
 // sets defaults on the config
@@ -85,6 +95,9 @@ func setDefaults(cfg *Config) {
 			return common.BytesToHash(crypto.Keccak256([]byte(new(big.Int).SetUint64(n).String())))
 		}
 	}
+	if cfg.GetRoundHeightFn == nil {
+		cfg.GetRoundHeightFn = roundHeightFn(cfg)
+	}
 }
 
 // Execute executes the code using the input as call data during the execution.
@@ -101,6 +114,9 @@ func Execute(code, input []byte, cfg *Config) ([]byte, *state.StateDB, error) {
 	if cfg.State == nil {
 		cfg.State, _ = state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
 	}
+	if err := prepareState(cfg); err != nil {
+		return nil, cfg.State, err
+	}
 	var (
 		address = common.BytesToAddress([]byte("contract"))
 		vmenv   = NewEnv(cfg)
@@ -131,6 +147,9 @@ func Create(input []byte, cfg *Config) ([]byte, common.Address, uint64, error) {
 	if cfg.State == nil {
 		cfg.State, _ = state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
 	}
+	if err := prepareState(cfg); err != nil {
+		return nil, common.Address{}, 0, err
+	}
 	var (
 		vmenv  = NewEnv(cfg)
 		sender = vm.AccountRef(cfg.Origin)
@@ -153,6 +172,9 @@ func Create(input []byte, cfg *Config) ([]byte, common.Address, uint64, error) {
 // be set.
 func Call(address common.Address, input []byte, cfg *Config) ([]byte, uint64, error) {
 	setDefaults(cfg)
+	if err := prepareState(cfg); err != nil {
+		return nil, 0, err
+	}
 
 	vmenv := NewEnv(cfg)
 
@@ -23,8 +23,10 @@ import (
 
 	"github.com/dexon-foundation/dexon/accounts/abi"
 	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/math"
 	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/params"
 )
@@ -203,3 +205,99 @@ func BenchmarkEVM_CREATE2_1200(bench *testing.B) {
 	// initcode size 1200K, repeatedly calls CREATE2 and then modifies the mem contents
 	benchmarkEVM_Create(bench, "5b5862124f80600080f5600152600056")
 }
+
+func TestDexconContext(t *testing.T) {
+	code := []byte{
+		byte(vm.RAND),
+		byte(vm.PUSH1), 0,
+		byte(vm.MSTORE),
+		byte(vm.PUSH1), 32,
+		byte(vm.PUSH1), 0,
+		byte(vm.RETURN),
+	}
+	rand := func(randomness []byte) []byte {
+		ret, _, err := Execute(code, nil, &Config{Randomness: randomness})
+		if err != nil {
+			t.Fatal("didn't expect error", err)
+		}
+		return ret
+	}
+	if common.Bytes2Hex(rand([]byte{1})) == common.Bytes2Hex(rand([]byte{2})) {
+		t.Error("expected RAND to depend on randomness")
+	}
+
+	key, _ := crypto.GenerateKey()
+	cfg := &Config{
+		ChainConfig: params.TestnetChainConfig,
+		BlockNumber: big.NewInt(100),
+		Round:       big.NewInt(2),
+		GovernanceState: &GovernanceState{
+			Config: params.TestnetChainConfig.Dexcon,
+			CRS:    []common.Hash{{1}},
+			Nodes: []GovernanceNode{{
+				Owner:     crypto.PubkeyToAddress(key.PublicKey),
+				PublicKey: crypto.FromECDSAPub(&key.PublicKey),
+				Staked:    (*math.HexOrDecimal256)(params.TestnetChainConfig.Dexcon.MinStake),
+			}},
+		},
+	}
+	cfg.State, _ = state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+
+	gov, err := abi.JSON(strings.NewReader(vm.GovernanceABIJSON))
+	if err != nil {
+		t.Fatal(err)
+	}
+	call := func(method string, args ...interface{}) *big.Int {
+		input, err := gov.Pack(method, args...)
+		if err != nil {
+			t.Fatal(err)
+		}
+		ret, _, err := Call(vm.GovernanceContractAddress, input, cfg)
+		if err != nil {
+			t.Fatalf("%s: didn't expect error %v", method, err)
+		}
+		return new(big.Int).SetBytes(ret)
+	}
+	if n := call("nodesLength"); n.Cmp(big.NewInt(1)) != 0 {
+		t.Error("expected 1 node, got", n)
+	}
+	if h := call("roundHeight", big.NewInt(2)); h.Cmp(cfg.BlockNumber) != 0 {
+		t.Errorf("expected round 2 at height %v, got %v", cfg.BlockNumber, h)
+	}
+	if height, ok := cfg.GetRoundHeightFn(3); ok {
+		t.Error("expected no height of round 3, got", height)
+	}
+}
+
+func TestGovernanceStateAppliedOnce(t *testing.T) {
+	key, _ := crypto.GenerateKey()
+	staked := params.TestnetChainConfig.Dexcon.MinStake
+	cfg := &Config{
+		ChainConfig: params.TestnetChainConfig,
+		GovernanceState: &GovernanceState{
+			CRS: []common.Hash{{1}},
+			Nodes: []GovernanceNode{{
+				Owner:     crypto.PubkeyToAddress(key.PublicKey),
+				PublicKey: crypto.FromECDSAPub(&key.PublicKey),
+				Staked:    (*math.HexOrDecimal256)(staked),
+			}},
+		},
+	}
+	code := []byte{byte(vm.PUSH1), 0, byte(vm.PUSH1), 0, byte(vm.RETURN)}
+	for i := 0; i < 2; i++ {
+		if _, _, err := Execute(code, nil, cfg); err != nil {
+			t.Fatal("didn't expect error", err)
+		}
+	}
+
+	helper := &vm.GovernanceStateHelper{StateDB: cfg.State}
+	if n := helper.LenNodes(); n.Cmp(big.NewInt(1)) != 0 {
+		t.Error("expected 1 node, got", n)
+	}
+	if n := helper.LenCRS(); n.Cmp(big.NewInt(1)) != 0 {
+		t.Error("expected 1 CRS, got", n)
+	}
+	if balance := cfg.State.GetBalance(vm.GovernanceContractAddress); balance.Cmp(staked) != 0 {
+		t.Errorf("expected governance balance %v, got %v", staked, balance)
+	}
+}
@@ -31,6 +31,7 @@ import (
 	"github.com/dexon-foundation/dexon/core/rawdb"
 	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/indexer"
 	"github.com/dexon-foundation/dexon/internal/ethapi"
 	"github.com/dexon-foundation/dexon/params"
 	"github.com/dexon-foundation/dexon/rlp"
@@ -147,6 +148,21 @@ func (api *PrivateAdminAPI) IsProposing() bool {
 	return api.dex.IsProposing()
 }
 
+// PeerSets returns the notary and DKG groups the node maintains connections
+// to, with the connection state of each member.
+func (api *PrivateAdminAPI) PeerSets() []PeerGroupInfo {
+	return api.dex.protocolManager.peers.Groups()
+}
+
+// Indexers returns the status of the indexers, empty if indexing is
+// disabled.
+func (api *PrivateAdminAPI) Indexers() []indexer.Status {
+	if api.dex.indexers == nil {
+		return []indexer.Status{}
+	}
+	return api.dex.indexers.Status()
+}
+
 // PublicDebugAPI is the collection of Ethereum full node APIs exposed
 // over the public debugging endpoint.
 type PublicDebugAPI struct {
@@ -0,0 +1,287 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"context"
+	"fmt"
+
+	ethereum "github.com/dexon-foundation/dexon"
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/eth/filters"
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/rpc"
+)
+
+// PublicDexonAPI provides an API to access DEXON specific information of
+// the chain.
+type PublicDexonAPI struct {
+	dex    *Dexon
+	events *filters.EventSystem
+}
+
+// NewPublicDexonAPI creates a new DEXON specific API.
+func NewPublicDexonAPI(dex *Dexon) *PublicDexonAPI {
+	return &PublicDexonAPI{
+		dex:    dex,
+		events: filters.NewEventSystem(dex.EventMux(), dex.APIBackend, false),
+	}
+}
+
+// RandomnessResult is the randomness of a block, which RAND is derived from,
+// with the material to prove it is the threshold signature of the DKG set.
+type RandomnessResult struct {
+	BlockNumber    hexutil.Uint64 `json:"blockNumber"`
+	BlockHash      common.Hash    `json:"blockHash"`
+	Round          hexutil.Uint64 `json:"round"`
+	ConsensusHash  common.Hash    `json:"consensusHash"`
+	Randomness     hexutil.Bytes  `json:"randomness"`
+	GroupPublicKey hexutil.Bytes  `json:"groupPublicKey"`
+	Threshold      int            `json:"threshold"`
+	Verified       bool           `json:"verified"`
+
+	// Caller and CallerNonce are the inputs of RAND executed directly by
+	// the contract a transaction calls.
+	TxHash      *common.Hash    `json:"transactionHash,omitempty"`
+	Caller      *common.Address `json:"caller,omitempty"`
+	CallerNonce *hexutil.Uint64 `json:"callerNonce,omitempty"`
+}
+
+func (api *PublicDexonAPI) blockByNumber(blockNr rpc.BlockNumber) (*types.Block, error) {
+	var block *types.Block
+	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
+		block = api.dex.blockchain.CurrentBlock()
+	} else {
+		block = api.dex.blockchain.GetBlockByNumber(uint64(blockNr))
+	}
+	if block == nil {
+		return nil, fmt.Errorf("block #%d not found", blockNr)
+	}
+	return block, nil
+}
+
+func (api *PublicDexonAPI) randomness(header *types.Header) (*RandomnessResult, error) {
+	proof, err := api.dex.governance.RandomnessProof(header)
+	if err != nil {
+		return nil, err
+	}
+	return &RandomnessResult{
+		BlockNumber:    hexutil.Uint64(header.Number.Uint64()),
+		BlockHash:      header.Hash(),
+		Round:          hexutil.Uint64(proof.Round),
+		ConsensusHash:  common.Hash(proof.ConsensusHash),
+		Randomness:     proof.Randomness,
+		GroupPublicKey: proof.GroupPublicKey,
+		Threshold:      proof.Threshold,
+		Verified:       proof.Verified,
+	}, nil
+}
+
+// GetBlockRandomness returns the randomness of the block and verifies it
+// against the DKG group public key of its round.
+func (api *PublicDexonAPI) GetBlockRandomness(blockNr rpc.BlockNumber) (*RandomnessResult, error) {
+	block, err := api.blockByNumber(blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return api.randomness(block.Header())
+}
+
+// GetTransactionRandomness returns the randomness of the block including the
+// transaction, with the caller and nonce RAND sees in the called contract.
+func (api *PublicDexonAPI) GetTransactionRandomness(txHash common.Hash) (*RandomnessResult, error) {
+	tx, blockHash, blockNumber, _ := rawdb.ReadTransaction(api.dex.chainDb, txHash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %x not found", txHash)
+	}
+	header := api.dex.blockchain.GetHeader(blockHash, blockNumber)
+	if header == nil {
+		return nil, fmt.Errorf("block %x not found", blockHash)
+	}
+	result, err := api.randomness(header)
+	if err != nil {
+		return nil, err
+	}
+	signer := types.MakeSigner(api.dex.chainConfig, header.Number)
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, err
+	}
+	// The nonce of the sender is increased before the call is executed.
+	nonce := hexutil.Uint64(tx.Nonce() + 1)
+	result.TxHash = &txHash
+	result.Caller = &from
+	result.CallerNonce = &nonce
+	return result, nil
+}
+
+// ComputeRand recomputes the output of RAND in the given block, for the
+// caller, its nonce and the remaining gas at the time RAND is executed.
+func (api *PublicDexonAPI) ComputeRand(blockNr rpc.BlockNumber, caller common.Address,
+	nonce hexutil.Uint64, gas hexutil.Uint64) (common.Hash, error) {
+	block, err := api.blockByNumber(blockNr)
+	if err != nil {
+		return common.Hash{}, err
+	}
+	return common.BytesToHash(vm.RandOutput(
+		block.Randomness(), caller, uint64(nonce), uint64(gas))), nil
+}
+
+// GovernanceEventFilter selects the governance contract events by name and
+// by the node they are about. Empty Types matches all events, and a non-nil
+// Node only matches the events indexed by node address, which are Staked,
+// Unstaked, Delegated and Undelegated.
+type GovernanceEventFilter struct {
+	Types []string        `json:"types"`
+	Node  *common.Address `json:"node"`
+}
+
+// topics returns the log topics matching the filter.
+func (f *GovernanceEventFilter) topics() ([][]common.Hash, error) {
+	var topics [][]common.Hash
+	if f == nil {
+		return topics, nil
+	}
+	var ids []common.Hash
+	for _, name := range f.Types {
+		id, err := vm.GovernanceEventID(name)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	topics = append(topics, ids)
+	if f.Node != nil {
+		topics = append(topics, []common.Hash{f.Node.Hash()})
+	}
+	return topics, nil
+}
+
+// GovernanceEventResult is a decoded governance contract event with the
+// position of its log in the chain.
+type GovernanceEventResult struct {
+	*vm.GovernanceEvent
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	TxIndex     hexutil.Uint   `json:"transactionIndex"`
+	LogIndex    hexutil.Uint   `json:"logIndex"`
+	Removed     bool           `json:"removed"`
+}
+
+func (api *PublicDexonAPI) governanceEventResult(log *types.Log) (*GovernanceEventResult, error) {
+	event, err := vm.UnpackGovernanceEvent(log)
+	if err != nil {
+		return nil, err
+	}
+	// The governance contract doesn't set the block number of its logs, and
+	// the block hash of logs is the one of the block before it is
+	// delivered, so both are resolved from the transaction.
+	tx, blockHash, blockNumber, _ := rawdb.ReadTransaction(api.dex.chainDb, log.TxHash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %x not found", log.TxHash)
+	}
+	return &GovernanceEventResult{
+		GovernanceEvent: event,
+		BlockNumber:     hexutil.Uint64(blockNumber),
+		BlockHash:       blockHash,
+		TxHash:          log.TxHash,
+		TxIndex:         hexutil.Uint(log.TxIndex),
+		LogIndex:        hexutil.Uint(log.Index),
+		Removed:         log.Removed,
+	}, nil
+}
+
+// GetGovernanceEvents returns the decoded governance contract events between
+// the given blocks, restricted to the given event names and node if any.
+func (api *PublicDexonAPI) GetGovernanceEvents(ctx context.Context, fromBlock, toBlock rpc.BlockNumber,
+	eventTypes []string, node *common.Address) ([]*GovernanceEventResult, error) {
+	topics, err := (&GovernanceEventFilter{Types: eventTypes, Node: node}).topics()
+	if err != nil {
+		return nil, err
+	}
+	filter := filters.NewRangeFilter(api.dex.APIBackend, fromBlock.Int64(), toBlock.Int64(),
+		[]common.Address{vm.GovernanceContractAddress}, topics)
+	logs, err := filter.Logs(ctx)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]*GovernanceEventResult, 0, len(logs))
+	for _, log := range logs {
+		result, err := api.governanceEventResult(log)
+		if err != nil {
+			return nil, err
+		}
+		results = append(results, result)
+	}
+	return results, nil
+}
+
+// GovernanceEvents creates a subscription that fires for the decoded
+// governance contract events of new blocks matching the filter.
+func (api *PublicDexonAPI) GovernanceEvents(ctx context.Context, filter *GovernanceEventFilter) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	topics, err := filter.topics()
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		rpcSub      = notifier.CreateSubscription()
+		matchedLogs = make(chan []*types.Log)
+	)
+
+	logsSub, err := api.events.SubscribeLogs(ethereum.FilterQuery{
+		Addresses: []common.Address{vm.GovernanceContractAddress},
+		Topics:    topics,
+	}, matchedLogs)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		for {
+			select {
+			case logs := <-matchedLogs:
+				for _, l := range logs {
+					result, err := api.governanceEventResult(l)
+					if err != nil {
+						log.Warn("Failed to decode governance event", "tx", l.TxHash, "err", err)
+						continue
+					}
+					notifier.Notify(rpcSub.ID, result)
+				}
+			case <-rpcSub.Err(): // client send an unsubscribe request
+				logsSub.Unsubscribe()
+				return
+			case <-notifier.Closed(): // connection dropped
+				logsSub.Unsubscribe()
+				return
+			}
+		}
+	}()
+
+	return rpcSub, nil
+}
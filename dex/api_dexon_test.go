@@ -0,0 +1,389 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"bytes"
+	"context"
+	"crypto/ecdsa"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	coreCrypto "github.com/dexon-foundation/dexon-consensus/core/crypto"
+	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+	dkgTypes "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+
+	"github.com/dexon-foundation/dexon/accounts/abi"
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/event"
+	"github.com/dexon-foundation/dexon/rlp"
+	"github.com/dexon-foundation/dexon/rpc"
+)
+
+// testDKG is a DKG of round 0 finished in the genesis governance state,
+// whose threshold signatures verify against the group public key.
+type testDKG struct {
+	ids  dkg.IDs
+	keys []*dkg.PrivateKey
+	gpk  *dkg.PublicKey
+}
+
+// newTestDKG runs a DKG of threshold members and returns the genesis
+// storage of the governance contract recording it as final.
+func newTestDKG(t *testing.T, threshold, finalizeds int) (*testDKG, core.GenesisAccount) {
+	d := &testDKG{}
+	prvShares := make([]*dkg.PrivateKeyShares, threshold)
+	pubShares := make([]*dkg.PublicKeyShares, threshold)
+	nodeIDs := make([]coreTypes.NodeID, threshold)
+	for i := range nodeIDs {
+		nodeIDs[i] = coreTypes.NodeID{Hash: coreCommon.NewRandomHash()}
+		d.ids = append(d.ids, dkg.NewID(nodeIDs[i].Hash[:]))
+	}
+	for i := range prvShares {
+		prvShares[i], pubShares[i] = dkg.NewPrivateKeyShares(threshold)
+		prvShares[i].SetParticipants(d.ids)
+	}
+	for _, id := range d.ids {
+		own := dkg.NewEmptyPrivateKeyShares()
+		for j, shares := range prvShares {
+			share, _ := shares.Share(id)
+			if err := own.AddShare(d.ids[j], share); err != nil {
+				t.Fatalf("failed to add share: %v", err)
+			}
+		}
+		key, err := own.RecoverPrivateKey(d.ids)
+		if err != nil {
+			t.Fatalf("failed to recover private key: %v", err)
+		}
+		d.keys = append(d.keys, key)
+	}
+	d.gpk = dkg.RecoverGroupPublicKey(pubShares)
+
+	statedb, _ := state.New(common.Hash{}, state.NewDatabase(ethdb.NewMemDatabase()))
+	recorder := &storageRecorder{StateDB: statedb, storage: make(map[common.Hash]common.Hash)}
+	helper := &vm.GovernanceStateHelper{StateDB: recorder}
+	for i, nodeID := range nodeIDs {
+		mpk, err := rlp.EncodeToBytes(&dkgTypes.MasterPublicKey{
+			ProposerID:      nodeID,
+			DKGID:           d.ids[i],
+			PublicKeyShares: *pubShares[i],
+		})
+		if err != nil {
+			t.Fatalf("failed to encode master public key: %v", err)
+		}
+		helper.PushDKGMasterPublicKey(big.NewInt(0), mpk)
+	}
+	for i := 0; i < finalizeds; i++ {
+		helper.IncDKGFinalizedsCount(big.NewInt(0))
+	}
+	account := core.GenesisAccount{
+		Balance: big.NewInt(0),
+		Staked:  big.NewInt(0),
+		Storage: recorder.storage,
+	}
+	return d, account
+}
+
+// storageRecorder records the storage written to the governance contract.
+type storageRecorder struct {
+	*state.StateDB
+	storage map[common.Hash]common.Hash
+}
+
+func (r *storageRecorder) SetState(addr common.Address, key, value common.Hash) {
+	r.StateDB.SetState(addr, key, value)
+	r.storage[key] = value
+}
+
+// sign returns the threshold signature of the hash.
+func (d *testDKG) sign(t *testing.T, hash coreCommon.Hash) []byte {
+	psigs := make([]dkg.PartialSignature, len(d.keys))
+	for i, key := range d.keys {
+		sig, err := key.Sign(hash)
+		if err != nil {
+			t.Fatalf("failed to sign: %v", err)
+		}
+		psigs[i] = dkg.PartialSignature(sig)
+	}
+	sig, err := dkg.RecoverSignature(psigs, d.ids)
+	if err != nil {
+		t.Fatalf("failed to recover signature: %v", err)
+	}
+	return sig.Signature
+}
+
+func TestRandomnessAPI(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("hex to ecdsa error: %v", err)
+	}
+	// The testnet DKG set of 4 has a threshold of 2, and needs 3 finalizeds.
+	d, govAccount := newTestDKG(t, 2, 3)
+	dex, err := newTestDexonWithGenesisAlloc(key, core.GenesisAlloc{
+		vm.GovernanceContractAddress: govAccount,
+	})
+	if err != nil {
+		t.Fatalf("new test dexon error: %v", err)
+	}
+
+	// Block 1 has a transaction and valid randomness, block 2 randomness
+	// signing another hash, block 3 writes them into chain.
+	for i, data := range [][][]byte{{{}}, nil, nil} {
+		block, err := prepareConfirmedBlockWithTxAndData(dex, key, data, 0)
+		if err != nil {
+			t.Fatalf("prepare block error: %v", err)
+		}
+		var randomness []byte
+		switch i {
+		case 0:
+			randomness = d.sign(t, block.Hash)
+		case 1:
+			randomness = d.sign(t, coreCommon.NewRandomHash())
+		}
+		dex.app.BlockDelivered(block.Hash, block.Position,
+			coreTypes.FinalizationResult{
+				Timestamp:  time.Now(),
+				Height:     uint64(i + 1),
+				Randomness: randomness,
+			})
+	}
+
+	api := &PublicDexonAPI{dex: dex}
+	if _, err := api.GetBlockRandomness(0); err == nil {
+		t.Error("expected error for genesis without randomness")
+	}
+
+	result, err := api.GetBlockRandomness(1)
+	if err != nil {
+		t.Fatalf("failed to get randomness of block 1: %v", err)
+	}
+	if !result.Verified || result.Threshold != 2 {
+		t.Errorf("block 1 randomness not verified: %+v", result)
+	}
+	if !bytes.Equal(result.GroupPublicKey, d.gpk.Bytes()) {
+		t.Errorf("group public key mismatch: have %x, want %x", result.GroupPublicKey, d.gpk.Bytes())
+	}
+	if !d.gpk.VerifySignature(coreCommon.Hash(result.ConsensusHash), coreCrypto.Signature{
+		Type:      "bls",
+		Signature: result.Randomness,
+	}) {
+		t.Error("randomness does not verify against the consensus hash")
+	}
+
+	result, err = api.GetBlockRandomness(2)
+	if err != nil {
+		t.Fatalf("failed to get randomness of block 2: %v", err)
+	}
+	if result.Verified || result.GroupPublicKey == nil {
+		t.Errorf("block 2 randomness verified: %+v", result)
+	}
+
+	block := dex.blockchain.GetBlockByNumber(1)
+	if len(block.Transactions()) != 1 {
+		t.Fatalf("transaction count mismatch: have %d, want 1", len(block.Transactions()))
+	}
+	tx := block.Transactions()[0]
+	result, err = api.GetTransactionRandomness(tx.Hash())
+	if err != nil {
+		t.Fatalf("failed to get transaction randomness: %v", err)
+	}
+	caller := crypto.PubkeyToAddress(key.PublicKey)
+	if !result.Verified || uint64(result.BlockNumber) != 1 ||
+		*result.TxHash != tx.Hash() || *result.Caller != caller ||
+		uint64(*result.CallerNonce) != tx.Nonce()+1 {
+		t.Errorf("transaction randomness mismatch: %+v", result)
+	}
+	if _, err := api.GetTransactionRandomness(common.Hash{1}); err == nil {
+		t.Error("expected error for unknown transaction")
+	}
+
+	rand, err := api.ComputeRand(1, caller, 1, 21000)
+	if err != nil {
+		t.Fatalf("failed to compute rand: %v", err)
+	}
+	want := common.BytesToHash(vm.RandOutput(block.Randomness(), caller, 1, 21000))
+	if rand != want {
+		t.Errorf("rand mismatch: have %x, want %x", rand, want)
+	}
+	if _, err := api.ComputeRand(rpc.BlockNumber(10), caller, 1, 21000); err == nil {
+		t.Error("expected error for unknown block")
+	}
+}
+
+func TestGovernanceEventFilterTopics(t *testing.T) {
+	var filter *GovernanceEventFilter
+	if topics, err := filter.topics(); err != nil || len(topics) != 0 {
+		t.Errorf("nil filter topics mismatch: have %v, %v", topics, err)
+	}
+
+	node := common.HexToAddress("0x1234")
+	filter = &GovernanceEventFilter{Types: []string{"Staked", "Unstaked"}, Node: &node}
+	topics, err := filter.topics()
+	if err != nil {
+		t.Fatalf("failed to build topics: %v", err)
+	}
+	staked, _ := vm.GovernanceEventID("Staked")
+	unstaked, _ := vm.GovernanceEventID("Unstaked")
+	if len(topics) != 2 || len(topics[0]) != 2 || topics[0][0] != staked || topics[0][1] != unstaked {
+		t.Errorf("event topics mismatch: have %v", topics)
+	}
+	if len(topics[1]) != 1 || topics[1][0] != node.Hash() {
+		t.Errorf("node topic mismatch: have %v", topics[1])
+	}
+
+	filter = &GovernanceEventFilter{Types: []string{"Transfer"}}
+	if _, err := filter.topics(); err == nil {
+		t.Error("expected error for unknown event")
+	}
+}
+
+func TestGovernanceEventsAPI(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("hex to ecdsa error: %v", err)
+	}
+	nodeKey, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("hex to ecdsa error: %v", err)
+	}
+	nodeAddr := crypto.PubkeyToAddress(nodeKey.PublicKey)
+	dex, err := newTestDexonWithGenesisAlloc(key, core.GenesisAlloc{
+		nodeAddr: {Balance: big.NewInt(1e18), Staked: big.NewInt(0)},
+	})
+	if err != nil {
+		t.Fatalf("new test dexon error: %v", err)
+	}
+	dex.eventMux = new(event.TypeMux)
+	abiObject, err := abi.JSON(strings.NewReader(vm.GovernanceABIJSON))
+	if err != nil {
+		t.Fatalf("get abi object fail: %v", err)
+	}
+
+	api := NewPublicDexonAPI(dex)
+	server := rpc.NewServer()
+	if err := server.RegisterName("dexon", api); err != nil {
+		t.Fatalf("failed to register api: %v", err)
+	}
+	defer server.Stop()
+	client := rpc.DialInProc(server)
+	defer client.Close()
+	results := make(chan *GovernanceEventResult, 4)
+	sub, err := client.Subscribe(context.Background(), "dexon", results, "governanceEvents",
+		&GovernanceEventFilter{Types: []string{"Delegated"}, Node: &nodeAddr})
+	if err != nil {
+		t.Fatalf("failed to subscribe: %v", err)
+	}
+	defer sub.Unsubscribe()
+
+	// The node stakes in height 1, and is delegated in height 2.
+	stake, err := abiObject.Pack("stake", crypto.FromECDSAPub(&nodeKey.PublicKey),
+		"Test1", "test1@dexon.org", "Taipei, Taiwan", "https://dexon.org")
+	if err != nil {
+		t.Fatalf("abiObject pack error: %v", err)
+	}
+	delegate, err := abiObject.Pack("delegate", nodeAddr)
+	if err != nil {
+		t.Fatalf("abiObject pack error: %v", err)
+	}
+	delegator := crypto.PubkeyToAddress(key.PublicKey)
+	signer := types.NewEIP155Signer(dex.chainConfig.ChainID)
+	delegateTx, err := types.SignTx(types.NewTransaction(dex.txPool.State().GetNonce(delegator),
+		vm.GovernanceContractAddress, big.NewInt(1e16), 1000000, big.NewInt(1), delegate), signer, key)
+	if err != nil {
+		t.Fatalf("sign tx error: %v", err)
+	}
+	// Make the delegate block write into chain with an empty block.
+	for i, step := range []struct {
+		key  *ecdsa.PrivateKey
+		data [][]byte
+		tx   *types.Transaction
+	}{{nodeKey, [][]byte{stake}, nil}, {key, nil, delegateTx}, {key, nil, nil}} {
+		if step.tx != nil {
+			if err := dex.txPool.AddRemote(step.tx); err != nil {
+				t.Fatalf("add tx error: %v", err)
+			}
+		}
+		block, err := prepareConfirmedBlockWithTxAndData(dex, step.key, step.data, 0)
+		if err != nil {
+			t.Fatalf("prepare block error: %v", err)
+		}
+		dex.app.BlockDelivered(block.Hash, block.Position,
+			coreTypes.FinalizationResult{
+				Timestamp: time.Now(),
+				Height:    uint64(i + 1),
+			})
+	}
+
+	block := dex.blockchain.GetBlockByNumber(2)
+	if block == nil || len(block.Transactions()) != 1 || block.Transactions()[0].Hash() != delegateTx.Hash() {
+		t.Fatalf("delegate block mismatch: have %v", block)
+	}
+	// The subscription decodes the args from JSON.
+	checkDelegated := func(result *GovernanceEventResult, delegator interface{}) {
+		if result.Name != "Delegated" || uint64(result.BlockNumber) != 2 ||
+			result.BlockHash != block.Hash() || result.TxHash != delegateTx.Hash() {
+			t.Errorf("delegated event mismatch: have %+v", result)
+		}
+		if have := result.Args["DelegatorAddress"]; have != delegator {
+			t.Errorf("delegator mismatch: have %v, want %v", have, delegator)
+		}
+	}
+
+	events, err := api.GetGovernanceEvents(context.Background(), 0, rpc.LatestBlockNumber, nil, &nodeAddr)
+	if err != nil {
+		t.Fatalf("failed to get governance events: %v", err)
+	}
+	if len(events) != 2 {
+		t.Fatalf("event count mismatch: have %d, want 2", len(events))
+	}
+	if events[0].Name != "Staked" || uint64(events[0].BlockNumber) != 1 {
+		t.Errorf("staked event mismatch: have %+v", events[0])
+	}
+	checkDelegated(events[1], delegator)
+	events, err = api.GetGovernanceEvents(context.Background(), 2, 2, []string{"Staked"}, nil)
+	if err != nil {
+		t.Fatalf("failed to get governance events: %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("event count mismatch: have %d, want 0", len(events))
+	}
+
+	select {
+	case result := <-results:
+		checkDelegated(result, strings.ToLower(delegator.Hex()))
+	case err := <-sub.Err():
+		t.Fatalf("subscription error: %v", err)
+	case <-time.After(5 * time.Second):
+		t.Fatal("delegated event not notified")
+	}
+	select {
+	case result := <-results:
+		t.Errorf("unexpected event notified: %+v", result)
+	default:
+	}
+}
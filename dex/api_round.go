@@ -0,0 +1,308 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/eth/filters"
+)
+
+// maxRoundTimelineBlocks bounds the blocks a GetRoundTimeline call goes
+// through, the rest of them are left to the calls resuming from Next.
+const maxRoundTimelineBlocks = 20000
+
+// RoundTimelineTx is a governance contract transaction in the timeline of a
+// round.
+type RoundTimelineTx struct {
+	BlockNumber hexutil.Uint64 `json:"blockNumber"`
+	BlockHash   common.Hash    `json:"blockHash"`
+	TxHash      common.Hash    `json:"transactionHash"`
+	From        common.Address `json:"from"`
+}
+
+// RoundSnapshot is the snapshotRound transaction recording the height of a
+// round.
+type RoundSnapshot struct {
+	RoundTimelineTx
+	Height hexutil.Uint64 `json:"height"`
+}
+
+// RoundDKGCounts is the DKG progress of a round in the governance contract.
+type RoundDKGCounts struct {
+	MasterPublicKeys hexutil.Uint64 `json:"masterPublicKeys"`
+	Complaints       hexutil.Uint64 `json:"complaints"`
+	MPKReadys        hexutil.Uint64 `json:"mpkReadys"`
+	Finalizeds       hexutil.Uint64 `json:"finalizeds"`
+}
+
+// RoundDKGStep is a DKG transaction of a round, with the DKG progress after
+// the block including it. Counts is nil if the state of the block is not
+// available anymore.
+type RoundDKGStep struct {
+	RoundTimelineTx
+	Method string          `json:"method"`
+	Failed bool            `json:"failed"`
+	Reason string          `json:"reason,omitempty"`
+	Counts *RoundDKGCounts `json:"counts"`
+}
+
+// RoundTimeline is how a round was prepared and run on the chain. Snapshot
+// and DKG are found in the blocks from FromBlock to ToBlock, and Next is the
+// block to resume from for the rest of them, nil if there are none.
+type RoundTimeline struct {
+	Round       hexutil.Uint64   `json:"round"`
+	CRS         *common.Hash     `json:"crs"`
+	CRSProposal *RoundTimelineTx `json:"crsProposal"`
+	Snapshot    *RoundSnapshot   `json:"snapshot"`
+	DKG         []*RoundDKGStep  `json:"dkg"`
+	DKGCounts   *RoundDKGCounts  `json:"dkgCounts"`
+	FirstBlock  *hexutil.Uint64  `json:"firstBlock"`
+	LastBlock   *hexutil.Uint64  `json:"lastBlock"`
+	FromBlock   *hexutil.Uint64  `json:"fromBlock"`
+	ToBlock     *hexutil.Uint64  `json:"toBlock"`
+	Next        *hexutil.Uint64  `json:"next"`
+}
+
+// roundTimelineMethods are the governance contract methods taking the round
+// as the first argument, which make up the timeline of the round.
+var roundTimelineMethods = func() map[string]string {
+	methods := make(map[string]string)
+	for _, name := range []string{
+		"addDKGMasterPublicKey",
+		"addDKGComplaint",
+		"addDKGMPKReady",
+		"addDKGFinalize",
+		"snapshotRound",
+	} {
+		methods[string(vm.GovernanceContractName2Method[name].Id())] = name
+	}
+	return methods
+}()
+
+// roundHeight returns the first block of the round, recorded by snapshotRound
+// or, before that, known by the blockchain from consensus.
+func (api *PublicDexonAPI) roundHeight(helper *vm.GovernanceStateHelper, round uint64) (uint64, bool) {
+	r := new(big.Int).SetUint64(round)
+	if helper.LenRoundHeight().Cmp(r) > 0 {
+		return helper.RoundHeight(r).Uint64(), true
+	}
+	return api.dex.blockchain.GetRoundHeight(round)
+}
+
+func (api *PublicDexonAPI) dkgCounts(header *types.Header, round *big.Int) *RoundDKGCounts {
+	statedb, err := api.dex.blockchain.StateAt(header.Root)
+	if err != nil {
+		return nil
+	}
+	helper := &vm.GovernanceStateHelper{StateDB: statedb}
+	return &RoundDKGCounts{
+		MasterPublicKeys: hexutil.Uint64(len(helper.DKGMasterPublicKeys(round))),
+		Complaints:       hexutil.Uint64(len(helper.DKGComplaints(round))),
+		MPKReadys:        hexutil.Uint64(helper.DKGMPKReadysCount(round).Uint64()),
+		Finalizeds:       hexutil.Uint64(helper.DKGFinalizedsCount(round).Uint64()),
+	}
+}
+
+// crsProposal finds the CRS proposal of the round between the given blocks
+// through the indexed CRSProposed events.
+func (api *PublicDexonAPI) crsProposal(ctx context.Context, round *big.Int, begin, end uint64) (*RoundTimelineTx, error) {
+	crsProposed, err := vm.GovernanceEventID("CRSProposed")
+	if err != nil {
+		return nil, err
+	}
+	filter := filters.NewRangeFilter(api.dex.APIBackend, int64(begin), int64(end),
+		[]common.Address{vm.GovernanceContractAddress},
+		[][]common.Hash{{crsProposed}, {common.BigToHash(round)}})
+	logs, err := filter.Logs(ctx)
+	if err != nil || len(logs) == 0 {
+		return nil, err
+	}
+	// The block of the log is taken from the transaction lookup, as the
+	// governance contract events carry no block number.
+	txHash := logs[len(logs)-1].TxHash
+	tx, blockHash, number, _ := rawdb.ReadTransaction(api.dex.ChainDb(), txHash)
+	if tx == nil {
+		return nil, fmt.Errorf("transaction %x not found", txHash)
+	}
+	signer := types.MakeSigner(api.dex.chainConfig, new(big.Int).SetUint64(number))
+	from, err := types.Sender(signer, tx)
+	if err != nil {
+		return nil, err
+	}
+	return &RoundTimelineTx{
+		BlockNumber: hexutil.Uint64(number),
+		BlockHash:   blockHash,
+		TxHash:      txHash,
+		From:        from,
+	}, nil
+}
+
+// GetRoundTimeline returns when the CRS of the round was proposed, when its
+// height was snapshotted, the DKG transactions of the round and its first
+// and last block. The CRS and DKG of a round are prepared in the round
+// before it, so the blocks from the first block of the previous round are
+// searched. The DKG methods emit no events, their transactions are found by
+// going through the blocks, up to maxRoundTimelineBlocks of them from the
+// given block if any, and the timeline tells where to resume from.
+func (api *PublicDexonAPI) GetRoundTimeline(ctx context.Context, round hexutil.Uint64,
+	from *hexutil.Uint64) (*RoundTimeline, error) {
+	return api.roundTimeline(ctx, round, from, maxRoundTimelineBlocks)
+}
+
+func (api *PublicDexonAPI) roundTimeline(ctx context.Context, round hexutil.Uint64,
+	from *hexutil.Uint64, limit uint64) (*RoundTimeline, error) {
+	head := api.dex.blockchain.CurrentBlock()
+	statedb, err := api.dex.blockchain.StateAt(head.Root())
+	if err != nil {
+		return nil, err
+	}
+	helper := &vm.GovernanceStateHelper{StateDB: statedb}
+	r := new(big.Int).SetUint64(uint64(round))
+
+	timeline := &RoundTimeline{
+		Round:     round,
+		DKG:       []*RoundDKGStep{},
+		DKGCounts: api.dkgCounts(head.Header(), r),
+	}
+	if helper.LenCRS().Cmp(r) > 0 {
+		crs := helper.CRS(r)
+		timeline.CRS = &crs
+	}
+	if height, ok := api.roundHeight(helper, uint64(round)); ok {
+		first := hexutil.Uint64(height)
+		timeline.FirstBlock = &first
+	}
+	end := head.NumberU64()
+	if height, ok := api.roundHeight(helper, uint64(round)+1); ok && height > 0 {
+		last := hexutil.Uint64(height - 1)
+		timeline.LastBlock = &last
+		end = height - 1
+	}
+	var begin uint64
+	if round > 0 {
+		height, ok := api.roundHeight(helper, uint64(round)-1)
+		if !ok {
+			// The previous round has not started, neither has this one.
+			return timeline, nil
+		}
+		begin = height
+	}
+
+	if timeline.CRSProposal, err = api.crsProposal(ctx, r, begin, end); err != nil {
+		return nil, err
+	}
+	start := begin
+	if from != nil {
+		if uint64(*from) < begin || uint64(*from) > end {
+			return nil, fmt.Errorf("block #%d is not in the blocks #%d-#%d of round %d",
+				*from, begin, end, round)
+		}
+		start = uint64(*from)
+	}
+	stop := end
+	if stop-start >= limit {
+		stop = start + limit - 1
+		next := hexutil.Uint64(stop + 1)
+		timeline.Next = &next
+	}
+	fromBlock, toBlock := hexutil.Uint64(start), hexutil.Uint64(stop)
+	timeline.FromBlock, timeline.ToBlock = &fromBlock, &toBlock
+
+	for number := start; number <= stop; number++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		block := api.dex.blockchain.GetBlockByNumber(number)
+		if block == nil {
+			return nil, fmt.Errorf("block #%d not found", number)
+		}
+		var (
+			receipts types.Receipts
+			counts   *RoundDKGCounts
+		)
+		signer := types.MakeSigner(api.dex.chainConfig, block.Number())
+		for i, tx := range block.Transactions() {
+			if tx.To() == nil || *tx.To() != vm.GovernanceContractAddress {
+				continue
+			}
+			data := tx.Data()
+			if len(data) < 4 {
+				continue
+			}
+			name, ok := roundTimelineMethods[string(data[:4])]
+			if !ok {
+				continue
+			}
+			args, err := vm.GovernanceContractName2Method[name].Inputs.UnpackValues(data[4:])
+			if err != nil || len(args) == 0 {
+				continue
+			}
+			if arg, ok := args[0].(*big.Int); !ok || arg.Cmp(r) != 0 {
+				continue
+			}
+
+			if receipts == nil {
+				receipts = api.dex.blockchain.GetReceiptsByHash(block.Hash())
+				if len(receipts) != len(block.Transactions()) {
+					return nil, fmt.Errorf("receipts of block #%d not found", number)
+				}
+			}
+			from, err := types.Sender(signer, tx)
+			if err != nil {
+				return nil, err
+			}
+			ttx := RoundTimelineTx{
+				BlockNumber: hexutil.Uint64(number),
+				BlockHash:   block.Hash(),
+				TxHash:      tx.Hash(),
+				From:        from,
+			}
+			receipt := receipts[i]
+			failed := receipt.Status == types.ReceiptStatusFailed
+			if name == "snapshotRound" {
+				if !failed {
+					height := args[1].(*big.Int)
+					timeline.Snapshot = &RoundSnapshot{
+						RoundTimelineTx: ttx,
+						Height:          hexutil.Uint64(height.Uint64()),
+					}
+				}
+				continue
+			}
+			if counts == nil {
+				counts = api.dkgCounts(block.Header(), r)
+			}
+			timeline.DKG = append(timeline.DKG, &RoundDKGStep{
+				RoundTimelineTx: ttx,
+				Method:          name,
+				Failed:          failed,
+				Reason:          receipt.RevertReason,
+				Counts:          counts,
+			})
+		}
+	}
+	return timeline, nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"context"
+	"math/big"
+	"strings"
+	"testing"
+	"time"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/accounts/abi"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+)
+
+func TestGetRoundTimeline(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatalf("hex to ecdsa error: %v", err)
+	}
+	d, govAccount := newTestDKG(t, 2, 3)
+	dex, err := newTestDexonWithGenesisAlloc(key, core.GenesisAlloc{
+		vm.GovernanceContractAddress: govAccount,
+	})
+	if err != nil {
+		t.Fatalf("new test dexon error: %v", err)
+	}
+	abiObject, err := abi.JSON(strings.NewReader(vm.GovernanceABIJSON))
+	if err != nil {
+		t.Fatalf("get abi object fail: %v", err)
+	}
+
+	// An invalid master public key of round 1 in height 1.
+	mpk, err := abiObject.Pack("addDKGMasterPublicKey", big.NewInt(1), []byte{1})
+	if err != nil {
+		t.Fatalf("abiObject pack error: %v", err)
+	}
+	// The CRS of round 1 in height 2.
+	crs0 := crypto.Keccak256Hash([]byte(dex.chainConfig.Dexcon.GenesisCRSText))
+	proposeCRS, err := abiObject.Pack("proposeCRS", big.NewInt(1), d.sign(t, coreCommon.Hash(crs0)))
+	if err != nil {
+		t.Fatalf("abiObject pack error: %v", err)
+	}
+	// Snapshot round 1 in height 3.
+	snapshot, err := abiObject.Pack("snapshotRound", big.NewInt(1), big.NewInt(1))
+	if err != nil {
+		t.Fatalf("abiObject pack error: %v", err)
+	}
+	// Make the snapshot block write into chain with an empty block.
+	for i, data := range [][][]byte{{mpk}, {proposeCRS}, {snapshot}, nil} {
+		block, err := prepareConfirmedBlockWithTxAndData(dex, key, data, 1)
+		if err != nil {
+			t.Fatalf("prepare block error: %v", err)
+		}
+		dex.app.BlockDelivered(block.Hash, block.Position,
+			coreTypes.FinalizationResult{
+				Timestamp: time.Now(),
+				Height:    uint64(i + 1),
+			})
+	}
+
+	api := &PublicDexonAPI{dex: dex}
+	timeline, err := api.GetRoundTimeline(context.Background(), 0, nil)
+	if err != nil {
+		t.Fatalf("failed to get round 0 timeline: %v", err)
+	}
+	if timeline.CRS == nil || *timeline.CRS != crs0 || timeline.CRSProposal != nil {
+		t.Errorf("round 0 CRS mismatch: have %v, %v", timeline.CRS, timeline.CRSProposal)
+	}
+	if timeline.FirstBlock == nil || *timeline.FirstBlock != 0 ||
+		timeline.LastBlock == nil || *timeline.LastBlock != 0 {
+		t.Errorf("round 0 blocks mismatch: have %v-%v", timeline.FirstBlock, timeline.LastBlock)
+	}
+
+	timeline, err = api.GetRoundTimeline(context.Background(), 1, nil)
+	if err != nil {
+		t.Fatalf("failed to get round 1 timeline: %v", err)
+	}
+	if timeline.CRS == nil {
+		t.Errorf("round 1 CRS not proposed")
+	}
+	block := dex.blockchain.GetBlockByNumber(2)
+	if proposal := timeline.CRSProposal; proposal == nil || proposal.BlockNumber != 2 ||
+		proposal.BlockHash != block.Hash() || proposal.TxHash != block.Transactions()[0].Hash() ||
+		proposal.From != crypto.PubkeyToAddress(key.PublicKey) {
+		t.Errorf("round 1 CRS proposal mismatch: have %+v", proposal)
+	}
+	if timeline.FirstBlock == nil || *timeline.FirstBlock != 1 || timeline.LastBlock != nil {
+		t.Errorf("round 1 blocks mismatch: have %v-%v", timeline.FirstBlock, timeline.LastBlock)
+	}
+	if *timeline.FromBlock != 0 || *timeline.ToBlock != 3 || timeline.Next != nil {
+		t.Errorf("round 1 scanned blocks mismatch: have %v-%v, next %v",
+			*timeline.FromBlock, *timeline.ToBlock, timeline.Next)
+	}
+	if timeline.Snapshot == nil || timeline.Snapshot.BlockNumber != 3 || timeline.Snapshot.Height != 1 {
+		t.Errorf("round 1 snapshot mismatch: have %+v", timeline.Snapshot)
+	}
+	if len(timeline.DKG) != 1 {
+		t.Fatalf("DKG step count mismatch: have %d, want 1", len(timeline.DKG))
+	}
+	step := timeline.DKG[0]
+	if step.Method != "addDKGMasterPublicKey" || !step.Failed || step.Reason == "" {
+		t.Errorf("DKG step mismatch: have %+v", step)
+	}
+	if step.Counts == nil || step.Counts.MasterPublicKeys != 0 {
+		t.Errorf("DKG counts mismatch: have %+v", step.Counts)
+	}
+
+	// Scanning two blocks at a time, the DKG step is found in the first
+	// blocks and the snapshot in the next ones.
+	timeline, err = api.roundTimeline(context.Background(), 1, nil, 2)
+	if err != nil {
+		t.Fatalf("failed to get round 1 timeline: %v", err)
+	}
+	if len(timeline.DKG) != 1 || timeline.Snapshot != nil || timeline.CRSProposal == nil {
+		t.Errorf("round 1 first blocks mismatch: have %+v", timeline)
+	}
+	if *timeline.FromBlock != 0 || *timeline.ToBlock != 1 || timeline.Next == nil || *timeline.Next != 2 {
+		t.Fatalf("round 1 first blocks mismatch: have %v-%v, next %v",
+			*timeline.FromBlock, *timeline.ToBlock, timeline.Next)
+	}
+	timeline, err = api.roundTimeline(context.Background(), 1, timeline.Next, 2)
+	if err != nil {
+		t.Fatalf("failed to resume round 1 timeline: %v", err)
+	}
+	if len(timeline.DKG) != 0 || timeline.Snapshot == nil {
+		t.Errorf("round 1 next blocks mismatch: have %+v", timeline)
+	}
+	if *timeline.FromBlock != 2 || *timeline.ToBlock != 3 || timeline.Next != nil {
+		t.Errorf("round 1 next blocks mismatch: have %v-%v, next %v",
+			*timeline.FromBlock, *timeline.ToBlock, timeline.Next)
+	}
+	out := hexutil.Uint64(4)
+	if _, err := api.GetRoundTimeline(context.Background(), 1, &out); err == nil {
+		t.Error("expected error for block out of the round")
+	}
+}
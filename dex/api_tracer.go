@@ -606,6 +606,7 @@ func (api *PrivateDebugAPI) traceTx(ctx context.Context, message core.Message, v
 			Failed:      failed,
 			ReturnValue: fmt.Sprintf("%x", ret),
 			StructLogs:  ethapi.FormatLogs(tracer.StructLogs()),
+			Governance:  tracer.GovernanceCalls(),
 		}, nil
 
 	case *tracers.Tracer:
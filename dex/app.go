@@ -555,6 +555,18 @@ func (d *DexconApp) BlockConfirmed(block coreTypes.Block) {
 	}
 }
 
+// pruneConfirmedBlocks drops the confirmed blocks proposed before the given
+// round which are never delivered. It returns the number of blocks dropped.
+func (d *DexconApp) pruneConfirmedBlocks(round uint64) int {
+	var pruned int
+	for _, chainID := range d.blockchain.ConfirmedBlockChainIDs() {
+		d.chainLock(chainID)
+		pruned += d.blockchain.RemoveConfirmedBlocksBefore(chainID, round)
+		d.chainUnlock(chainID)
+	}
+	return pruned
+}
+
 func (d *DexconApp) SubscribeNewFinalizedBlockEvent(
 	ch chan<- core.NewFinalizedBlockEvent) event.Subscription {
 	return d.scope.Track(d.finalizedBlockFeed.Subscribe(ch))
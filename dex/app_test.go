@@ -400,6 +400,52 @@ func TestBlockConfirmed(t *testing.T) {
 	}
 }
 
+func TestPruneConfirmedBlocks(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Errorf("hex to ecdsa error: %v", err)
+	}
+
+	dex, err := newTestDexonWithGenesis(key)
+	if err != nil {
+		t.Errorf("new test dexon error: %v", err)
+	}
+
+	chainID := uint32(new(big.Int).Mod(crypto.PubkeyToAddress(key.PublicKey).Big(),
+		big.NewInt(int64(dex.chainConfig.Dexcon.NumChains))).Uint64())
+
+	var blocks []*coreTypes.Block
+	for round := uint64(0); round < 3; round++ {
+		payload, witness, _, _, err := prepareData(dex, key, int(round)*10, 10)
+		if err != nil {
+			t.Errorf("prepare data error: %v", err)
+		}
+
+		block := &coreTypes.Block{}
+		block.Hash = coreCommon.NewRandomHash()
+		block.Witness = witness
+		block.Payload = payload
+		block.ProposerID = coreTypes.NodeID{coreCommon.Hash{1, 2, 3}}
+		block.Position.ChainID = chainID
+		block.Position.Round = round
+
+		dex.app.BlockConfirmed(*block)
+		blocks = append(blocks, block)
+	}
+
+	if pruned := dex.app.pruneConfirmedBlocks(2); pruned != 2 {
+		t.Errorf("expect pruned blocks is 2 but %v", pruned)
+	}
+	if block, _ := dex.app.blockchain.GetConfirmedBlockByHash(chainID, blocks[2].Hash); block == nil {
+		t.Errorf("confirmed block of round 2 is pruned")
+	}
+
+	info := dex.app.blockchain.GetAddressInfo(chainID, crypto.PubkeyToAddress(key.PublicKey))
+	if info.Counter != 1 {
+		t.Errorf("expect address counter is 1 but %v", info.Counter)
+	}
+}
+
 func TestBlockDelivered(t *testing.T) {
 	key, err := crypto.GenerateKey()
 	if err != nil {
@@ -643,6 +689,12 @@ func BenchmarkBlockDeliveredFlow(b *testing.B) {
 }
 
 func newTestDexonWithGenesis(allocKey *ecdsa.PrivateKey) (*Dexon, error) {
+	return newTestDexonWithGenesisAlloc(allocKey, nil)
+}
+
+// newTestDexonWithGenesisAlloc creates a test dexon with extra genesis
+// accounts besides the one of allocKey.
+func newTestDexonWithGenesisAlloc(allocKey *ecdsa.PrivateKey, alloc core.GenesisAlloc) (*Dexon, error) {
 	db := ethdb.NewMemDatabase()
 
 	key, err := crypto.GenerateKey()
@@ -652,6 +704,10 @@ func newTestDexonWithGenesis(allocKey *ecdsa.PrivateKey) (*Dexon, error) {
 
 	testBankAddress := crypto.PubkeyToAddress(allocKey.PublicKey)
 	genesis := core.DefaultTestnetGenesisBlock()
+	// Failed governance calls return their reasons as in new chains.
+	genesisConfig := *genesis.Config
+	genesisConfig.GovernanceRevertReasonBlock = big.NewInt(0)
+	genesis.Config = &genesisConfig
 	genesis.Alloc = core.GenesisAlloc{
 		testBankAddress: {
 			Balance:   big.NewInt(100000000000000000),
@@ -659,6 +715,9 @@ func newTestDexonWithGenesis(allocKey *ecdsa.PrivateKey) (*Dexon, error) {
 			PublicKey: crypto.FromECDSAPub(&key.PublicKey),
 		},
 	}
+	for addr, account := range alloc {
+		genesis.Alloc[addr] = account
+	}
 	chainConfig, _, err := core.SetupGenesisBlock(db, genesis)
 	if err != nil {
 		return nil, err
@@ -670,10 +729,11 @@ func newTestDexonWithGenesis(allocKey *ecdsa.PrivateKey) (*Dexon, error) {
 	engine := dexcon.New()
 
 	dex := &Dexon{
-		chainDb:     db,
-		chainConfig: chainConfig,
-		networkID:   config.NetworkId,
-		engine:      engine,
+		chainDb:      db,
+		chainConfig:  chainConfig,
+		networkID:    config.NetworkId,
+		engine:       engine,
+		bloomIndexer: NewBloomIndexer(db, params.BloomBitsBlocks, params.BloomConfirms),
 	}
 
 	dex.blockchain, err = core.NewBlockChain(db, nil, chainConfig, engine, vmConfig, nil)
@@ -682,6 +742,7 @@ func newTestDexonWithGenesis(allocKey *ecdsa.PrivateKey) (*Dexon, error) {
 	}
 
 	txPoolConfig := core.DefaultTxPoolConfig
+	txPoolConfig.Journal = ""
 	dex.txPool = core.NewTxPool(txPoolConfig, chainConfig, dex.blockchain, true)
 
 	dex.APIBackend = &DexAPIBackend{dex, nil}
@@ -755,7 +816,7 @@ func prepareConfirmedBlockWithTxAndData(dex *Dexon, key *ecdsa.PrivateKey, data
 		// Prepare one block for pending.
 		nonce := dex.txPool.State().GetNonce(address)
 		signer := types.NewEIP155Signer(dex.chainConfig.ChainID)
-		tx := types.NewTransaction(uint64(nonce), vm.GovernanceContractAddress, big.NewInt(0), params.TxGas*2,
+		tx := types.NewTransaction(uint64(nonce), vm.GovernanceContractAddress, big.NewInt(0), 1000000,
 			big.NewInt(1), d)
 		tx, err = types.SignTx(tx, signer, key)
 		if err != nil {
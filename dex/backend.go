@@ -22,12 +22,15 @@ import (
 	"time"
 
 	"github.com/dexon-foundation/dexon/accounts"
+	"github.com/dexon-foundation/dexon/accounts/keystore"
 	"github.com/dexon-foundation/dexon/consensus"
 	"github.com/dexon-foundation/dexon/consensus/dexcon"
 	"github.com/dexon-foundation/dexon/core"
 	"github.com/dexon-foundation/dexon/core/bloombits"
 	"github.com/dexon-foundation/dexon/core/rawdb"
 	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/dex/db"
 	"github.com/dexon-foundation/dexon/dex/downloader"
 	"github.com/dexon-foundation/dexon/eth/filters"
 	"github.com/dexon-foundation/dexon/eth/gasprice"
@@ -35,6 +38,7 @@ import (
 	"github.com/dexon-foundation/dexon/event"
 	"github.com/dexon-foundation/dexon/indexer"
 	"github.com/dexon-foundation/dexon/internal/ethapi"
+	"github.com/dexon-foundation/dexon/ldex"
 	"github.com/dexon-foundation/dexon/log"
 	"github.com/dexon-foundation/dexon/node"
 	"github.com/dexon-foundation/dexon/p2p"
@@ -56,7 +60,8 @@ type Dexon struct {
 	protocolManager *ProtocolManager
 
 	// DB interfaces
-	chainDb ethdb.Database // Block chain database
+	chainDb  ethdb.Database // Block chain database
+	dbConfig db.Config      // Consensus core database options
 
 	eventMux       *event.TypeMux
 	engine         consensus.Engine
@@ -72,12 +77,15 @@ type Dexon struct {
 	governance *DexconGovernance
 	network    *DexconNetwork
 
-	bp *blockProposer
+	bp     *blockProposer
+	pruner *corePruner
+
+	lightServer *ldex.Server
 
 	networkID     uint64
 	netRPCService *ethapi.PublicNetAPI
 
-	indexer indexer.Indexer
+	indexers *indexer.Supervisor
 }
 
 func New(ctx *node.ServiceContext, config *Config) (*Dexon, error) {
@@ -123,7 +131,14 @@ func New(ctx *node.ServiceContext, config *Config) (*Dexon, error) {
 			EVMInterpreter:          config.EVMInterpreter,
 			IsBlockProposer:         config.BlockProposerEnabled,
 		}
-		cacheConfig = &core.CacheConfig{Disabled: config.NoPruning, TrieCleanLimit: config.TrieCleanCache, TrieDirtyLimit: config.TrieDirtyCache, TrieTimeLimit: config.TrieTimeout}
+		cacheConfig = &core.CacheConfig{
+			Disabled:       config.NoPruning,
+			TrieCleanLimit: config.TrieCleanCache,
+			TrieDirtyLimit: config.TrieDirtyCache,
+			TrieTimeLimit:  config.TrieTimeout,
+			RoundPruning:   config.RoundPruning,
+			StateRetention: config.StateRetention,
+		}
 	)
 	dex.blockchain, err = core.NewBlockChain(chainDb, cacheConfig, dex.chainConfig, dex.engine, vmConfig, nil)
 
@@ -135,12 +150,25 @@ func New(ctx *node.ServiceContext, config *Config) (*Dexon, error) {
 	}
 	dex.bloomIndexer.Start(dex.blockchain)
 
+	dex.dbConfig = coreDBConfig(config)
+	lastRound, _ := rawdb.ReadLastRoundNumber(chainDb)
+	migrated, err := db.NewDatabaseWithConfig(chainDb, dex.dbConfig).MigrateDKGPrivateKeys(lastRound + 1)
+	if err != nil {
+		return nil, err
+	}
+	if migrated > 0 {
+		log.Info("Encrypted DKG private keys", "count", migrated)
+	}
+
 	if config.Indexer.Enable {
-		dex.indexer = indexer.NewIndexerFromConfig(
+		dex.indexers, err = indexer.NewSupervisor(
 			indexer.NewROBlockChain(dex.blockchain),
 			config.Indexer,
 		)
-		dex.indexer.Start()
+		if err != nil {
+			return nil, err
+		}
+		dex.indexers.Start()
 	}
 
 	if config.TxPool.Journal != "" {
@@ -177,15 +205,41 @@ func New(ctx *node.ServiceContext, config *Config) (*Dexon, error) {
 		return nil, err
 	}
 
+	pm.peerSetGracePeriod = config.PeerSetGracePeriod
+	pm.rateLimit = config.RateLimit
 	dex.protocolManager = pm
 	dex.network = NewDexconNetwork(pm)
 
+	if config.LightServ > 0 {
+		dex.lightServer = ldex.NewServer(dex.blockchain, config.NetworkId, config.LightPeers)
+	}
+
 	dex.bp = NewBlockProposer(dex, dMoment)
+	dex.pruner = newCorePruner(db.NewDatabaseWithConfig(chainDb, dex.dbConfig), dex.blockchain, dex.app)
 	return dex, nil
 }
 
+// coreDBConfig returns the consensus core database options of config.
+func coreDBConfig(config *Config) db.Config {
+	c := db.Config{
+		ScryptN:            keystore.LightScryptN,
+		ScryptP:            keystore.LightScryptP,
+		DKGKeyRetention:    config.DKGKeyRetention,
+		CoreBlockRetention: config.CoreBlockRetention,
+	}
+	if config.DKGKeyPassphrase != "" {
+		c.DKGKeyAuth = []byte(config.DKGKeyPassphrase)
+	} else if config.PrivateKey != nil {
+		c.DKGKeyAuth = crypto.Keccak256(crypto.FromECDSA(config.PrivateKey))
+	}
+	return c
+}
+
 func (s *Dexon) Protocols() []p2p.Protocol {
-	return s.protocolManager.SubProtocols
+	if s.lightServer == nil {
+		return s.protocolManager.SubProtocols
+	}
+	return append(s.protocolManager.SubProtocols, s.lightServer.Protocols()...)
 }
 
 func (s *Dexon) APIs() []rpc.API {
@@ -206,6 +260,11 @@ func (s *Dexon) APIs() []rpc.API {
 			Version:   "1.0",
 			Service:   filters.NewPublicFilterAPI(s.APIBackend, false),
 			Public:    true,
+		}, {
+			Namespace: "dexon",
+			Version:   "1.0",
+			Service:   NewPublicDexonAPI(s),
+			Public:    true,
 		}, {
 			Namespace: "admin",
 			Version:   "1.0",
@@ -246,14 +305,22 @@ func (s *Dexon) Start(srvr *p2p.Server) error {
 	// Start the networking layer and the light server if requested
 	s.protocolManager.Start(srvr, maxPeers)
 	s.protocolManager.addSelfRecord()
+	if s.lightServer != nil {
+		s.lightServer.Start()
+	}
+	s.pruner.start()
 	return nil
 }
 
 func (s *Dexon) Stop() error {
 	s.bp.Stop()
 	s.app.Stop()
-	if s.indexer != nil {
-		s.indexer.Stop()
+	s.pruner.stop()
+	if s.lightServer != nil {
+		s.lightServer.Stop()
+	}
+	if s.indexers != nil {
+		s.indexers.Stop()
 	}
 	return nil
 }
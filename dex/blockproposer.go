@@ -102,7 +102,7 @@ func (b *blockProposer) IsProposing() bool {
 }
 
 func (b *blockProposer) initConsensus() *dexCore.Consensus {
-	db := db.NewDatabase(b.dex.chainDb)
+	db := db.NewDatabaseWithConfig(b.dex.chainDb, b.dex.dbConfig)
 	privkey := coreEcdsa.NewPrivateKeyFromECDSA(b.dex.config.PrivateKey)
 	return dexCore.NewConsensus(b.dMoment,
 		b.dex.app, b.dex.governance, db, b.dex.network, privkey, log.Root())
@@ -112,7 +112,7 @@ func (b *blockProposer) syncConsensus() (*dexCore.Consensus, error) {
 	atomic.StoreInt32(&b.syncing, 1)
 	defer atomic.StoreInt32(&b.syncing, 0)
 
-	db := db.NewDatabase(b.dex.chainDb)
+	db := db.NewDatabaseWithConfig(b.dex.chainDb, b.dex.dbConfig)
 	privkey := coreEcdsa.NewPrivateKeyFromECDSA(b.dex.config.PrivateKey)
 	consensusSync := syncer.NewConsensus(b.dMoment, b.dex.app, b.dex.governance,
 		db, b.dex.network, privkey, log.Root())
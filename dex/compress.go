@@ -0,0 +1,75 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"fmt"
+
+	"github.com/dexon-foundation/dexon/p2p"
+	"github.com/dexon-foundation/dexon/rlp"
+	"github.com/golang/snappy"
+)
+
+// isCompressible reports whether the payload of the message is compressed
+// when capSnappy is negotiated.
+func isCompressible(code uint64) bool {
+	return code == LatticeBlockMsg || code == BlockBodiesMsg
+}
+
+// compressed reports whether the payload of the message is compressed on the
+// wire to the peer.
+func (p *peer) compressed(code uint64) bool {
+	return isCompressible(code) && p.HasCapability(capSnappy)
+}
+
+// send sends the message to the peer, the payload is the snappy compressed
+// RLP encoding of data if compression is negotiated.
+func (p *peer) send(code uint64, data interface{}) error {
+	if !p.compressed(code) {
+		return p2p.Send(p.rw, code, data)
+	}
+	payload, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		return err
+	}
+	return p2p.Send(p.rw, code, snappy.Encode(nil, payload))
+}
+
+// decode decodes the payload of a message received from the peer into val,
+// decompressing it first if compression is negotiated.
+func (p *peer) decode(msg p2p.Msg, val interface{}) error {
+	if !p.compressed(msg.Code) {
+		return msg.Decode(val)
+	}
+	var data []byte
+	if err := msg.Decode(&data); err != nil {
+		return err
+	}
+	size, err := snappy.DecodedLen(data)
+	if err != nil {
+		return err
+	}
+	if size > ProtocolMaxMsgSize {
+		return fmt.Errorf("decompressed size %d > %d", size, ProtocolMaxMsgSize)
+	}
+	payload, err := snappy.Decode(nil, data)
+	if err != nil {
+		return err
+	}
+	return rlp.DecodeBytes(payload, val)
+}
@@ -48,6 +48,8 @@ var DefaultConfig = Config{
 		Percentile: 60,
 	},
 	BlockProposerEnabled: false,
+	PeerSetGracePeriod:   30 * time.Second,
+	RateLimit:            DefaultRateLimitConfig,
 	DefaultGasPrice:      big.NewInt(params.GWei),
 	Indexer:              indexer.Config{},
 }
@@ -81,6 +83,11 @@ type Config struct {
 	SyncMode  downloader.SyncMode
 	NoPruning bool
 
+	// RoundPruning only keeps the states at round heights and of the most
+	// recent StateRetention blocks.
+	RoundPruning   bool
+	StateRetention uint64
+
 	// Light client options
 	LightServ  int `toml:",omitempty"` // Maximum percentage of time allowed for serving LES requests
 	LightPeers int `toml:",omitempty"` // Maximum number of LES client peers
@@ -106,6 +113,13 @@ type Config struct {
 	// BlockProposer options
 	BlockProposerEnabled bool
 
+	// PeerSetGracePeriod is how long notary and DKG connections of the
+	// previous round are kept after the round changes.
+	PeerSetGracePeriod time.Duration
+
+	// Per-peer rate limits of consensus messages
+	RateLimit RateLimitConfig
+
 	// Enables tracking of SHA3 preimages in the VM
 	EnablePreimageRecording bool
 
@@ -120,6 +134,20 @@ type Config struct {
 	// Dexon options
 	DMoment int64
 
+	// DKGKeyPassphrase is used to encrypt DKG private keys at rest. The
+	// encryption key is derived from the node key if it is empty.
+	DKGKeyPassphrase string `toml:"-"`
+
+	// DKGKeyRetention is the number of most recent rounds whose DKG private
+	// keys are kept in the database. Zero keeps all of them.
+	DKGKeyRetention uint64
+
+	// CoreBlockRetention is the number of most recent rounds whose core
+	// blocks are kept in the database. Zero keeps all of them. Core blocks
+	// never finalized are always kept, while undelivered confirmed blocks out
+	// of the window are dropped from memory.
+	CoreBlockRetention uint64
+
 	// Indexer config
 	Indexer indexer.Config
 }
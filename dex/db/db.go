@@ -18,23 +18,65 @@
 package db
 
 import (
+	"encoding/json"
+	"errors"
+	"sort"
+
 	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	dexCore "github.com/dexon-foundation/dexon-consensus/core"
 	coreDKG "github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
 	coreDb "github.com/dexon-foundation/dexon-consensus/core/db"
 	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
 
+	"github.com/dexon-foundation/dexon/accounts/keystore"
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/core/rawdb"
 	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/rlp"
 )
 
+var errNoDKGKeyAuth = errors.New("DKG private key is encrypted but no auth is given")
+
+// Config contains the options for storing DKG private keys.
+type Config struct {
+	// DKGKeyAuth is the secret the DKG private key encryption key is derived
+	// from. DKG private keys are stored unencrypted if it is empty.
+	DKGKeyAuth []byte
+
+	// Scrypt parameters used to derive the encryption key.
+	ScryptN int
+	ScryptP int
+
+	// DKGKeyRetention is the number of most recent rounds whose DKG private
+	// keys are kept. Zero disables pruning.
+	DKGKeyRetention uint64
+
+	// CoreBlockRetention is the number of most recent rounds whose core
+	// blocks are kept. Zero disables pruning.
+	CoreBlockRetention uint64
+}
+
+// minCoreBlockRetention is the least number of rounds whose core blocks are
+// kept. Syncing the consensus core walks the compaction chain back through
+// the rounds whose configurations are still in use, and peers pull lattice
+// blocks of the recent rounds only.
+const minCoreBlockRetention = dexCore.ConfigRoundShift + 1
+
 // DB implement dexon-consensus BlockDatabase interface.
 type DB struct {
-	db ethdb.Database
+	db     ethdb.Database
+	config Config
 }
 
 func NewDatabase(db ethdb.Database) *DB {
-	return &DB{db}
+	return &DB{db: db}
+}
+
+// NewDatabaseWithConfig creates a DB which stores DKG private keys according
+// to the given config.
+func NewDatabaseWithConfig(db ethdb.Database, config Config) *DB {
+	return &DB{db: db, config: config}
 }
 
 func (d *DB) HasBlock(hash coreCommon.Hash) bool {
@@ -49,8 +91,23 @@ func (d *DB) GetBlock(hash coreCommon.Hash) (coreTypes.Block, error) {
 	return *block, nil
 }
 
+// GetAllBlocks returns an iterator over all the core blocks in the database,
+// ordered by round, chain and height.
 func (d *DB) GetAllBlocks() (coreDb.BlockIterator, error) {
-	return nil, coreDb.ErrNotImplemented
+	db, ok := d.db.(rawdb.DatabaseIteratee)
+	if !ok {
+		return nil, coreDb.ErrNotImplemented
+	}
+	var blocks blockRefs
+	err := rawdb.IterateCoreBlocks(db, func(block *coreTypes.Block, size int) error {
+		blocks = append(blocks, blockRef{hash: block.Hash, position: block.Position})
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Sort(blocks)
+	return &blockIterator{db: d, blocks: blocks}, nil
 }
 
 func (d *DB) UpdateBlock(block coreTypes.Block) error {
@@ -70,10 +127,17 @@ func (d *DB) PutBlock(block coreTypes.Block) error {
 }
 
 func (d *DB) HasDKGPrivateKey(round uint64) (bool, error) {
+	has, err := rawdb.HasCoreDKGEncryptedPrivateKey(d.db, round)
+	if err != nil || has {
+		return has, err
+	}
 	return rawdb.HasCoreDKGPrivateKey(d.db, round)
 }
 
 func (d *DB) GetDKGPrivateKey(round uint64) (coreDKG.PrivateKey, error) {
+	if data := rawdb.ReadCoreDKGEncryptedPrivateKey(d.db, round); len(data) > 0 {
+		return d.decryptDKGPrivateKey(data)
+	}
 	key := rawdb.ReadCoreDKGPrivateKey(d.db, round)
 	if key == nil {
 		return coreDKG.PrivateKey{}, coreDb.ErrDKGPrivateKeyDoesNotExist
@@ -89,7 +153,183 @@ func (d *DB) PutDKGPrivateKey(round uint64, key coreDKG.PrivateKey) error {
 	if has {
 		return coreDb.ErrDKGPrivateKeyExists
 	}
-	return rawdb.WriteCoreDKGPrivateKey(d.db, round, &key)
+	if len(d.config.DKGKeyAuth) == 0 {
+		err = rawdb.WriteCoreDKGPrivateKey(d.db, round, &key)
+	} else {
+		err = d.writeEncryptedDKGPrivateKey(round, &key)
+	}
+	if err != nil {
+		return err
+	}
+	return d.pruneDKGPrivateKeys(round)
+}
+
+// MigrateDKGPrivateKeys encrypts the plain DKG private keys of rounds up to
+// lastRound and prunes the ones out of the retention window. It returns the
+// number of keys encrypted.
+func (d *DB) MigrateDKGPrivateKeys(lastRound uint64) (int, error) {
+	migrated := 0
+	if len(d.config.DKGKeyAuth) > 0 {
+		for round := rawdb.ReadCoreDKGPrivateKeyPrunedRound(d.db); round <= lastRound; round++ {
+			key := rawdb.ReadCoreDKGPrivateKey(d.db, round)
+			if key == nil {
+				continue
+			}
+			if err := d.writeEncryptedDKGPrivateKey(round, key); err != nil {
+				return migrated, err
+			}
+			if err := rawdb.DeleteCoreDKGPrivateKey(d.db, round); err != nil {
+				return migrated, err
+			}
+			migrated++
+		}
+	}
+	return migrated, d.pruneDKGPrivateKeys(lastRound)
+}
+
+func (d *DB) writeEncryptedDKGPrivateKey(round uint64, key *coreDKG.PrivateKey) error {
+	data, err := rlp.EncodeToBytes(key)
+	if err != nil {
+		return err
+	}
+	cryptoJSON, err := keystore.EncryptDataV3(
+		data, d.config.DKGKeyAuth, d.config.ScryptN, d.config.ScryptP)
+	if err != nil {
+		return err
+	}
+	enc, err := json.Marshal(cryptoJSON)
+	if err != nil {
+		return err
+	}
+	return rawdb.WriteCoreDKGEncryptedPrivateKey(d.db, round, enc)
+}
+
+func (d *DB) decryptDKGPrivateKey(enc []byte) (coreDKG.PrivateKey, error) {
+	var key coreDKG.PrivateKey
+	if len(d.config.DKGKeyAuth) == 0 {
+		return key, errNoDKGKeyAuth
+	}
+	var cryptoJSON keystore.CryptoJSON
+	if err := json.Unmarshal(enc, &cryptoJSON); err != nil {
+		return key, err
+	}
+	data, err := keystore.DecryptDataV3(cryptoJSON, string(d.config.DKGKeyAuth))
+	if err != nil {
+		return key, err
+	}
+	err = rlp.DecodeBytes(data, &key)
+	return key, err
+}
+
+// pruneDKGPrivateKeys deletes the DKG private keys of rounds out of the
+// retention window ending at round.
+func (d *DB) pruneDKGPrivateKeys(round uint64) error {
+	retention := d.config.DKGKeyRetention
+	if retention == 0 || round < retention {
+		return nil
+	}
+	from, to := rawdb.ReadCoreDKGPrivateKeyPrunedRound(d.db), round-retention+1
+	if from >= to {
+		return nil
+	}
+	for r := from; r < to; r++ {
+		if err := rawdb.DeleteCoreDKGPrivateKey(d.db, r); err != nil {
+			return err
+		}
+		if err := rawdb.DeleteCoreDKGEncryptedPrivateKey(d.db, r); err != nil {
+			return err
+		}
+	}
+	log.Debug("Pruned DKG private keys", "from", from, "to", to)
+	return rawdb.WriteCoreDKGPrivateKeyPrunedRound(d.db, to)
+}
+
+// CoreBlockRetentionStart returns the first round of the core block
+// retention window ending at round. It returns zero if nothing is out of the
+// window or pruning is disabled.
+func (d *DB) CoreBlockRetentionStart(round uint64) uint64 {
+	retention := d.config.CoreBlockRetention
+	if retention == 0 {
+		return 0
+	}
+	if retention < minCoreBlockRetention {
+		retention = minCoreBlockRetention
+	}
+	if round < retention {
+		return 0
+	}
+	return round - retention + 1
+}
+
+// PruneCoreBlocks deletes the core blocks of rounds out of the retention
+// window ending at round. Only the blocks already embedded in a canonical
+// block are deleted, as they can be recovered from the DexconMeta of the
+// block header. Blocks never finalized, whose Finalization.Height is zero,
+// are therefore never pruned. It returns the number of blocks deleted and
+// their size.
+func (d *DB) PruneCoreBlocks(round uint64) (int, common.StorageSize, error) {
+	end := d.CoreBlockRetentionStart(round)
+	if end == 0 {
+		return 0, 0, nil
+	}
+	db, ok := d.db.(rawdb.DatabaseIteratee)
+	if !ok {
+		return 0, 0, coreDb.ErrNotImplemented
+	}
+	var (
+		batch  = d.db.NewBatch()
+		pruned int
+		size   common.StorageSize
+	)
+	err := rawdb.IterateCoreBlocks(db, func(block *coreTypes.Block, blockSize int) error {
+		if block.Position.Round >= end || !d.isEmbedded(block) {
+			return nil
+		}
+		if err := rawdb.DeleteCoreBlock(batch, common.Hash(block.Hash)); err != nil {
+			return err
+		}
+		pruned++
+		size += common.StorageSize(blockSize)
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		return nil
+	})
+	if err != nil {
+		return pruned, size, err
+	}
+	if err := batch.Write(); err != nil {
+		return pruned, size, err
+	}
+	if pruned > 0 {
+		log.Debug("Pruned core blocks", "before", end, "count", pruned, "size", size)
+	}
+	return pruned, size, nil
+}
+
+// isEmbedded checks whether the core block is the DexconMeta of the canonical
+// block at its finalization height.
+func (d *DB) isEmbedded(block *coreTypes.Block) bool {
+	height := block.Finalization.Height
+	if height == 0 {
+		return false
+	}
+	hash := rawdb.ReadCanonicalHash(d.db, height)
+	if hash == (common.Hash{}) {
+		return false
+	}
+	header := rawdb.ReadHeader(d.db, hash, height)
+	if header == nil {
+		return false
+	}
+	var embedded coreTypes.Block
+	if err := rlp.DecodeBytes(header.DexconMeta, &embedded); err != nil {
+		return false
+	}
+	return embedded.Hash == block.Hash
 }
 
 func (d *DB) PutCompactionChainTipInfo(hash coreCommon.Hash, height uint64) error {
@@ -105,3 +345,40 @@ func (d *DB) GetCompactionChainTipInfo() (hash coreCommon.Hash, height uint64) {
 }
 
 func (d *DB) Close() error { return nil }
+
+// blockRef references a core block by its hash and position.
+type blockRef struct {
+	hash     coreCommon.Hash
+	position coreTypes.Position
+}
+
+type blockRefs []blockRef
+
+func (b blockRefs) Len() int      { return len(b) }
+func (b blockRefs) Swap(i, j int) { b[i], b[j] = b[j], b[i] }
+func (b blockRefs) Less(i, j int) bool {
+	pi, pj := b[i].position, b[j].position
+	if pi.Round != pj.Round {
+		return pi.Round < pj.Round
+	}
+	if pi.ChainID != pj.ChainID {
+		return pi.ChainID < pj.ChainID
+	}
+	return pi.Height < pj.Height
+}
+
+// blockIterator implements coreDb.BlockIterator, it retrieves the referenced
+// blocks one at a time.
+type blockIterator struct {
+	db     *DB
+	blocks blockRefs
+}
+
+func (it *blockIterator) NextBlock() (coreTypes.Block, error) {
+	if len(it.blocks) == 0 {
+		return coreTypes.Block{}, coreDb.ErrIterationFinished
+	}
+	hash := it.blocks[0].hash
+	it.blocks = it.blocks[1:]
+	return it.db.GetBlock(hash)
+}
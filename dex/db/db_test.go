@@ -0,0 +1,258 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package db
+
+import (
+	"bytes"
+	"math/big"
+	"reflect"
+	"testing"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	coreDKG "github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	coreDb "github.com/dexon-foundation/dexon-consensus/core/db"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/accounts/keystore"
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+func newTestConfig(auth string, retention uint64) Config {
+	return Config{
+		DKGKeyAuth:      []byte(auth),
+		ScryptN:         keystore.LightScryptN,
+		ScryptP:         keystore.LightScryptP,
+		DKGKeyRetention: retention,
+	}
+}
+
+func mustEncodeDKGPrivateKey(t *testing.T, key *coreDKG.PrivateKey) []byte {
+	data, err := rlp.EncodeToBytes(key)
+	if err != nil {
+		t.Fatalf("failed to encode DKG private key: %v", err)
+	}
+	return data
+}
+
+func TestDKGPrivateKeyEncryption(t *testing.T) {
+	memdb := ethdb.NewMemDatabase()
+	d := NewDatabaseWithConfig(memdb, newTestConfig("secret", 0))
+
+	key := coreDKG.NewPrivateKey()
+	if err := d.PutDKGPrivateKey(1, *key); err != nil {
+		t.Fatalf("failed to put DKG private key: %v", err)
+	}
+	if err := d.PutDKGPrivateKey(1, *key); err != coreDb.ErrDKGPrivateKeyExists {
+		t.Errorf("duplicated put error mismatch: have %v, want %v",
+			err, coreDb.ErrDKGPrivateKeyExists)
+	}
+	if rawdb.ReadCoreDKGPrivateKey(memdb, 1) != nil {
+		t.Errorf("DKG private key stored in plain")
+	}
+	enc := rawdb.ReadCoreDKGEncryptedPrivateKey(memdb, 1)
+	if len(enc) == 0 {
+		t.Fatalf("encrypted DKG private key not found")
+	}
+	if bytes.Contains(enc, mustEncodeDKGPrivateKey(t, key)) {
+		t.Errorf("encrypted DKG private key contains plain key")
+	}
+
+	has, err := d.HasDKGPrivateKey(1)
+	if err != nil || !has {
+		t.Errorf("HasDKGPrivateKey mismatch: have (%v, %v), want (true, nil)", has, err)
+	}
+	got, err := d.GetDKGPrivateKey(1)
+	if err != nil {
+		t.Fatalf("failed to get DKG private key: %v", err)
+	}
+	if !bytes.Equal(mustEncodeDKGPrivateKey(t, &got), mustEncodeDKGPrivateKey(t, key)) {
+		t.Errorf("decrypted DKG private key mismatch")
+	}
+
+	if _, err := NewDatabaseWithConfig(memdb, newTestConfig("wrong", 0)).GetDKGPrivateKey(1); err == nil {
+		t.Errorf("decrypted DKG private key with wrong auth")
+	}
+	if _, err := NewDatabase(memdb).GetDKGPrivateKey(1); err != errNoDKGKeyAuth {
+		t.Errorf("error mismatch: have %v, want %v", err, errNoDKGKeyAuth)
+	}
+}
+
+func TestDKGPrivateKeyMigration(t *testing.T) {
+	memdb := ethdb.NewMemDatabase()
+	plain := NewDatabase(memdb)
+
+	keys := make([]*coreDKG.PrivateKey, 5)
+	for i := range keys {
+		keys[i] = coreDKG.NewPrivateKey()
+		if err := plain.PutDKGPrivateKey(uint64(i), *keys[i]); err != nil {
+			t.Fatalf("failed to put DKG private key: %v", err)
+		}
+	}
+
+	d := NewDatabaseWithConfig(memdb, newTestConfig("secret", 3))
+	migrated, err := d.MigrateDKGPrivateKeys(4)
+	if err != nil {
+		t.Fatalf("failed to migrate DKG private keys: %v", err)
+	}
+	if migrated != 5 {
+		t.Errorf("migrated count mismatch: have %d, want 5", migrated)
+	}
+	for round := uint64(0); round < 5; round++ {
+		if rawdb.ReadCoreDKGPrivateKey(memdb, round) != nil {
+			t.Errorf("plain DKG private key of round %d not removed", round)
+		}
+		key, err := d.GetDKGPrivateKey(round)
+		if round < 2 {
+			if err != coreDb.ErrDKGPrivateKeyDoesNotExist {
+				t.Errorf("DKG private key of round %d not pruned", round)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("failed to get DKG private key of round %d: %v", round, err)
+		}
+		if !bytes.Equal(mustEncodeDKGPrivateKey(t, &key), mustEncodeDKGPrivateKey(t, keys[round])) {
+			t.Errorf("DKG private key of round %d mismatch", round)
+		}
+	}
+	if pruned := rawdb.ReadCoreDKGPrivateKeyPrunedRound(memdb); pruned != 2 {
+		t.Errorf("pruned round mismatch: have %d, want 2", pruned)
+	}
+
+	// Putting a new key keeps sliding the retention window.
+	if err := d.PutDKGPrivateKey(5, *coreDKG.NewPrivateKey()); err != nil {
+		t.Fatalf("failed to put DKG private key: %v", err)
+	}
+	if has, _ := d.HasDKGPrivateKey(2); has {
+		t.Errorf("DKG private key of round 2 not pruned")
+	}
+	if has, _ := d.HasDKGPrivateKey(3); !has {
+		t.Errorf("DKG private key of round 3 pruned")
+	}
+}
+
+func TestGetAllBlocks(t *testing.T) {
+	memdb := ethdb.NewMemDatabase()
+	d := NewDatabase(memdb)
+
+	// Blocks are put out of order, along with a DKG private key sharing the
+	// key prefix of core blocks.
+	positions := []coreTypes.Position{
+		{Round: 1, ChainID: 0, Height: 0},
+		{Round: 0, ChainID: 1, Height: 1},
+		{Round: 0, ChainID: 0, Height: 1},
+		{Round: 0, ChainID: 1, Height: 0},
+		{Round: 0, ChainID: 0, Height: 0},
+	}
+	for _, pos := range positions {
+		block := coreTypes.Block{
+			Hash:     coreCommon.NewRandomHash(),
+			Position: pos,
+		}
+		if err := d.PutBlock(block); err != nil {
+			t.Fatalf("failed to put block: %v", err)
+		}
+	}
+	if err := d.PutDKGPrivateKey(1, *coreDKG.NewPrivateKey()); err != nil {
+		t.Fatalf("failed to put DKG private key: %v", err)
+	}
+
+	it, err := d.GetAllBlocks()
+	if err != nil {
+		t.Fatalf("failed to get all blocks: %v", err)
+	}
+	var got []coreTypes.Position
+	for {
+		block, err := it.NextBlock()
+		if err == coreDb.ErrIterationFinished {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to iterate blocks: %v", err)
+		}
+		got = append(got, block.Position)
+	}
+	want := []coreTypes.Position{
+		{Round: 0, ChainID: 0, Height: 0},
+		{Round: 0, ChainID: 0, Height: 1},
+		{Round: 0, ChainID: 1, Height: 0},
+		{Round: 0, ChainID: 1, Height: 1},
+		{Round: 1, ChainID: 0, Height: 0},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("block order mismatch: have %v, want %v", got, want)
+	}
+}
+
+func TestPruneCoreBlocks(t *testing.T) {
+	memdb := ethdb.NewMemDatabase()
+	d := NewDatabaseWithConfig(memdb, Config{CoreBlockRetention: 1})
+
+	// Put a finalized block per round, all but the one of round 1 embedded
+	// in the canonical chain.
+	var blocks []coreTypes.Block
+	for round := uint64(0); round < 5; round++ {
+		block := coreTypes.Block{
+			Hash:         coreCommon.NewRandomHash(),
+			Position:     coreTypes.Position{Round: round},
+			Finalization: coreTypes.FinalizationResult{Height: round + 1},
+		}
+		if err := d.PutBlock(block); err != nil {
+			t.Fatalf("failed to put block: %v", err)
+		}
+		if round != 1 {
+			meta, err := rlp.EncodeToBytes(&block)
+			if err != nil {
+				t.Fatalf("failed to encode block: %v", err)
+			}
+			header := &types.Header{Number: new(big.Int).SetUint64(round + 1), DexconMeta: meta}
+			rawdb.WriteHeader(memdb, header)
+			rawdb.WriteCanonicalHash(memdb, header.Hash(), round+1)
+		}
+		blocks = append(blocks, block)
+	}
+
+	// A block never finalized is kept out of the retention window.
+	unfinalized := coreTypes.Block{Hash: coreCommon.NewRandomHash()}
+	if err := d.PutBlock(unfinalized); err != nil {
+		t.Fatalf("failed to put block: %v", err)
+	}
+
+	// The retention window is at least the minimum retention.
+	if start := d.CoreBlockRetentionStart(4); start != 2 {
+		t.Errorf("retention start mismatch: have %d, want 2", start)
+	}
+	pruned, size, err := d.PruneCoreBlocks(4)
+	if err != nil {
+		t.Fatalf("failed to prune core blocks: %v", err)
+	}
+	if pruned != 1 || size == 0 {
+		t.Errorf("pruned mismatch: have %d blocks of %v, want 1 block", pruned, size)
+	}
+	for round, block := range blocks {
+		if has, want := d.HasBlock(block.Hash), round != 0; has != want {
+			t.Errorf("block of round %d existence mismatch: have %v, want %v", round, has, want)
+		}
+	}
+	if !d.HasBlock(unfinalized.Hash) {
+		t.Error("unfinalized block is pruned")
+	}
+}
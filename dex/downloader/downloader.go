@@ -28,16 +28,12 @@ import (
 	ethereum "github.com/dexon-foundation/dexon"
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/core/rawdb"
-	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/types"
-	"github.com/dexon-foundation/dexon/core/vm"
-	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/event"
 	"github.com/dexon-foundation/dexon/log"
 	"github.com/dexon-foundation/dexon/metrics"
 	"github.com/dexon-foundation/dexon/params"
-	"github.com/dexon-foundation/dexon/trie"
 )
 
 var (
@@ -87,6 +83,7 @@ var (
 	errInvalidBlock            = errors.New("retrieved block is invalid")
 	errInvalidBody             = errors.New("retrieved block body is invalid")
 	errInvalidReceipt          = errors.New("retrieved receipt is invalid")
+	errInvalidGovState         = errors.New("retrieved governance state is invalid")
 	errCancelBlockFetch        = errors.New("block download canceled (requested)")
 	errCancelHeaderFetch       = errors.New("block header download canceled (requested)")
 	errCancelBodyFetch         = errors.New("block body download canceled (requested)")
@@ -334,7 +331,7 @@ func (d *Downloader) Synchronise(id string, head common.Hash, number uint64, mod
 
 	case errTimeout, errBadPeer, errStallingPeer,
 		errEmptyHeaderSet, errPeersUnavailable, errTooOld,
-		errInvalidAncestor, errInvalidChain:
+		errInvalidAncestor, errInvalidChain, errInvalidGovState:
 		log.Warn("Synchronisation failed, dropping peer", "peer", id, "err", err)
 		if d.dropPeer == nil {
 			// The dropPeer method is nil when `--copydb` is used for a local copy.
@@ -470,7 +467,7 @@ func (d *Downloader) syncWithPeer(p *peerConnection, hash common.Hash, number ui
 
 	if d.mode == FastSync || d.mode == LightSync {
 		// fetch gov state
-		govState, err := d.fetchGovState(p, latest.Hash(), latest.Root)
+		govState, err := d.fetchGovState(p, latest)
 		if err != nil {
 			return err
 		}
@@ -638,8 +635,8 @@ func (d *Downloader) fetchHeight(p *peerConnection) (*types.Header, error) {
 }
 
 func (d *Downloader) fetchGovState(p *peerConnection,
-	hash common.Hash, root common.Hash) (*types.GovState, error) {
-	go p.peer.RequestGovStateByHash(hash)
+	header *types.Header) (*types.GovState, error) {
+	go p.peer.RequestGovStateByHash(header.Hash())
 
 	ttl := d.requestTTL()
 	timeout := time.After(ttl)
@@ -652,43 +649,10 @@ func (d *Downloader) fetchGovState(p *peerConnection,
 				log.Debug("Received gov state from incorrect peer", "peer", packet.PeerId())
 				break
 			}
-
-			// TODO(sonic): refactor this.
 			govState := packet.(*govStatePack).govState
-
-			// reconstruct the gov state
-			db := ethdb.NewMemDatabase()
-			for _, value := range govState.Proof {
-				db.Put(crypto.Keccak256(value), value)
-			}
-
-			// proof and state should split
-			// check the state object of governance contract
-			key := crypto.Keccak256(vm.GovernanceContractAddress.Bytes())
-			_, _, err := trie.VerifyProof(root, key, db)
-			if err != nil {
+			if err := verifyGovState(header, govState); err != nil {
 				return nil, err
 			}
-
-			triedb := trie.NewDatabase(db)
-			t, err := trie.New(common.Hash{}, triedb)
-			for _, entry := range govState.Storage {
-				t.TryUpdate(entry[0], entry[1])
-			}
-			err = triedb.Commit(t.Hash(), false)
-			if err != nil {
-				return nil, err
-			}
-
-			statedb, err := state.New(root, state.NewDatabase(db))
-			if err != nil {
-				return nil, err
-			}
-
-			storageTrie := statedb.StorageTrie(vm.GovernanceContractAddress)
-			if storageTrie == nil {
-				return nil, fmt.Errorf("storage not match")
-			}
 			return govState, nil
 		case <-timeout:
 			p.log.Debug("Waiting for head header timed out", "elapsed", ttl)
@@ -1412,7 +1376,6 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, number uint64)
 
 				// In case of header only syncing, validate the chunk immediately
 				if d.mode == FastSync || d.mode == LightSync {
-					// TODO(sonic) update the gov state to make TSigVerify correct
 					// Collect the yet unknown headers to mark them as uncertain
 					unknown := make([]*types.Header, 0, len(headersWithGovState))
 					for _, header := range chunk {
@@ -1421,8 +1384,14 @@ func (d *Downloader) processHeaders(origin uint64, pivot uint64, number uint64)
 						}
 					}
 
+					// Verify the gov states against the state roots of their
+					// headers before storing them for the TSig verification,
+					// the headers are verified right after.
 					for _, header := range chunk {
 						if header.GovState != nil {
+							if err := verifyGovState(header.Header, header.GovState); err != nil {
+								return err
+							}
 							log.Debug("Got gov state, store it", "round", header.Round, "number", header.Number.Uint64())
 							d.gov.StoreState(header.GovState)
 						}
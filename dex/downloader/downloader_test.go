@@ -30,6 +30,7 @@ import (
 	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/types"
 	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/event"
 	"github.com/dexon-foundation/dexon/trie"
@@ -934,6 +935,7 @@ func testBlockHeaderAttackerDropping(t *testing.T, protocol int) {
 		{errPeersUnavailable, true},         // Nobody had the advertised blocks, drop the advertiser
 		{errInvalidAncestor, true},          // Agreed upon ancestor is not acceptable, drop the chain rewriter
 		{errInvalidChain, true},             // Hash chain was detected as invalid, definitely drop
+		{errInvalidGovState, true},          // Governance state was forged, definitely drop
 		{errInvalidBlock, false},            // A bad peer was detected, but not the sync origin
 		{errInvalidBody, false},             // A bad peer was detected, but not the sync origin
 		{errInvalidReceipt, false},          // A bad peer was detected, but not the sync origin
@@ -1297,3 +1299,73 @@ func (ftp *floodingTestPeer) RequestHeadersByNumber(from uint64, count, skip int
 	}
 	return nil
 }
+
+// Tests that governance states with forged storage are rejected during fast
+// sync and the serving peer dropped, whether they are requested directly or
+// attached to the headers.
+func TestForgedGovStateRequested64Fast(t *testing.T) { testForgedGovState(t, 64, FastSync, false) }
+func TestForgedGovStateAttached64Fast(t *testing.T)  { testForgedGovState(t, 64, FastSync, true) }
+
+func testForgedGovState(t *testing.T, protocol int, mode SyncMode, attached bool) {
+	t.Parallel()
+
+	tester := newTester()
+	defer tester.terminate()
+	chain := testChainBase.shorten(MaxHeaderFetch)
+
+	tester.newPeer("attacker", protocol, chain)
+	tester.downloader.peers.peers["attacker"].peer = &forgedGovStatePeer{
+		downloadTesterPeer: tester.peers["attacker"],
+		attached:           attached,
+	}
+	head := chain.headBlock()
+	err := tester.downloader.Synchronise("attacker", head.Hash(), head.NumberU64(), mode)
+	if err != errInvalidGovState {
+		t.Fatalf("synchronisation error mismatch: have %v, want %v", err, errInvalidGovState)
+	}
+	if _, ok := tester.peers["attacker"]; ok {
+		t.Errorf("peer serving forged governance state not dropped")
+	}
+}
+
+// forgedGovStatePeer is a malicious peer serving governance states with
+// forged storage, either on request or attached to the last header of the
+// header batches.
+type forgedGovStatePeer struct {
+	*downloadTesterPeer
+	attached bool // Whether the governance states are attached to the headers
+}
+
+func (p *forgedGovStatePeer) RequestGovStateByHash(hash common.Hash) error {
+	// Serve the honest state on request when forging the attached ones, so
+	// the verification of the attached states is reached.
+	if p.attached {
+		return p.downloadTesterPeer.RequestGovStateByHash(hash)
+	}
+	go p.dl.downloader.DeliverGovState(p.id, forgeGovState(p.chain.govStateByHash(hash)))
+	return nil
+}
+
+func (p *forgedGovStatePeer) RequestHeadersByNumber(origin uint64, amount int, skip int, reverse, withGov bool) error {
+	result := p.chain.headersByNumber(origin, amount, skip)
+	if p.attached && withGov && len(result) > 0 {
+		// Copy the header, the chain is shared by the parallel tests.
+		last := result[len(result)-1]
+		result[len(result)-1] = &types.HeaderWithGovState{
+			Header:   types.CopyHeader(last.Header),
+			GovState: forgeGovState(p.chain.govStateByHash(last.Hash())),
+		}
+	}
+	go p.dl.downloader.DeliverHeaders(p.id, result)
+	return nil
+}
+
+// forgeGovState adds a storage entry to the governance state, which no longer
+// matches the storage root of the governance account.
+func forgeGovState(govState *types.GovState) *types.GovState {
+	forged := *govState
+	forged.Storage = append([][2][]byte{{
+		crypto.Keccak256([]byte("forged")), {0x01},
+	}}, govState.Storage...)
+	return &forged
+}
@@ -8,12 +8,29 @@ import (
 	"github.com/dexon-foundation/dexon/core"
 	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/verifier"
 	"github.com/dexon-foundation/dexon/crypto"
 	"github.com/dexon-foundation/dexon/ethdb"
 	"github.com/dexon-foundation/dexon/log"
 	"github.com/dexon-foundation/dexon/trie"
 )
 
+// verifyGovState verifies a governance state received from a peer is the state
+// of the governance contract at the header, by the Merkle proof of the
+// governance account against the state root of the header.
+func verifyGovState(header *types.Header, s *types.GovState) error {
+	if s == nil {
+		return errInvalidGovState
+	}
+	if err := verifier.VerifyGovState(header, s); err != nil {
+		log.Debug("Invalid governance state", "number", header.Number,
+			"hash", header.Hash(), "err", err)
+		govStateInvalidMeter.Mark(1)
+		return errInvalidGovState
+	}
+	return nil
+}
+
 // governanceDB is backed by memory db for fast sync.
 // it implements core.GovernanceStateDB
 type governanceStateDB struct {
@@ -31,6 +31,8 @@ var (
 	govStateInMeter = metrics.NewRegisteredMeter("dex/downloader/govStates/in", nil)
 	// govStateReqTimer     = metrics.NewRegisteredTimer("dex/downloader/govStates/req", nil)
 	govStateDropMeter = metrics.NewRegisteredMeter("dex/downloader/govStates/drop", nil)
+	// govStateInvalidMeter counts the governance states failing verification.
+	govStateInvalidMeter = metrics.NewRegisteredMeter("dex/downloader/govStates/invalid", nil)
 	// govStateTimeoutMeter = metrics.NewRegisteredMeter("dex/downloader/govStates/timeout", nil)
 
 	bodyInMeter      = metrics.NewRegisteredMeter("dex/downloader/bodies/in", nil)
@@ -47,6 +47,7 @@ import (
 	coreCrypto "github.com/dexon-foundation/dexon-consensus/core/crypto"
 	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
 	dkgTypes "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	coreUtils "github.com/dexon-foundation/dexon-consensus/core/utils"
 
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/consensus"
@@ -139,6 +140,13 @@ type ProtocolManager struct {
 	isBlockProposer bool
 	app             dexconApp
 
+	// peerSetGracePeriod is how long connections of the previous round are
+	// kept after the round changes.
+	peerSetGracePeriod time.Duration
+
+	// rateLimit is the per-peer rate limits of consensus messages.
+	rateLimit RateLimitConfig
+
 	finalizedBlockCh  chan core.NewFinalizedBlockEvent
 	finalizedBlockSub event.Subscription
 }
@@ -287,6 +295,7 @@ func (pm *ProtocolManager) Start(srvr p2pServer, maxPeers int) {
 	pm.chainHeadCh = make(chan core.ChainHeadEvent)
 	pm.chainHeadSub = pm.blockchain.SubscribeChainHeadEvent(pm.chainHeadCh)
 	go pm.peerSetLoop()
+	go pm.peerScoreLoop()
 
 	// start sync handlers
 	go pm.syncer()
@@ -329,7 +338,9 @@ func (pm *ProtocolManager) ReceiveChan() <-chan interface{} {
 }
 
 func (pm *ProtocolManager) newPeer(pv int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
-	return newPeer(pv, p, newMeteredMsgWriter(rw))
+	peer := newPeer(pv, p, newMeteredMsgWriter(rw))
+	peer.rateLimiter = newMsgRateLimiter(pm.rateLimit)
+	return peer
 }
 
 // handle is the callback invoked to manage the life cycle of an eth peer. When
@@ -393,6 +404,16 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	}
 	defer msg.Discard()
 
+	if allowed, abusive := p.rateLimiter.allow(msg.Code); !allowed {
+		rateLimitedMsgMeter.Mark(1)
+		if abusive {
+			abusivePeerMeter.Mark(1)
+			return errResp(ErrRateLimitExceeded, "msg %v", msg.Code)
+		}
+		p.Log().Trace("Dropping rate limited message", "code", msg.Code)
+		return nil
+	}
+
 	// Handle the message depending on its contents
 	switch {
 	case msg.Code == StatusMsg:
@@ -585,7 +606,7 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	case msg.Code == BlockBodiesMsg:
 		// A batch of block bodies arrived to one of our previous requests
 		var request blockBodiesData
-		if err := msg.Decode(&request); err != nil {
+		if err := p.decode(msg, &request); err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
 		// Deliver them all to the downloader for queuing
@@ -785,9 +806,15 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 			break
 		}
 		var block coreTypes.Block
-		if err := msg.Decode(&block); err != nil {
+		if err := p.decode(msg, &block); err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
+		if err := coreUtils.VerifyBlockSignature(&block); err != nil {
+			return pm.invalidSignature(p, msg, err)
+		}
+		h := rlpHash(&block)
+		p.score.deliverBlock(block.Hash, p.knownLatticeBlocks.Contains(h))
+		p.knownLatticeBlocks.Add(h)
 		pm.cache.addBlock(&block)
 		pm.receiveCh <- &block
 	case msg.Code == VoteMsg:
@@ -798,10 +825,37 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&vote); err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
-		if vote.Type >= coreTypes.VotePreCom {
-			pm.cache.addVote(&vote)
+		return pm.handleVote(p, msg, &vote)
+	case msg.Code == VotesMsg:
+		if !pm.isBlockProposer {
+			break
+		}
+		var votes []*coreTypes.Vote
+		if err := msg.Decode(&votes); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		if len(votes) > maxVotesBatch {
+			return errResp(ErrMsgTooLarge, "%d votes > %d", len(votes), maxVotesBatch)
+		}
+		for i, vote := range votes {
+			if vote == nil {
+				return errResp(ErrDecode, "vote %d is nil", i)
+			}
+			// The first vote is accounted when the message is received.
+			if i > 0 {
+				if allowed, abusive := p.rateLimiter.allow(msg.Code); !allowed {
+					rateLimitedMsgMeter.Mark(1)
+					if abusive {
+						abusivePeerMeter.Mark(1)
+						return errResp(ErrRateLimitExceeded, "msg %v", msg.Code)
+					}
+					break
+				}
+			}
+			if err := pm.handleVote(p, msg, vote); err != nil {
+				return err
+			}
 		}
-		pm.receiveCh <- &vote
 	case msg.Code == AgreementMsg:
 		if !pm.isBlockProposer {
 			break
@@ -831,6 +885,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&ps); err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
+		if ok, err := coreUtils.VerifyDKGPrivateShareSignature(&ps); !ok {
+			return pm.invalidSignature(p, msg, err)
+		}
 		pm.receiveCh <- &ps
 	case msg.Code == DKGPartialSignatureMsg:
 		if !pm.isBlockProposer {
@@ -841,6 +898,9 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 		if err := msg.Decode(&psig); err != nil {
 			return errResp(ErrDecode, "msg %v: %v", msg, err)
 		}
+		if ok, err := coreUtils.VerifyDKGPartialSignatureSignature(&psig); !ok {
+			return pm.invalidSignature(p, msg, err)
+		}
 		pm.receiveCh <- &psig
 	case msg.Code == PullBlocksMsg:
 		if !pm.isBlockProposer {
@@ -912,6 +972,37 @@ func (pm *ProtocolManager) handleMsg(p *peer) error {
 	return nil
 }
 
+// handleVote verifies a vote received from the peer and passes it to the
+// consensus core.
+func (pm *ProtocolManager) handleVote(p *peer, msg p2p.Msg, vote *coreTypes.Vote) error {
+	if vote.Type >= coreTypes.MaxVoteType {
+		p.score.markInvalid()
+		return nil
+	}
+	if ok, err := coreUtils.VerifyVoteSignature(vote); !ok {
+		return pm.invalidSignature(p, msg, err)
+	}
+	h := rlpHash(vote)
+	p.score.deliverVote(vote.Position, p.knownVotes.Contains(h))
+	p.knownVotes.Add(h)
+	if vote.Type >= coreTypes.VotePreCom {
+		pm.cache.addVote(vote)
+	}
+	pm.receiveCh <- vote
+	return nil
+}
+
+// invalidSignature penalizes a peer sending a consensus message with an
+// invalid signature. The returned error disconnects the peer.
+func (pm *ProtocolManager) invalidSignature(p *peer, msg p2p.Msg, err error) error {
+	p.score.markInvalid()
+	invalidSignatureMeter.Mark(1)
+	if err == nil {
+		err = errors.New("signer mismatch")
+	}
+	return errResp(ErrInvalidSignature, "msg %v: %v", msg, err)
+}
+
 // BroadcastBlock will either propagate a block to a subset of it's peers, or
 // will only announce it's availability (depending what's requested).
 func (pm *ProtocolManager) BroadcastBlock(block *types.Block, propagate bool) {
@@ -981,7 +1072,8 @@ func (pm *ProtocolManager) BroadcastRecords(records []*enr.Record) {
 // to reduce traffic
 func (pm *ProtocolManager) BroadcastLatticeBlock(block *coreTypes.Block) {
 	pm.cache.addBlock(block)
-	for _, peer := range pm.peers.PeersWithoutLatticeBlock(rlpHash(block)) {
+	peers := pm.peers.PeersWithoutLatticeBlock(rlpHash(block))
+	for _, peer := range sortPeersByScore(peers) {
 		peer.AsyncSendLatticeBlock(block)
 	}
 }
@@ -997,7 +1089,7 @@ func (pm *ProtocolManager) BroadcastVote(vote *coreTypes.Vote) {
 		round:   vote.Position.Round,
 	}
 	h := rlpHash(vote)
-	for _, peer := range pm.peers.PeersWithLabel(label) {
+	for _, peer := range sortPeersByScore(pm.peers.PeersWithLabel(label)) {
 		if !peer.knownVotes.Contains(h) {
 			peer.AsyncSendVote(vote)
 		}
@@ -1083,7 +1175,7 @@ func (pm *ProtocolManager) BroadcastDKGPartialSignature(
 func (pm *ProtocolManager) BroadcastPullBlocks(
 	hashes coreCommon.Hashes) {
 	// TODO(jimmy-dexon): pull from notary set only.
-	for idx, peer := range pm.peers.Peers() {
+	for idx, peer := range sortPeersByScore(pm.peers.Peers()) {
 		if idx >= maxPullPeers {
 			break
 		}
@@ -1098,7 +1190,7 @@ func (pm *ProtocolManager) BroadcastPullVotes(
 		chainID: pos.ChainID,
 		round:   pos.Round,
 	}
-	for idx, peer := range pm.peers.PeersWithLabel(label) {
+	for idx, peer := range sortPeersByScore(pm.peers.PeersWithLabel(label)) {
 		if idx >= maxPullPeers {
 			break
 		}
@@ -1109,7 +1201,7 @@ func (pm *ProtocolManager) BroadcastPullVotes(
 func (pm *ProtocolManager) BroadcastPullRandomness(
 	hashes coreCommon.Hashes) {
 	// TODO(jimmy-dexon): pull from dkg set only.
-	for idx, peer := range pm.peers.Peers() {
+	for idx, peer := range sortPeersByScore(pm.peers.Peers()) {
 		if idx >= maxPullPeers {
 			break
 		}
@@ -1172,44 +1264,62 @@ func (pm *ProtocolManager) recordBroadcastLoop() {
 	}
 }
 
-// a loop keep building and maintaining peers in notary set.
-// TODO: finish this
+// a loop keep building and maintaining peers in notary set and dkg set.
+// Connections of the next round are built as soon as its CRS is known, and
+// connections of the previous round are kept for peerSetGracePeriod after
+// the round changes.
 func (pm *ProtocolManager) peerSetLoop() {
 	log.Debug("start peer set loop")
-	round := pm.gov.LenCRS() - 1
-	log.Trace("first len crs", "len", round+1, "round", round)
+	round := pm.blockchain.CurrentBlock().Round()
 	if round >= 1 {
 		pm.peers.BuildConnection(round - 1)
 	}
 	pm.peers.BuildConnection(round)
+	pm.buildNextRoundConnection(round)
 
+	var (
+		graceRound uint64
+		graceTimer <-chan time.Time
+	)
 	for {
 		select {
-		case <-pm.chainHeadCh:
+		case ev := <-pm.chainHeadCh:
 			if !pm.isBlockProposer {
 				break
 			}
 
-			newRound := pm.gov.LenCRS() - 1
-			log.Trace("new round", "round", newRound)
+			newRound := ev.Block.Round()
 			if newRound == round {
+				pm.buildNextRoundConnection(round)
 				break
 			}
-			if newRound == round+1 {
-				pm.peers.BuildConnection(newRound)
-				if round >= 1 {
-					pm.peers.ForgetConnection(round - 1)
-				}
-			} else {
-				// just forget all network connection and rebuild.
-				pm.peers.ForgetConnection(round)
-
+			log.Debug("Peer set round changed", "from", round, "to", newRound)
+			if newRound < round {
+				// Chain rewound, just forget all network connection and rebuild.
+				pm.peers.ForgetConnection(round + 1)
+				graceTimer = nil
 				if newRound >= 1 {
 					pm.peers.BuildConnection(newRound - 1)
 				}
-				pm.peers.BuildConnection(newRound)
+			} else if newRound >= 2 {
+				pm.peers.ForgetConnection(newRound - 2)
+			}
+			pm.peers.BuildConnection(newRound)
+			pm.buildNextRoundConnection(newRound)
+
+			if newRound > round && newRound >= 1 {
+				if pm.peerSetGracePeriod > 0 {
+					graceRound = newRound - 1
+					graceTimer = time.After(pm.peerSetGracePeriod)
+				} else {
+					pm.peers.ForgetConnection(newRound - 1)
+				}
 			}
 			round = newRound
+		case <-graceTimer:
+			log.Debug("Peer set grace period expired", "round", graceRound)
+			pm.peers.ForgetConnection(graceRound)
+			graceTimer = nil
 		case <-time.After(5 * time.Second):
 			pm.peers.lock.Lock()
 			pm.peers.dumpPeerLabel("ticker")
@@ -1220,6 +1330,38 @@ func (pm *ProtocolManager) peerSetLoop() {
 	}
 }
 
+// peerScoreLoop periodically evaluates the health of peers, dropping the
+// misbehaving notary and DKG peers so that they are redialed.
+func (pm *ProtocolManager) peerScoreLoop() {
+	ticker := time.NewTicker(scoreInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			for _, p := range pm.peers.Peers() {
+				p.score.expire(now)
+				if score := p.score.score(); score < minPeerScore && pm.peers.HasLabel(p.id) {
+					p.Log().Debug("Dropping unhealthy peer", "score", score)
+					pm.removePeer(p.id)
+					continue
+				}
+				p.score.decay()
+			}
+		case <-pm.quitSync:
+			return
+		}
+	}
+}
+
+// buildNextRoundConnection pre-builds the connections of round+1 if its CRS
+// (and therefore its notary and DKG sets) is already known.
+func (pm *ProtocolManager) buildNextRoundConnection(round uint64) {
+	if pm.gov.LenCRS() > round+1 {
+		pm.peers.BuildConnection(round + 1)
+	}
+}
+
 // NodeInfo represents a short summary of the Ethereum sub-protocol metadata
 // known about the host peer.
 type NodeInfo struct {
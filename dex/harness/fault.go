@@ -0,0 +1,133 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package harness
+
+import (
+	"bytes"
+	"io/ioutil"
+	"time"
+
+	"github.com/dexon-foundation/dexon/dex"
+	"github.com/dexon-foundation/dexon/p2p"
+)
+
+// Fault is what happens to a message.
+type Fault struct {
+	Drop  bool          // Drop the message
+	Delay time.Duration // Delay the message, ignored if dropped
+}
+
+// FaultFunc decides the fault of a message of the code sent by the node
+// from to the node to, by their indices.
+type FaultFunc func(from, to int, code uint64) Fault
+
+// SetFault sets the fault injector, nil to deliver all messages.
+func (n *Network) SetFault(fault FaultFunc) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.fault = fault
+}
+
+// Partition splits the nodes into the groups, messages between nodes of
+// different groups are dropped. Nodes not in any group are isolated.
+func (n *Network) Partition(groups ...[]int) {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.groups = make(map[int]int)
+	for g, group := range groups {
+		for _, i := range group {
+			n.groups[i] = g
+		}
+	}
+}
+
+// Heal removes the partition.
+func (n *Network) Heal() {
+	n.lock.Lock()
+	defer n.lock.Unlock()
+	n.groups = nil
+}
+
+func (n *Network) faultOf(from, to int, code uint64) Fault {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	if n.groups != nil {
+		g1, ok1 := n.groups[from]
+		g2, ok2 := n.groups[to]
+		if !ok1 || !ok2 || g1 != g2 {
+			return Fault{Drop: true}
+		}
+	}
+	if n.fault == nil {
+		return Fault{}
+	}
+	return n.fault(from, to, code)
+}
+
+// service is the dex service with the messages of its protocols passing
+// through the fault injector.
+type service struct {
+	*dex.Dexon
+	net   *Network
+	index int
+}
+
+// Protocols implements node.Service, wrapping the protocols of dex.
+func (s *service) Protocols() []p2p.Protocol {
+	protocols := append([]p2p.Protocol(nil), s.Dexon.Protocols()...)
+	for i := range protocols {
+		run := protocols[i].Run
+		protocols[i].Run = func(peer *p2p.Peer, rw p2p.MsgReadWriter) error {
+			to, ok := s.net.ids[peer.ID()]
+			if !ok {
+				return run(peer, rw)
+			}
+			return run(peer, &faultyRW{MsgReadWriter: rw, s: s, to: to})
+		}
+	}
+	return protocols
+}
+
+// faultyRW applies the faults to the messages written.
+type faultyRW struct {
+	p2p.MsgReadWriter
+	s  *service
+	to int
+}
+
+func (rw *faultyRW) WriteMsg(msg p2p.Msg) error {
+	fault := rw.s.net.faultOf(rw.s.index, rw.to, msg.Code)
+	switch {
+	case fault.Drop:
+		return msg.Discard()
+	case fault.Delay > 0:
+		payload, err := ioutil.ReadAll(msg.Payload)
+		if err != nil {
+			return err
+		}
+		time.AfterFunc(fault.Delay, func() {
+			rw.MsgReadWriter.WriteMsg(p2p.Msg{
+				Code:    msg.Code,
+				Size:    uint32(len(payload)),
+				Payload: bytes.NewReader(payload),
+			})
+		})
+		return nil
+	}
+	return rw.MsgReadWriter.WriteMsg(msg)
+}
@@ -0,0 +1,366 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package harness runs a DEXON network of in-process full nodes for
+// integration tests. The nodes share a generated Dexcon genesis staking all
+// of them, connect to each other over the loopback interface and propose
+// blocks from a common dMoment, so rounds advance and DKG runs as on a real
+// network. Messages of the dex protocols pass through a fault injector,
+// letting tests drop or delay them, partition the nodes or kill nodes.
+package harness
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"errors"
+	"fmt"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/dex"
+	"github.com/dexon-foundation/dexon/node"
+	"github.com/dexon-foundation/dexon/p2p"
+	"github.com/dexon-foundation/dexon/p2p/enode"
+	"github.com/dexon-foundation/dexon/params"
+)
+
+// Config is the configuration of a harness network.
+type Config struct {
+	Nodes     int    // Number of nodes, all of them in the notary and DKG sets
+	NumChains uint32 // Number of lattice chains
+
+	RoundInterval    time.Duration
+	LambdaBA         time.Duration
+	LambdaDKG        time.Duration
+	MinBlockInterval time.Duration
+
+	// DMomentDelay is the delay between Start and the start of the
+	// consensus core, leaving time for the nodes to connect.
+	DMomentDelay time.Duration
+}
+
+// DefaultConfig is a network of four nodes with rounds short enough for
+// tests to observe round changes with DKG.
+var DefaultConfig = Config{
+	Nodes:            4,
+	NumChains:        4,
+	RoundInterval:    60 * time.Second,
+	LambdaBA:         250 * time.Millisecond,
+	LambdaDKG:        1000 * time.Millisecond,
+	MinBlockInterval: 500 * time.Millisecond,
+	DMomentDelay:     5 * time.Second,
+}
+
+// networkID is the network ID of the harness nodes.
+const networkID = 1337
+
+var (
+	errTooFewNodes = errors.New("a network needs at least four nodes for DKG")
+	errNotStarted  = errors.New("network not started")
+	errNoNodeAlive = errors.New("no node alive")
+)
+
+// Node is a full node of the network.
+type Node struct {
+	Index int
+	Key   *ecdsa.PrivateKey
+
+	stack *node.Node
+	dex   *dex.Dexon
+	alive bool
+}
+
+// ID returns the node ID of the node.
+func (n *Node) ID() enode.ID {
+	return enode.PubkeyToIDV4(&n.Key.PublicKey)
+}
+
+// Dexon returns the dex service of the node.
+func (n *Node) Dexon() *dex.Dexon {
+	return n.dex
+}
+
+// BlockChain returns the chain of the node.
+func (n *Node) BlockChain() *core.BlockChain {
+	return n.dex.BlockChain()
+}
+
+// Network is a network of in-process full nodes.
+type Network struct {
+	config  Config
+	genesis *core.Genesis
+	nodes   []*Node
+	ids     map[enode.ID]int
+
+	lock    sync.RWMutex
+	fault   FaultFunc
+	groups  map[int]int // Partition group of each node, nil if not partitioned
+	started bool
+}
+
+// New creates a network of the config and its genesis, the nodes start on
+// Start.
+func New(config Config) (*Network, error) {
+	if config.Nodes < 4 {
+		return nil, errTooFewNodes
+	}
+	n := &Network{
+		config: config,
+		ids:    make(map[enode.ID]int),
+	}
+	for i := 0; i < config.Nodes; i++ {
+		key, err := crypto.GenerateKey()
+		if err != nil {
+			return nil, err
+		}
+		nd := &Node{Index: i, Key: key}
+		n.nodes = append(n.nodes, nd)
+		n.ids[nd.ID()] = i
+	}
+	genesis, err := n.makeGenesis()
+	if err != nil {
+		return nil, err
+	}
+	n.genesis = genesis
+	return n, nil
+}
+
+// makeGenesis creates a genesis staking all nodes.
+func (n *Network) makeGenesis() (*core.Genesis, error) {
+	ether := big.NewInt(1e18)
+
+	dexcon := *params.TestnetChainConfig.Dexcon
+	dexcon.Owner = crypto.PubkeyToAddress(n.nodes[0].Key.PublicKey)
+	dexcon.MinStake = new(big.Int).Set(ether)
+	dexcon.NumChains = n.config.NumChains
+	dexcon.NotarySetSize = uint32(len(n.nodes))
+	dexcon.DKGSetSize = uint32(len(n.nodes))
+	dexcon.RoundInterval = uint64(n.config.RoundInterval / time.Millisecond)
+	dexcon.LambdaBA = uint64(n.config.LambdaBA / time.Millisecond)
+	dexcon.LambdaDKG = uint64(n.config.LambdaDKG / time.Millisecond)
+	dexcon.MinBlockInterval = uint64(n.config.MinBlockInterval / time.Millisecond)
+
+	config := *params.TestnetChainConfig
+	config.ChainID = big.NewInt(networkID)
+	config.Dexcon = &dexcon
+
+	nodes := make([]core.DexconNode, len(n.nodes))
+	for i, nd := range n.nodes {
+		nodes[i] = core.DexconNode{
+			PublicKey: &nd.Key.PublicKey,
+			Staked:    dexcon.MinStake,
+			Balance:   new(big.Int).Mul(ether, big.NewInt(1e6)),
+			Info:      core.NodeInfo{Name: fmt.Sprintf("node%d", nd.Index)},
+		}
+	}
+	return core.NewDexconGenesis(&config, nodes)
+}
+
+// Genesis returns the genesis of the network.
+func (n *Network) Genesis() *core.Genesis {
+	return n.genesis
+}
+
+// Nodes returns the nodes of the network.
+func (n *Network) Nodes() []*Node {
+	return n.nodes
+}
+
+// Node returns the i-th node.
+func (n *Network) Node(i int) *Node {
+	return n.nodes[i]
+}
+
+// Start starts the nodes, connects them to each other and starts proposing
+// blocks from a dMoment DMomentDelay after now.
+func (n *Network) Start() error {
+	dMoment := time.Now().Add(n.config.DMomentDelay).Unix()
+	for _, nd := range n.nodes {
+		if err := n.startNode(nd, dMoment); err != nil {
+			n.Stop()
+			return err
+		}
+	}
+	for i, nd := range n.nodes {
+		for _, peer := range n.nodes[i+1:] {
+			nd.stack.Server().AddPeer(peer.stack.Server().Self())
+		}
+	}
+	for _, nd := range n.nodes {
+		if err := nd.dex.StartProposing(); err != nil {
+			n.Stop()
+			return err
+		}
+	}
+	n.lock.Lock()
+	n.started = true
+	n.lock.Unlock()
+	return nil
+}
+
+func (n *Network) startNode(nd *Node, dMoment int64) error {
+	stack, err := node.New(&node.Config{
+		Name:  fmt.Sprintf("node%d", nd.Index),
+		NoUSB: true,
+		P2P: p2p.Config{
+			PrivateKey:  nd.Key,
+			MaxPeers:    len(n.nodes) * 2,
+			ListenAddr:  "127.0.0.1:0",
+			NoDiscovery: true,
+		},
+	})
+	if err != nil {
+		return err
+	}
+	config := dex.DefaultConfig
+	config.Genesis = n.genesis
+	config.NetworkId = networkID
+	config.BlockProposerEnabled = true
+	config.DMoment = dMoment
+	config.PrivateKey = nd.Key
+	config.TxPool.Journal = ""
+	err = stack.Register(func(ctx *node.ServiceContext) (node.Service, error) {
+		d, err := dex.New(ctx, &config)
+		if err != nil {
+			return nil, err
+		}
+		nd.dex = d
+		return &service{Dexon: d, net: n, index: nd.Index}, nil
+	})
+	if err != nil {
+		return err
+	}
+	if err := stack.Start(); err != nil {
+		return err
+	}
+	nd.stack = stack
+	nd.alive = true
+	return nil
+}
+
+// Stop stops all nodes alive.
+func (n *Network) Stop() {
+	for _, nd := range n.nodes {
+		n.Kill(nd.Index)
+	}
+}
+
+// Kill stops the i-th node.
+func (n *Network) Kill(i int) error {
+	nd := n.nodes[i]
+	n.lock.Lock()
+	alive := nd.alive
+	nd.alive = false
+	n.lock.Unlock()
+	if !alive {
+		return nil
+	}
+	nd.dex.StopProposing()
+	return nd.stack.Stop()
+}
+
+// Alive returns the nodes not killed.
+func (n *Network) Alive() []*Node {
+	n.lock.RLock()
+	defer n.lock.RUnlock()
+	var alive []*Node
+	for _, nd := range n.nodes {
+		if nd.alive {
+			alive = append(alive, nd)
+		}
+	}
+	return alive
+}
+
+// WaitRound waits until every node alive has a block of the round.
+func (n *Network) WaitRound(ctx context.Context, round uint64) error {
+	return n.wait(ctx, func(nd *Node) bool {
+		return nd.BlockChain().CurrentBlock().Round() >= round
+	})
+}
+
+// WaitHeight waits until every node alive has a block of the height.
+func (n *Network) WaitHeight(ctx context.Context, height uint64) error {
+	return n.wait(ctx, func(nd *Node) bool {
+		return nd.BlockChain().CurrentBlock().NumberU64() >= height
+	})
+}
+
+func (n *Network) wait(ctx context.Context, done func(*Node) bool) error {
+	n.lock.RLock()
+	started := n.started
+	n.lock.RUnlock()
+	if !started {
+		return errNotStarted
+	}
+	ticker := time.NewTicker(100 * time.Millisecond)
+	defer ticker.Stop()
+	for {
+		finished := true
+		for _, nd := range n.Alive() {
+			if !done(nd) {
+				finished = false
+				break
+			}
+		}
+		if finished {
+			return nil
+		}
+		select {
+		case <-ticker.C:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// CheckConsistency checks the nodes alive delivered the same blocks, up to
+// the lowest head among them.
+func (n *Network) CheckConsistency() error {
+	alive := n.Alive()
+	if len(alive) == 0 {
+		return errNoNodeAlive
+	}
+	height := alive[0].BlockChain().CurrentBlock().NumberU64()
+	for _, nd := range alive[1:] {
+		if h := nd.BlockChain().CurrentBlock().NumberU64(); h < height {
+			height = h
+		}
+	}
+	for number := uint64(0); number <= height; number++ {
+		var want *types.Block
+		for _, nd := range alive {
+			block := nd.BlockChain().GetBlockByNumber(number)
+			if block == nil {
+				return fmt.Errorf("node %d: block #%d missing", nd.Index, number)
+			}
+			if want == nil {
+				want = block
+				continue
+			}
+			if block.Hash() != want.Hash() {
+				return fmt.Errorf("block #%d mismatch: node %d has %x, node %d has %x",
+					number, alive[0].Index, want.Hash(), nd.Index, block.Hash())
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,101 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package harness
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+func TestNetwork(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping network test in short mode")
+	}
+	n, err := New(DefaultConfig)
+	if err != nil {
+		t.Fatalf("failed to create network: %v", err)
+	}
+	if err := n.Start(); err != nil {
+		t.Fatalf("failed to start network: %v", err)
+	}
+	defer n.Stop()
+
+	// Round 1 blocks carry the randomness of the DKG set of round 1.
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Minute)
+	defer cancel()
+	if err := n.WaitRound(ctx, 1); err != nil {
+		t.Fatalf("failed to reach round 1: %v", err)
+	}
+	head := n.Node(0).BlockChain().CurrentBlock()
+	if len(head.Randomness()) == 0 {
+		t.Errorf("block #%d of round %d has no randomness", head.NumberU64(), head.Round())
+	}
+	if err := n.CheckConsistency(); err != nil {
+		t.Fatalf("inconsistent chains: %v", err)
+	}
+
+	// The network tolerates a faulty node out of four.
+	if err := n.Kill(3); err != nil {
+		t.Fatalf("failed to kill node: %v", err)
+	}
+	height := n.Node(0).BlockChain().CurrentBlock().NumberU64()
+	ctx, cancel = context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+	if err := n.WaitHeight(ctx, height+10); err != nil {
+		t.Fatalf("no progress with a node killed: %v", err)
+	}
+	if err := n.CheckConsistency(); err != nil {
+		t.Fatalf("inconsistent chains: %v", err)
+	}
+}
+
+func TestFaultOf(t *testing.T) {
+	n := &Network{}
+	if fault := n.faultOf(0, 1, 0); fault.Drop || fault.Delay != 0 {
+		t.Errorf("fault without injector: %+v", fault)
+	}
+
+	n.SetFault(func(from, to int, code uint64) Fault {
+		return Fault{Delay: time.Duration(code)}
+	})
+	if fault := n.faultOf(0, 1, 5); fault.Delay != 5 {
+		t.Errorf("delay mismatch: got %v, want 5", fault.Delay)
+	}
+
+	n.Partition([]int{0, 1}, []int{2})
+	tests := []struct {
+		from, to int
+		drop     bool
+	}{
+		{0, 1, false},
+		{1, 0, false},
+		{0, 2, true},
+		{2, 1, true},
+		{3, 0, true}, // Not in any group
+	}
+	for _, tt := range tests {
+		if fault := n.faultOf(tt.from, tt.to, 0); fault.Drop != tt.drop {
+			t.Errorf("%d -> %d: drop mismatch: got %v, want %v", tt.from, tt.to, fault.Drop, tt.drop)
+		}
+	}
+	n.Heal()
+	if fault := n.faultOf(0, 2, 0); fault.Drop {
+		t.Errorf("message dropped after heal")
+	}
+}
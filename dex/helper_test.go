@@ -290,6 +290,9 @@ func (p *testPeer) handshake(t *testing.T, number uint64, head common.Hash, gene
 		CurrentBlock:    head,
 		GenesisBlock:    genesis,
 	}
+	if p.version >= dex65 {
+		msg.Capabilities = localCapabilities
+	}
 	if err := p2p.ExpectMsg(p.app, StatusMsg, msg); err != nil {
 		t.Fatalf("status recv: %v", err)
 	}
@@ -74,6 +74,11 @@ var (
 	miscInTrafficMeter                     = metrics.NewRegisteredMeter("dex/misc/in/traffic", nil)
 	miscOutPacketsMeter                    = metrics.NewRegisteredMeter("dex/misc/out/packets", nil)
 	miscOutTrafficMeter                    = metrics.NewRegisteredMeter("dex/misc/out/traffic", nil)
+	rateLimitedMsgMeter                    = metrics.NewRegisteredMeter("dex/ratelimit/dropped", nil)
+	abusivePeerMeter                       = metrics.NewRegisteredMeter("dex/ratelimit/disconnected", nil)
+	invalidSignatureMeter                  = metrics.NewRegisteredMeter("dex/invalid/signatures", nil)
+	prunedCoreBlockMeter                   = metrics.NewRegisteredMeter("dex/prune/coreblocks", nil)
+	prunedCoreBlockSizeMeter               = metrics.NewRegisteredMeter("dex/prune/coreblocks/size", nil)
 )
 
 // meteredMsgReadWriter is a wrapper around a p2p.MsgReadWriter, capable of
@@ -127,7 +132,7 @@ func (rw *meteredMsgReadWriter) ReadMsg() (p2p.Msg, error) {
 	case msg.Code == LatticeBlockMsg:
 		packets = propLatticeBlockInPacketsMeter
 		traffic = propLatticeBlockInTrafficMeter
-	case msg.Code == VoteMsg:
+	case msg.Code == VoteMsg || msg.Code == VotesMsg:
 		packets, traffic = propVoteInPacketsMeter, propVoteInTrafficMeter
 
 	case msg.Code == PullBlocksMsg:
@@ -170,7 +175,7 @@ func (rw *meteredMsgReadWriter) WriteMsg(msg p2p.Msg) error {
 	case msg.Code == LatticeBlockMsg:
 		packets = propLatticeBlockOutPacketsMeter
 		traffic = propLatticeBlockOutTrafficMeter
-	case msg.Code == VoteMsg:
+	case msg.Code == VoteMsg || msg.Code == VotesMsg:
 		packets, traffic = propVoteOutPacketsMeter, propVoteOutTrafficMeter
 
 	case msg.Code == PullBlocksMsg:
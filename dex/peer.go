@@ -38,6 +38,7 @@ import (
 	"errors"
 	"fmt"
 	"net"
+	"sort"
 	"sync"
 	"time"
 
@@ -103,6 +104,9 @@ const (
 	maxQueuedPullVotes            = 128
 	maxQueuedPullRandomness       = 128
 
+	// maxVotesBatch is the maximum number of votes batched in one VotesMsg.
+	maxVotesBatch = 64
+
 	handshakeTimeout = 5 * time.Second
 
 	groupNodeNum = 3
@@ -111,9 +115,10 @@ const (
 // PeerInfo represents a short summary of the Ethereum sub-protocol metadata known
 // about a connected peer.
 type PeerInfo struct {
-	Version int    `json:"version"` // Ethereum protocol version negotiated
-	Number  uint64 `json:"number"`  // Number the peer's blockchain
-	Head    string `json:"head"`    // SHA3 hash of the peer's best owned block
+	Version int            `json:"version"` // Ethereum protocol version negotiated
+	Number  uint64         `json:"number"`  // Number the peer's blockchain
+	Head    string         `json:"head"`    // SHA3 hash of the peer's best owned block
+	Score   *PeerScoreInfo `json:"score"`   // Health of the peer delivering consensus messages
 }
 
 type setType uint32
@@ -123,6 +128,17 @@ const (
 	notaryset
 )
 
+func (s setType) String() string {
+	switch s {
+	case dkgset:
+		return "dkg"
+	case notaryset:
+		return "notary"
+	default:
+		return fmt.Sprintf("unknown(%d)", uint32(s))
+	}
+}
+
 type peerLabel struct {
 	set     setType
 	chainID uint32
@@ -141,6 +157,11 @@ type peer struct {
 	number uint64
 	lock   sync.RWMutex
 
+	score       *peerScore      // Health of the peer delivering consensus messages
+	rateLimiter *msgRateLimiter // Rate limiter of consensus messages, nil if disabled
+
+	capabilities map[string]struct{} // Capabilities negotiated in the handshake
+
 	knownTxs                   mapset.Set // Set of transaction hashes known to be known by this peer
 	knownRecords               mapset.Set // Set of node record known to be known by this peer
 	knownBlocks                mapset.Set // Set of block hashes known to be known by this peer
@@ -172,6 +193,7 @@ func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
 		rw:                         rw,
 		version:                    version,
 		id:                         p.ID().String(),
+		score:                      newPeerScore(),
 		knownTxs:                   mapset.NewSet(),
 		knownRecords:               mapset.NewSet(),
 		knownBlocks:                mapset.NewSet(),
@@ -227,6 +249,14 @@ func (p *peer) broadcast() {
 			}
 			p.Log().Trace("Broadcast lattice block")
 		case vote := <-p.queuedVotes:
+			if p.HasCapability(capBatchVotes) {
+				votes := p.drainVotes(vote)
+				if err := p.SendVotes(votes); err != nil {
+					return
+				}
+				p.Log().Trace("Broadcast votes", "count", len(votes))
+				break
+			}
 			if err := p.SendVote(vote); err != nil {
 				return
 			}
@@ -281,6 +311,21 @@ func (p *peer) broadcast() {
 	}
 }
 
+// drainVotes collects the queued votes, up to maxVotesBatch, into a batch
+// starting with the given vote.
+func (p *peer) drainVotes(vote *coreTypes.Vote) []*coreTypes.Vote {
+	votes := []*coreTypes.Vote{vote}
+	for len(votes) < maxVotesBatch {
+		select {
+		case vote := <-p.queuedVotes:
+			votes = append(votes, vote)
+		default:
+			return votes
+		}
+	}
+	return votes
+}
+
 // close signals the broadcast goroutine to terminate.
 func (p *peer) close() {
 	close(p.term)
@@ -294,6 +339,7 @@ func (p *peer) Info() *PeerInfo {
 		Version: p.version,
 		Number:  number,
 		Head:    hash.Hex(),
+		Score:   p.score.info(),
 	}
 }
 
@@ -433,13 +479,15 @@ func (p *peer) AsyncSendNewBlock(block *types.Block) {
 
 func (p *peer) SendLatticeBlock(block *coreTypes.Block) error {
 	p.knownLatticeBlocks.Add(rlpHash(block))
-	return p2p.Send(p.rw, LatticeBlockMsg, block)
+	p.score.markBlock(block.Hash)
+	return p.send(LatticeBlockMsg, block)
 }
 
 func (p *peer) AsyncSendLatticeBlock(block *coreTypes.Block) {
 	select {
 	case p.queuedLatticeBlocks <- block:
 		p.knownLatticeBlocks.Add(rlpHash(block))
+		p.score.markBlock(block.Hash)
 	default:
 		p.Log().Debug("Dropping lattice block propagation")
 	}
@@ -447,13 +495,25 @@ func (p *peer) AsyncSendLatticeBlock(block *coreTypes.Block) {
 
 func (p *peer) SendVote(vote *coreTypes.Vote) error {
 	p.knownVotes.Add(rlpHash(vote))
+	p.score.markVote(vote.Position)
 	return p2p.Send(p.rw, VoteMsg, vote)
 }
 
+// SendVotes sends a batch of votes to the peer, the peer must support
+// capBatchVotes.
+func (p *peer) SendVotes(votes []*coreTypes.Vote) error {
+	for _, vote := range votes {
+		p.knownVotes.Add(rlpHash(vote))
+		p.score.markVote(vote.Position)
+	}
+	return p2p.Send(p.rw, VotesMsg, votes)
+}
+
 func (p *peer) AsyncSendVote(vote *coreTypes.Vote) {
 	select {
 	case p.queuedVotes <- vote:
 		p.knownVotes.Add(rlpHash(vote))
+		p.score.markVote(vote.Position)
 	default:
 		p.Log().Debug("Dropping vote propagation")
 	}
@@ -516,6 +576,7 @@ func (p *peer) AsyncSendDKGPartialSignature(psig *dkgTypes.PartialSignature) {
 }
 
 func (p *peer) SendPullBlocks(hashes coreCommon.Hashes) error {
+	p.score.pullBlocks(hashes)
 	return p2p.Send(p.rw, PullBlocksMsg, hashes)
 }
 
@@ -528,6 +589,7 @@ func (p *peer) AsyncSendPullBlocks(hashes coreCommon.Hashes) {
 }
 
 func (p *peer) SendPullVotes(pos coreTypes.Position) error {
+	p.score.pullVotes(pos)
 	return p2p.Send(p.rw, PullVotesMsg, pos)
 }
 
@@ -558,13 +620,13 @@ func (p *peer) SendBlockHeaders(headers []*types.HeaderWithGovState) error {
 
 // SendBlockBodies sends a batch of block contents to the remote peer.
 func (p *peer) SendBlockBodies(bodies []*blockBody) error {
-	return p2p.Send(p.rw, BlockBodiesMsg, blockBodiesData(bodies))
+	return p.send(BlockBodiesMsg, blockBodiesData(bodies))
 }
 
 // SendBlockBodiesRLP sends a batch of block contents to the remote peer from
 // an already RLP encoded format.
 func (p *peer) SendBlockBodiesRLP(bodies []rlp.RawValue) error {
-	return p2p.Send(p.rw, BlockBodiesMsg, bodies)
+	return p.send(BlockBodiesMsg, bodies)
 }
 
 // SendNodeDataRLP sends a batch of arbitrary internal data, corresponding to the
@@ -630,20 +692,25 @@ func (p *peer) RequestReceipts(hashes []common.Hash) error {
 }
 
 // Handshake executes the eth protocol handshake, negotiating version number,
-// network IDs, difficulties, head and genesis blocks.
+// network IDs, difficulties, head and genesis blocks, and since dex/65 the
+// capabilities.
 func (p *peer) Handshake(network uint64, number uint64, head common.Hash, genesis common.Hash) error {
 	// Send out own handshake in a new thread
 	errc := make(chan error, 2)
 	var status statusData // safe to read after two values have been received from errc
 
+	local := &statusData{
+		ProtocolVersion: uint32(p.version),
+		NetworkId:       network,
+		Number:          number,
+		CurrentBlock:    head,
+		GenesisBlock:    genesis,
+	}
+	if p.version >= dex65 {
+		local.Capabilities = localCapabilities
+	}
 	go func() {
-		errc <- p2p.Send(p.rw, StatusMsg, &statusData{
-			ProtocolVersion: uint32(p.version),
-			NetworkId:       network,
-			Number:          number,
-			CurrentBlock:    head,
-			GenesisBlock:    genesis,
-		})
+		errc <- p2p.Send(p.rw, StatusMsg, local)
 	}()
 	go func() {
 		errc <- p.readStatus(network, &status, genesis)
@@ -661,9 +728,36 @@ func (p *peer) Handshake(network uint64, number uint64, head common.Hash, genesi
 		}
 	}
 	p.number, p.head = status.Number, status.CurrentBlock
+	if p.version >= dex65 {
+		p.setCapabilities(status.Capabilities)
+	}
 	return nil
 }
 
+// setCapabilities keeps the capabilities supported by both sides.
+func (p *peer) setCapabilities(remote []string) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	p.capabilities = make(map[string]struct{})
+	for _, c := range remote {
+		for _, local := range localCapabilities {
+			if c == local {
+				p.capabilities[c] = struct{}{}
+			}
+		}
+	}
+}
+
+// HasCapability reports whether the capability is negotiated with the peer.
+func (p *peer) HasCapability(c string) bool {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	_, ok := p.capabilities[c]
+	return ok
+}
+
 func (p *peer) readStatus(network uint64, status *statusData, genesis common.Hash) (err error) {
 	msg, err := p.rw.ReadMsg()
 	if err != nil {
@@ -837,6 +931,15 @@ func (ps *peerSet) PeersWithLabel(label peerLabel) []*peer {
 	return list
 }
 
+// HasLabel returns if the peer with the given id belongs to any notary or DKG
+// group.
+func (ps *peerSet) HasLabel(id string) bool {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	return len(ps.peer2Labels[id]) > 0
+}
+
 func (ps *peerSet) PeersWithoutVote(hash common.Hash, label peerLabel) []*peer {
 	ps.lock.RLock()
 	defer ps.lock.RUnlock()
@@ -946,6 +1049,9 @@ func (ps *peerSet) Close() {
 func (ps *peerSet) BuildConnection(round uint64) {
 	ps.lock.Lock()
 	defer ps.lock.Unlock()
+	if _, ok := ps.history[round]; ok {
+		return
+	}
 	defer ps.dumpPeerLabel(fmt.Sprintf("BuildConnection: %d", round))
 
 	ps.history[round] = struct{}{}
@@ -1013,12 +1119,78 @@ func (ps *peerSet) ForgetConnection(round uint64) {
 
 	for r := range ps.history {
 		if r <= round {
-			ps.forgetConnection(round)
+			ps.forgetConnection(r)
 			delete(ps.history, r)
 		}
 	}
 }
 
+// Rounds returns the rounds whose connections are currently built.
+func (ps *peerSet) Rounds() []uint64 {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	rounds := make([]uint64, 0, len(ps.history))
+	for r := range ps.history {
+		rounds = append(rounds, r)
+	}
+	sort.Slice(rounds, func(i, j int) bool { return rounds[i] < rounds[j] })
+	return rounds
+}
+
+// PeerGroupInfo represents a notary or DKG group the peer set maintains
+// connections to.
+type PeerGroupInfo struct {
+	Set     string          `json:"set"`
+	Round   uint64          `json:"round"`
+	ChainID uint32          `json:"chainID"`
+	Peers   []PeerLabelInfo `json:"peers"`
+}
+
+// PeerLabelInfo represents a member of a peer group.
+type PeerLabelInfo struct {
+	ID        string `json:"id"`
+	Connected bool   `json:"connected"`
+}
+
+// Groups returns the notary and DKG groups with their members, sorted by
+// round, set and chain id.
+func (ps *peerSet) Groups() []PeerGroupInfo {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	groups := make([]PeerGroupInfo, 0, len(ps.label2Peers))
+	for label, ids := range ps.label2Peers {
+		group := PeerGroupInfo{
+			Set:     label.set.String(),
+			Round:   label.round,
+			ChainID: label.chainID,
+			Peers:   make([]PeerLabelInfo, 0, len(ids)),
+		}
+		for id := range ids {
+			_, connected := ps.peers[id]
+			group.Peers = append(group.Peers, PeerLabelInfo{
+				ID:        id,
+				Connected: connected,
+			})
+		}
+		sort.Slice(group.Peers, func(i, j int) bool {
+			return group.Peers[i].ID < group.Peers[j].ID
+		})
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if groups[i].Round != groups[j].Round {
+			return groups[i].Round < groups[j].Round
+		}
+		if groups[i].Set != groups[j].Set {
+			return groups[i].Set < groups[j].Set
+		}
+		return groups[i].ChainID < groups[j].ChainID
+	})
+	return groups
+}
+
 func (ps *peerSet) forgetConnection(round uint64) {
 	dkgPKs, err := ps.gov.DKGSet(round)
 	if err != nil {
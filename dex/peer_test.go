@@ -418,6 +418,93 @@ func TestPeerSetBuildDKGConn(t *testing.T) {
 	}
 }
 
+func TestPeerSetBuildAndForgetConnection(t *testing.T) {
+	key, err := crypto.GenerateKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	server := newTestP2PServer(key)
+	self := server.Self()
+	table := newNodeTable()
+
+	var nodes []*enode.Node
+	for i := 0; i < 4; i++ {
+		nodes = append(nodes, randomNode())
+	}
+
+	gov := &testGovernance{
+		numChainsFunc: func(uint64) uint32 {
+			return 1
+		},
+	}
+	gov.notarySetFunc = func(
+		round uint64, cid uint32) (map[string]struct{}, error) {
+		m := map[uint64][]*enode.Node{
+			10: {self, nodes[0], nodes[1]},
+			11: {self, nodes[1], nodes[2]},
+			12: {self, nodes[2], nodes[3]},
+		}
+		return newTestNodeSet(m[round]), nil
+	}
+	gov.dkgSetFunc = func(round uint64) (map[string]struct{}, error) {
+		return newTestNodeSet([]*enode.Node{self, nodes[round-10]}), nil
+	}
+
+	ps := newPeerSet(gov, server, table)
+	err = ps.Register(newDummyPeer(nodes[1]))
+	if err != nil {
+		t.Error(err)
+	}
+
+	ps.BuildConnection(10)
+	ps.BuildConnection(11)
+	// Building an already built round is a no-op.
+	ps.BuildConnection(11)
+	ps.BuildConnection(12)
+
+	if rounds := ps.Rounds(); len(rounds) != 3 ||
+		rounds[0] != 10 || rounds[1] != 11 || rounds[2] != 12 {
+		t.Errorf("rounds mismatch: got %v, want [10 11 12]", rounds)
+	}
+	groups := ps.Groups()
+	if len(groups) != 6 {
+		t.Fatalf("num of groups mismatch: got %d, want 6", len(groups))
+	}
+	if groups[0].Set != "dkg" || groups[0].Round != 10 ||
+		groups[1].Set != "notary" || groups[1].Round != 10 {
+		t.Errorf("groups not sorted: %+v", groups[:2])
+	}
+	for _, peer := range groups[1].Peers {
+		want := peer.ID == nodes[1].ID().String()
+		if peer.Connected != want {
+			t.Errorf("peer %s connected mismatch: got %v, want %v",
+				peer.ID, peer.Connected, want)
+		}
+	}
+
+	// Forgetting round 11 forgets all rounds before it as well.
+	ps.ForgetConnection(11)
+	err = checkPeer2Labels(ps, map[string][]peerLabel{
+		nodes[2].ID().String(): {
+			{set: dkgset, round: 12},
+			{set: notaryset, chainID: 0, round: 12},
+		},
+		nodes[3].ID().String(): {
+			{set: notaryset, chainID: 0, round: 12},
+		},
+	})
+	if err != nil {
+		t.Error(err)
+	}
+	err = checkDirectPeer(server, []enode.ID{nodes[2].ID(), nodes[3].ID()})
+	if err != nil {
+		t.Error(err)
+	}
+	if rounds := ps.Rounds(); len(rounds) != 1 || rounds[0] != 12 {
+		t.Errorf("rounds mismatch: got %v, want [12]", rounds)
+	}
+}
+
 func checkPeer2Labels(ps *peerSet, want map[string][]peerLabel) error {
 	if len(ps.peer2Labels) != len(want) {
 		return fmt.Errorf("peer num mismatch: got %d, want %d",
@@ -506,5 +593,5 @@ func newTestNodeSet(nodes []*enode.Node) map[string]struct{} {
 }
 
 func newDummyPeer(node *enode.Node) *peer {
-	return &peer{id: node.ID().String()}
+	return &peer{id: node.ID().String(), score: newPeerScore()}
 }
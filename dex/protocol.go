@@ -50,16 +50,17 @@ import (
 // Constants to match up protocol versions and messages
 const (
 	dex64 = 64
+	dex65 = 65
 )
 
 // ProtocolName is the official short name of the protocol used during capability negotiation.
 var ProtocolName = "dex"
 
 // ProtocolVersions are the upported versions of the eth protocol (first is primary).
-var ProtocolVersions = []uint{dex64}
+var ProtocolVersions = []uint{dex65, dex64}
 
 // ProtocolLengths are the number of implemented message corresponding to different protocol versions.
-var ProtocolLengths = []uint64{43}
+var ProtocolLengths = []uint64{44, 43}
 
 const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
 
@@ -96,8 +97,24 @@ const (
 
 	GetGovStateMsg = 0x29
 	GovStateMsg    = 0x2a
+
+	// Protocol messages belonging to dex/65
+	VotesMsg = 0x2b
 )
 
+// Capabilities negotiated in the status message of dex/65.
+const (
+	// capBatchVotes allows votes to be batched in VotesMsg.
+	capBatchVotes = "batchvotes"
+
+	// capSnappy allows LatticeBlockMsg and BlockBodiesMsg payloads to be
+	// compressed with snappy.
+	capSnappy = "snappy"
+)
+
+// localCapabilities are the capabilities supported by this node.
+var localCapabilities = []string{capBatchVotes, capSnappy}
+
 type errCode int
 
 const (
@@ -110,6 +127,8 @@ const (
 	ErrNoStatusMsg
 	ErrExtraStatusMsg
 	ErrSuspendedPeer
+	ErrRateLimitExceeded
+	ErrInvalidSignature
 )
 
 func (e errCode) String() string {
@@ -127,6 +146,8 @@ var errorToString = map[int]string{
 	ErrNoStatusMsg:             "No status message",
 	ErrExtraStatusMsg:          "Extra status message",
 	ErrSuspendedPeer:           "Suspended peer",
+	ErrRateLimitExceeded:       "Rate limit exceeded",
+	ErrInvalidSignature:        "Invalid signature",
 }
 
 type txPool interface {
@@ -180,6 +201,10 @@ type statusData struct {
 	Number          uint64
 	CurrentBlock    common.Hash
 	GenesisBlock    common.Hash
+
+	// Capabilities is only sent since dex/65. It is kept as the tail so the
+	// status of dex/64 encodes the same.
+	Capabilities []string `rlp:"tail"`
 }
 
 // newBlockHashesData is the network packet for the block announcements.
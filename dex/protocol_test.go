@@ -28,8 +28,10 @@ import (
 	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
 	coreCrypto "github.com/dexon-foundation/dexon-consensus/core/crypto"
 	"github.com/dexon-foundation/dexon-consensus/core/crypto/dkg"
+	coreEcdsa "github.com/dexon-foundation/dexon-consensus/core/crypto/ecdsa"
 	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
 	dkgTypes "github.com/dexon-foundation/dexon-consensus/core/types/dkg"
+	coreUtils "github.com/dexon-foundation/dexon-consensus/core/utils"
 
 	"github.com/dexon-foundation/dexon/common"
 	"github.com/dexon-foundation/dexon/core/types"
@@ -38,6 +40,7 @@ import (
 	"github.com/dexon-foundation/dexon/p2p"
 	"github.com/dexon-foundation/dexon/p2p/enr"
 	"github.com/dexon-foundation/dexon/rlp"
+	"github.com/golang/snappy"
 )
 
 func init() {
@@ -69,15 +72,15 @@ func testStatusMsgErrors(t *testing.T, protocol int) {
 			wantError: errResp(ErrNoStatusMsg, "first msg has code 2 (!= 0)"),
 		},
 		{
-			code: StatusMsg, data: statusData{10, DefaultConfig.NetworkId, number, head.Hash(), genesis.Hash()},
+			code: StatusMsg, data: statusData{10, DefaultConfig.NetworkId, number, head.Hash(), genesis.Hash(), nil},
 			wantError: errResp(ErrProtocolVersionMismatch, "10 (!= %d)", protocol),
 		},
 		{
-			code: StatusMsg, data: statusData{uint32(protocol), 999, number, head.Hash(), genesis.Hash()},
+			code: StatusMsg, data: statusData{uint32(protocol), 999, number, head.Hash(), genesis.Hash(), nil},
 			wantError: errResp(ErrNetworkIdMismatch, "999 (!= 237)"),
 		},
 		{
-			code: StatusMsg, data: statusData{uint32(protocol), DefaultConfig.NetworkId, number, head.Hash(), common.Hash{3}},
+			code: StatusMsg, data: statusData{uint32(protocol), DefaultConfig.NetworkId, number, head.Hash(), common.Hash{3}, nil},
 			wantError: errResp(ErrGenesisBlockMismatch, "0300000000000000 (!= %x)", genesis.Hash().Bytes()[:8]),
 		},
 	}
@@ -312,9 +315,12 @@ func TestSendNodeRecords(t *testing.T) {
 	wg.Wait()
 }
 
-func TestRecvLatticeBlock(t *testing.T) {
+func TestRecvLatticeBlock64(t *testing.T) { testRecvLatticeBlock(t, dex64) }
+func TestRecvLatticeBlock65(t *testing.T) { testRecvLatticeBlock(t, dex65) }
+
+func testRecvLatticeBlock(t *testing.T, protocol int) {
 	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
-	p, _ := newTestPeer("peer", dex64, pm, true)
+	p, _ := newTestPeer("peer", protocol, pm, true)
 	defer pm.Stop()
 	defer p.close()
 
@@ -351,7 +357,10 @@ func TestRecvLatticeBlock(t *testing.T) {
 		},
 	}
 
-	if err := p2p.Send(p.app, LatticeBlockMsg, &block); err != nil {
+	if err := newTestSigner(t).SignBlock(&block); err != nil {
+		t.Fatalf("sign error: %v", err)
+	}
+	if err := sendTestMsg(p.app, protocol, LatticeBlockMsg, &block); err != nil {
 		t.Fatalf("send error: %v", err)
 	}
 
@@ -367,9 +376,135 @@ func TestRecvLatticeBlock(t *testing.T) {
 	}
 }
 
-func TestSendLatticeBlock(t *testing.T) {
+// sendTestMsg sends a message the way a peer of the given protocol version
+// does, compressing the payload since dex/65.
+func sendTestMsg(w p2p.MsgWriter, protocol int, code uint64, data interface{}) error {
+	if protocol < dex65 || !isCompressible(code) {
+		return p2p.Send(w, code, data)
+	}
+	payload, err := rlp.EncodeToBytes(data)
+	if err != nil {
+		return err
+	}
+	return p2p.Send(w, code, snappy.Encode(nil, payload))
+}
+
+// decodeTestMsg decodes a message the way a peer of the given protocol
+// version does, decompressing the payload since dex/65.
+func decodeTestMsg(msg p2p.Msg, protocol int, val interface{}) error {
+	if protocol < dex65 || !isCompressible(msg.Code) {
+		return msg.Decode(val)
+	}
+	var data []byte
+	if err := msg.Decode(&data); err != nil {
+		return err
+	}
+	payload, err := snappy.Decode(nil, data)
+	if err != nil {
+		return err
+	}
+	return rlp.DecodeBytes(payload, val)
+}
+
+func TestHandshakeCapabilities(t *testing.T) {
 	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
-	p, _ := newTestPeer("peer", dex64, pm, true)
+	defer pm.Stop()
+
+	p64, _ := newTestPeer("peer64", dex64, pm, true)
+	defer p64.close()
+	p65, _ := newTestPeer("peer65", dex65, pm, true)
+	defer p65.close()
+	waitForRegister(pm, 2)
+
+	for _, c := range localCapabilities {
+		if p64.HasCapability(c) {
+			t.Errorf("dex64 peer has capability %q", c)
+		}
+		if !p65.HasCapability(c) {
+			t.Errorf("dex65 peer has no capability %q", c)
+		}
+	}
+}
+
+func newTestSigner(t *testing.T) *coreUtils.Signer {
+	prvKey, err := coreEcdsa.NewPrivateKey()
+	if err != nil {
+		t.Fatalf("failed to generate key: %v", err)
+	}
+	return coreUtils.NewSigner(prvKey)
+}
+
+func TestRecvInvalidSignature(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	p, errc := newTestPeer("peer", dex64, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	vote := coreTypes.Vote{
+		VoteHeader: coreTypes.VoteHeader{
+			Period: 10,
+			Position: coreTypes.Position{
+				ChainID: 11,
+				Round:   12,
+				Height:  13,
+			},
+		},
+	}
+	if err := newTestSigner(t).SignVote(&vote); err != nil {
+		t.Fatalf("sign error: %v", err)
+	}
+	// Tamper the vote after signing.
+	vote.Period++
+
+	if err := p2p.Send(p.app, VoteMsg, vote); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Errorf("peer not disconnected")
+		}
+	case <-pm.ReceiveChan():
+		t.Errorf("vote with invalid signature received")
+	case <-time.After(time.Second):
+		t.Errorf("peer not disconnected within 1 second")
+	}
+}
+
+func TestRecvRateLimited(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	pm.rateLimit = RateLimitConfig{
+		Enabled:    true,
+		PullBlocks: RateLimit{Rate: 0, Burst: 2},
+		Violation:  RateLimit{Rate: 0, Burst: 2},
+	}
+	p, errc := newTestPeer("peer", dex64, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	// Two allowed requests, two rate limited ones, then the peer is
+	// considered abusive.
+	for i := 0; i < 5; i++ {
+		if err := p2p.Send(p.app, PullBlocksMsg, coreCommon.Hashes{}); err != nil {
+			t.Fatalf("send error: %v", err)
+		}
+	}
+	select {
+	case err := <-errc:
+		if err == nil {
+			t.Errorf("peer not disconnected")
+		}
+	case <-time.After(time.Second):
+		t.Errorf("peer not disconnected within 1 second")
+	}
+}
+
+func TestSendLatticeBlock64(t *testing.T) { testSendLatticeBlock(t, dex64) }
+func TestSendLatticeBlock65(t *testing.T) { testSendLatticeBlock(t, dex65) }
+
+func testSendLatticeBlock(t *testing.T, protocol int) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	p, _ := newTestPeer("peer", protocol, pm, true)
 	defer pm.Stop()
 	defer p.close()
 
@@ -416,7 +551,7 @@ func TestSendLatticeBlock(t *testing.T) {
 	}
 
 	var b coreTypes.Block
-	if err := msg.Decode(&b); err != nil {
+	if err := decodeTestMsg(msg, protocol, &b); err != nil {
 		t.Errorf("%v: %v", p.Peer, err)
 	}
 
@@ -447,6 +582,9 @@ func TestRecvVote(t *testing.T) {
 		},
 	}
 
+	if err := newTestSigner(t).SignVote(&vote); err != nil {
+		t.Fatalf("sign error: %v", err)
+	}
 	if err := p2p.Send(p.app, VoteMsg, vote); err != nil {
 		t.Fatalf("send error: %v", err)
 	}
@@ -563,6 +701,84 @@ func TestSendVote(t *testing.T) {
 	wg.Wait()
 }
 
+func TestRecvVotes(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	p, _ := newTestPeer("peer", dex65, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	signer := newTestSigner(t)
+	votes := make([]*coreTypes.Vote, 3)
+	for i := range votes {
+		votes[i] = &coreTypes.Vote{
+			VoteHeader: coreTypes.VoteHeader{
+				Period: uint64(i),
+				Position: coreTypes.Position{
+					ChainID: 11,
+					Round:   12,
+					Height:  13,
+				},
+			},
+		}
+		if err := signer.SignVote(votes[i]); err != nil {
+			t.Fatalf("sign error: %v", err)
+		}
+	}
+	if err := p2p.Send(p.app, VotesMsg, votes); err != nil {
+		t.Fatalf("send error: %v", err)
+	}
+
+	for i := range votes {
+		select {
+		case msg := <-pm.ReceiveChan():
+			if rlpHash(msg.(*coreTypes.Vote)) != rlpHash(votes[i]) {
+				t.Errorf("vote %d mismatch", i)
+			}
+		case <-time.After(1 * time.Second):
+			t.Fatalf("vote %d not received within 1 seconds", i)
+		}
+	}
+}
+
+func TestSendVotes(t *testing.T) {
+	pm, _ := newTestProtocolManagerMust(t, downloader.FullSync, 0, nil, nil)
+	p, _ := newTestPeer("peer", dex65, pm, true)
+	defer pm.Stop()
+	defer p.close()
+
+	vote := coreTypes.Vote{
+		VoteHeader: coreTypes.VoteHeader{
+			Period: 10,
+			Position: coreTypes.Position{
+				ChainID: 1,
+				Round:   10,
+				Height:  13,
+			},
+		},
+	}
+	b := crypto.FromECDSAPub(p.Node().Pubkey())
+	pm.peers.addDirectPeer(hex.EncodeToString(b),
+		peerLabel{set: notaryset, chainID: 1, round: 10})
+
+	waitForRegister(pm, 1)
+	pm.BroadcastVote(&vote)
+
+	msg, err := p.app.ReadMsg()
+	if err != nil {
+		t.Fatalf("%v: read error: %v", p.Peer, err)
+	}
+	if msg.Code != VotesMsg {
+		t.Fatalf("%v: got code %d, want %d", p.Peer, msg.Code, VotesMsg)
+	}
+	var votes []*coreTypes.Vote
+	if err := msg.Decode(&votes); err != nil {
+		t.Fatalf("%v: %v", p.Peer, err)
+	}
+	if len(votes) != 1 || rlpHash(votes[0]) != rlpHash(&vote) {
+		t.Errorf("votes mismatch")
+	}
+}
+
 type mockPublicKey ecdsa.PublicKey
 
 func (p *mockPublicKey) VerifySignature(hash coreCommon.Hash, signature coreCrypto.Signature) bool {
@@ -592,6 +808,9 @@ func TestRecvDKGPrivateShare(t *testing.T) {
 		},
 	}
 
+	if err := newTestSigner(t).SignDKGPrivateShare(&privateShare); err != nil {
+		t.Fatalf("sign error: %v", err)
+	}
 	if err := p2p.Send(
 		p.app, DKGPrivateShareMsg, &privateShare); err != nil {
 		t.Fatalf("send error: %v", err)
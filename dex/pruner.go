@@ -0,0 +1,126 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"sync"
+
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/dex/db"
+	"github.com/dexon-foundation/dexon/log"
+)
+
+// corePruner prunes the core blocks and the undelivered confirmed blocks of
+// rounds out of the retention window in the background whenever the chain
+// enters a new round. Pruning scans all the core blocks, so it runs apart
+// from the chain head loop and triggers arriving meanwhile are coalesced.
+type corePruner struct {
+	db    *db.DB
+	chain *core.BlockChain
+	app   *DexconApp
+	round uint64 // Last round the pruning is triggered at
+
+	trigger chan struct{}
+	quit    chan struct{}
+	wg      sync.WaitGroup
+}
+
+func newCorePruner(db *db.DB, chain *core.BlockChain, app *DexconApp) *corePruner {
+	return &corePruner{
+		db:      db,
+		chain:   chain,
+		app:     app,
+		trigger: make(chan struct{}, 1),
+		quit:    make(chan struct{}),
+	}
+}
+
+func (p *corePruner) start() {
+	p.wg.Add(2)
+	go p.loop()
+	go p.worker()
+}
+
+func (p *corePruner) stop() {
+	close(p.quit)
+	p.wg.Wait()
+}
+
+func (p *corePruner) loop() {
+	defer p.wg.Done()
+
+	ch := make(chan core.ChainHeadEvent, 16)
+	sub := p.chain.SubscribeChainHeadEvent(ch)
+	defer sub.Unsubscribe()
+
+	p.round = p.chain.CurrentBlock().Round()
+	p.notify()
+	for {
+		select {
+		case ev := <-ch:
+			if round := ev.Block.Round(); round > p.round {
+				p.round = round
+				p.notify()
+			}
+		case <-sub.Err():
+			return
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+// notify triggers the worker without blocking. A pending trigger already
+// covers the new round as the worker prunes at the current round.
+func (p *corePruner) notify() {
+	select {
+	case p.trigger <- struct{}{}:
+	default:
+	}
+}
+
+func (p *corePruner) worker() {
+	defer p.wg.Done()
+
+	for {
+		select {
+		case <-p.trigger:
+			p.prune(p.chain.CurrentBlock().Round())
+		case <-p.quit:
+			return
+		}
+	}
+}
+
+func (p *corePruner) prune(round uint64) {
+	if end := p.db.CoreBlockRetentionStart(round); end > 0 {
+		if pruned := p.app.pruneConfirmedBlocks(end); pruned > 0 {
+			log.Info("Pruned confirmed blocks", "round", round, "count", pruned)
+		}
+	}
+	pruned, size, err := p.db.PruneCoreBlocks(round)
+	if err != nil {
+		log.Error("Failed to prune core blocks", "round", round, "err", err)
+		return
+	}
+	if pruned > 0 {
+		prunedCoreBlockMeter.Mark(int64(pruned))
+		prunedCoreBlockSizeMeter.Mark(int64(size))
+		log.Info("Pruned core blocks", "round", round, "count", pruned, "reclaimed", size)
+	}
+}
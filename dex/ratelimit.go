@@ -0,0 +1,148 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"sync"
+	"time"
+)
+
+// RateLimit is a token bucket limit: Rate messages per second are allowed on
+// average, with bursts of up to Burst messages.
+type RateLimit struct {
+	Rate  float64
+	Burst int
+}
+
+// RateLimitConfig contains the per-peer rate limits of consensus messages.
+type RateLimitConfig struct {
+	Enabled bool
+
+	LatticeBlock   RateLimit
+	Vote           RateLimit
+	PullBlocks     RateLimit
+	PullVotes      RateLimit
+	PullRandomness RateLimit
+	DKG            RateLimit
+
+	// Violation limits the number of rate limited messages. A peer exceeding
+	// it is considered abusive and disconnected.
+	Violation RateLimit
+}
+
+// DefaultRateLimitConfig contains the default rate limits of consensus
+// messages.
+var DefaultRateLimitConfig = RateLimitConfig{
+	Enabled:        true,
+	LatticeBlock:   RateLimit{Rate: 50, Burst: 100},
+	Vote:           RateLimit{Rate: 200, Burst: 400},
+	PullBlocks:     RateLimit{Rate: 20, Burst: 40},
+	PullVotes:      RateLimit{Rate: 20, Burst: 40},
+	PullRandomness: RateLimit{Rate: 20, Burst: 40},
+	DKG:            RateLimit{Rate: 50, Burst: 100},
+	Violation:      RateLimit{Rate: 1, Burst: 100},
+}
+
+func (c *RateLimitConfig) limits() map[uint64]RateLimit {
+	return map[uint64]RateLimit{
+		LatticeBlockMsg:        c.LatticeBlock,
+		VoteMsg:                c.Vote,
+		PullBlocksMsg:          c.PullBlocks,
+		PullVotesMsg:           c.PullVotes,
+		PullRandomnessMsg:      c.PullRandomness,
+		DKGPrivateShareMsg:     c.DKG,
+		DKGPartialSignatureMsg: c.DKG,
+	}
+}
+
+// tokenBucket is a token bucket filled at rate tokens per second.
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(limit RateLimit, now time.Time) *tokenBucket {
+	return &tokenBucket{
+		rate:   limit.Rate,
+		burst:  float64(limit.Burst),
+		tokens: float64(limit.Burst),
+		last:   now,
+	}
+}
+
+// take takes a token from the bucket, returns false if the bucket is empty.
+func (b *tokenBucket) take(now time.Time) bool {
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// msgRateLimiter limits the rate of consensus messages received from a peer.
+type msgRateLimiter struct {
+	lock       sync.Mutex
+	buckets    map[uint64]*tokenBucket
+	violations *tokenBucket
+}
+
+func newMsgRateLimiter(config RateLimitConfig) *msgRateLimiter {
+	if !config.Enabled {
+		return nil
+	}
+	now := time.Now()
+	l := &msgRateLimiter{
+		buckets:    make(map[uint64]*tokenBucket),
+		violations: newTokenBucket(config.Violation, now),
+	}
+	for code, limit := range config.limits() {
+		l.buckets[code] = newTokenBucket(limit, now)
+	}
+	// Batched votes are accounted per vote.
+	l.buckets[VotesMsg] = l.buckets[VoteMsg]
+	return l
+}
+
+// allow reports whether a message of the given code is allowed, and whether
+// the peer has exceeded the violation limit and should be disconnected.
+func (l *msgRateLimiter) allow(code uint64) (allowed bool, abusive bool) {
+	if l == nil {
+		return true, false
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+
+	bucket, ok := l.buckets[code]
+	if !ok {
+		return true, false
+	}
+	now := time.Now()
+	if bucket.take(now) {
+		return true, false
+	}
+	return false, !l.violations.take(now)
+}
@@ -0,0 +1,302 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+	lru "github.com/hashicorp/golang-lru"
+)
+
+const (
+	maxPeerScore = 100
+
+	// minPeerScore is the score under which a direct peer is dropped and
+	// redialed.
+	minPeerScore = 0
+
+	// pullTimeout is how long a pull request may stay unanswered before it
+	// is counted as missed.
+	pullTimeout = 5 * time.Second
+
+	// scoreInterval is the interval the scores are evaluated and decayed.
+	scoreInterval = 10 * time.Second
+
+	duplicatePenalty  = 1
+	invalidPenalty    = 20
+	missedPullPenalty = 10
+
+	// latencyPenaltyUnit is the pull response latency costing one point.
+	latencyPenaltyUnit = 100 * time.Millisecond
+	maxLatencyPenalty  = 30
+
+	maxPendingPulls = 1024
+
+	// maxKnownPulls is the number of lattice blocks and vote positions
+	// remembered as held by the peer.
+	maxKnownPulls = 1024
+)
+
+// PeerScoreInfo represents a short summary of the health of a peer.
+type PeerScoreInfo struct {
+	Score       int    `json:"score"`
+	Latency     string `json:"latency"`
+	Delivered   uint64 `json:"delivered"`
+	Duplicates  uint64 `json:"duplicates"`
+	Invalids    uint64 `json:"invalids"`
+	MissedPulls uint64 `json:"missedPulls"`
+}
+
+// pullRequest is a pull request sent to the peer. The peer only answers the
+// data it has, so an unanswered request is only counted as missed if the peer
+// is expected to have the data, that is, it sent us or was sent any of it.
+type pullRequest struct {
+	sent     time.Time
+	expected bool
+	answered bool
+}
+
+// peerScore tracks how well a peer delivers consensus messages. Counters are
+// halved every scoreInterval, so a peer recovers from past misbehaviour.
+type peerScore struct {
+	lock sync.Mutex
+
+	latency     time.Duration // Moving average of pull response latency
+	delivered   uint64
+	duplicates  uint64
+	invalids    uint64
+	missedPulls uint64
+
+	pendingBlocks map[coreCommon.Hash]*pullRequest
+	pendingVotes  map[coreTypes.Position]*pullRequest
+
+	knownBlocks *lru.Cache // Hashes of the lattice blocks the peer has
+	knownVotes  *lru.Cache // Positions the peer has votes of
+}
+
+func newPeerScore() *peerScore {
+	knownBlocks, _ := lru.New(maxKnownPulls)
+	knownVotes, _ := lru.New(maxKnownPulls)
+	return &peerScore{
+		pendingBlocks: make(map[coreCommon.Hash]*pullRequest),
+		pendingVotes:  make(map[coreTypes.Position]*pullRequest),
+		knownBlocks:   knownBlocks,
+		knownVotes:    knownVotes,
+	}
+}
+
+// markBlock records that the peer has the lattice block.
+func (s *peerScore) markBlock(hash coreCommon.Hash) {
+	s.knownBlocks.Add(hash, struct{}{})
+}
+
+// markVote records that the peer has votes of the position.
+func (s *peerScore) markVote(pos coreTypes.Position) {
+	s.knownVotes.Add(pos, struct{}{})
+}
+
+// pullBlocks records that the given blocks are pulled from the peer.
+func (s *peerScore) pullBlocks(hashes coreCommon.Hashes) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	req := &pullRequest{sent: time.Now()}
+	for _, hash := range hashes {
+		if len(s.pendingBlocks) >= maxPendingPulls {
+			break
+		}
+		if _, ok := s.pendingBlocks[hash]; ok {
+			continue
+		}
+		if s.knownBlocks.Contains(hash) {
+			req.expected = true
+		}
+		s.pendingBlocks[hash] = req
+	}
+}
+
+// pullVotes records that the votes of the given position are pulled from the
+// peer.
+func (s *peerScore) pullVotes(pos coreTypes.Position) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if len(s.pendingVotes) >= maxPendingPulls {
+		return
+	}
+	if _, ok := s.pendingVotes[pos]; !ok {
+		s.pendingVotes[pos] = &pullRequest{
+			sent:     time.Now(),
+			expected: s.knownVotes.Contains(pos),
+		}
+	}
+}
+
+// deliverBlock records a lattice block received from the peer. A block
+// answering a pull request is never a duplicate.
+func (s *peerScore) deliverBlock(hash coreCommon.Hash, duplicate bool) {
+	s.markBlock(hash)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if req, ok := s.pendingBlocks[hash]; ok {
+		s.answer(req)
+		delete(s.pendingBlocks, hash)
+		duplicate = false
+	}
+	s.deliver(duplicate)
+}
+
+// deliverVote records a vote received from the peer. A vote answering a pull
+// request is never a duplicate.
+func (s *peerScore) deliverVote(pos coreTypes.Position, duplicate bool) {
+	s.markVote(pos)
+
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if req, ok := s.pendingVotes[pos]; ok {
+		s.answer(req)
+		delete(s.pendingVotes, pos)
+		duplicate = false
+	}
+	s.deliver(duplicate)
+}
+
+// make sure the s.lock is held
+func (s *peerScore) answer(req *pullRequest) {
+	if !req.answered {
+		s.updateLatency(time.Since(req.sent))
+		req.answered = true
+	}
+}
+
+// markInvalid records an invalid message received from the peer.
+func (s *peerScore) markInvalid() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.invalids++
+}
+
+// make sure the s.lock is held
+func (s *peerScore) deliver(duplicate bool) {
+	if duplicate {
+		s.duplicates++
+	} else {
+		s.delivered++
+	}
+}
+
+// make sure the s.lock is held
+func (s *peerScore) updateLatency(latency time.Duration) {
+	if s.latency == 0 {
+		s.latency = latency
+		return
+	}
+	s.latency = (s.latency*7 + latency) / 8
+}
+
+// expire drops the pull requests unanswered for pullTimeout, counting each
+// request the peer is expected to answer as one missed pull.
+func (s *peerScore) expire(now time.Time) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	missed := make(map[*pullRequest]struct{})
+	for hash, req := range s.pendingBlocks {
+		if now.Sub(req.sent) > pullTimeout {
+			if req.expected && !req.answered {
+				missed[req] = struct{}{}
+			}
+			delete(s.pendingBlocks, hash)
+		}
+	}
+	for pos, req := range s.pendingVotes {
+		if now.Sub(req.sent) > pullTimeout {
+			if req.expected && !req.answered {
+				missed[req] = struct{}{}
+			}
+			delete(s.pendingVotes, pos)
+		}
+	}
+	s.missedPulls += uint64(len(missed))
+}
+
+// decay halves the counters so the score reflects recent behaviour.
+func (s *peerScore) decay() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.delivered /= 2
+	s.duplicates /= 2
+	s.invalids /= 2
+	s.missedPulls /= 2
+}
+
+// score returns the current score of the peer, from maxPeerScore down to
+// negative values for misbehaving peers.
+func (s *peerScore) score() int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return s.scoreLocked()
+}
+
+// make sure the s.lock is held
+func (s *peerScore) scoreLocked() int {
+	latencyPenalty := int(s.latency / latencyPenaltyUnit)
+	if latencyPenalty > maxLatencyPenalty {
+		latencyPenalty = maxLatencyPenalty
+	}
+	return maxPeerScore - latencyPenalty -
+		int(s.duplicates)*duplicatePenalty -
+		int(s.invalids)*invalidPenalty -
+		int(s.missedPulls)*missedPullPenalty
+}
+
+func (s *peerScore) info() *PeerScoreInfo {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	return &PeerScoreInfo{
+		Score:       s.scoreLocked(),
+		Latency:     s.latency.String(),
+		Delivered:   s.delivered,
+		Duplicates:  s.duplicates,
+		Invalids:    s.invalids,
+		MissedPulls: s.missedPulls,
+	}
+}
+
+// sortPeersByScore sorts the peers from the healthiest to the least healthy.
+func sortPeersByScore(peers []*peer) []*peer {
+	scores := make(map[*peer]int, len(peers))
+	for _, p := range peers {
+		scores[p] = p.score.score()
+	}
+	sort.SliceStable(peers, func(i, j int) bool {
+		return scores[peers[i]] > scores[peers[j]]
+	})
+	return peers
+}
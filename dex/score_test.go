@@ -0,0 +1,123 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package dex
+
+import (
+	"testing"
+	"time"
+
+	coreCommon "github.com/dexon-foundation/dexon-consensus/common"
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+)
+
+func TestPeerScore(t *testing.T) {
+	s := newPeerScore()
+	if score := s.score(); score != maxPeerScore {
+		t.Errorf("initial score mismatch: got %d, want %d", score, maxPeerScore)
+	}
+
+	hash := coreCommon.NewRandomHash()
+	s.pullBlocks(coreCommon.Hashes{hash})
+	// A pulled block is not a duplicate even if the peer already knows it.
+	s.deliverBlock(hash, true)
+	if s.delivered != 1 || s.duplicates != 0 {
+		t.Errorf("delivery mismatch: got (%d, %d), want (1, 0)",
+			s.delivered, s.duplicates)
+	}
+	s.deliverBlock(hash, true)
+	if s.duplicates != 1 {
+		t.Errorf("duplicates mismatch: got %d, want 1", s.duplicates)
+	}
+
+	// Pulls of data the peer is known to have.
+	pos := coreTypes.Position{ChainID: 1, Round: 2, Height: 3}
+	s.markVote(pos)
+	s.pullVotes(pos)
+	missing := coreCommon.NewRandomHash()
+	s.markBlock(missing)
+	s.pullBlocks(coreCommon.Hashes{missing})
+	s.expire(time.Now().Add(pullTimeout + time.Second))
+	if s.missedPulls != 2 {
+		t.Errorf("missed pulls mismatch: got %d, want 2", s.missedPulls)
+	}
+	s.markInvalid()
+
+	want := maxPeerScore - duplicatePenalty - 2*missedPullPenalty - invalidPenalty
+	if score := s.score(); score != want {
+		t.Errorf("score mismatch: got %d, want %d", score, want)
+	}
+
+	s.decay()
+	want = maxPeerScore - missedPullPenalty
+	if score := s.score(); score != want {
+		t.Errorf("decayed score mismatch: got %d, want %d", score, want)
+	}
+}
+
+func TestPeerScoreMissedPulls(t *testing.T) {
+	// Honest peers lacking the pulled block do not answer, and are not
+	// expected to.
+	hashes := coreCommon.Hashes{coreCommon.NewRandomHash(), coreCommon.NewRandomHash()}
+	peers := []*peer{
+		newDummyPeer(randomNode()),
+		newDummyPeer(randomNode()),
+		newDummyPeer(randomNode()),
+	}
+	for i := 0; i < 20; i++ {
+		for _, p := range peers {
+			p.score.pullBlocks(hashes)
+			p.score.pullVotes(coreTypes.Position{Height: uint64(i)})
+			p.score.expire(time.Now().Add(pullTimeout + time.Second))
+		}
+	}
+	for _, p := range peers {
+		if p.score.missedPulls != 0 || p.score.score() != maxPeerScore {
+			t.Errorf("honest peer penalized: %+v", p.score.info())
+		}
+	}
+
+	// A peer having one of the blocks misses the request only once.
+	s := newPeerScore()
+	s.markBlock(hashes[1])
+	s.pullBlocks(hashes)
+	s.expire(time.Now().Add(pullTimeout + time.Second))
+	if s.missedPulls != 1 {
+		t.Errorf("missed pulls mismatch: got %d, want 1", s.missedPulls)
+	}
+
+	// Answering part of the request answers it.
+	s = newPeerScore()
+	s.markBlock(hashes[1])
+	s.pullBlocks(hashes)
+	s.deliverBlock(hashes[0], false)
+	s.expire(time.Now().Add(pullTimeout + time.Second))
+	if s.missedPulls != 0 || len(s.pendingBlocks) != 0 {
+		t.Errorf("answered request missed: %d, %d pending", s.missedPulls, len(s.pendingBlocks))
+	}
+}
+
+func TestSortPeersByScore(t *testing.T) {
+	healthy := newDummyPeer(randomNode())
+	unhealthy := newDummyPeer(randomNode())
+	unhealthy.score.markInvalid()
+
+	peers := sortPeersByScore([]*peer{unhealthy, healthy})
+	if peers[0] != healthy || peers[1] != unhealthy {
+		t.Errorf("peers not sorted by score")
+	}
+}
@@ -4,6 +4,7 @@
 // bigram_tracer.js (1.712kB)
 // call_tracer.js (8.596kB)
 // evmdis_tracer.js (4.194kB)
+// gov_tracer.js (2.169kB)
 // noop_tracer.js (1.271kB)
 // opcount_tracer.js (1.372kB)
 // prestate_tracer.js (3.892kB)
@@ -157,6 +158,26 @@ func evmdis_tracerJs() (*asset, error) {
 	return a, nil
 }
 
+var _gov_tracerJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x55\xdd\x6e\xdb\x38\x13\xbd\x96\x9e\xe2\x7c\xbe\x4a\x00\x7f\x52\x36\x3f\xea\xd6\xd9\x14\xf0\x16\x49\x1b\x6c\xea\x06\xb6\xb3\x45\xb0\xe8\x05\x2d\x8e\x24\xa2\x34\x29\x90\x94\x7f\x10\xf8\xdd\x17\xa4\x24\xdb\x5b\xa4\x45\xaf\x2c\x0e\x79\xce\x9c\x39\x1c\x8e\xd3\x14\xef\x75\xbd\x35\xa2\xac\x1c\xce\xcf\x7e\xfb\x1d\xf3\x8a\xc0\x69\xa3\xd5\xff\x73\xad\x2c\x29\xdb\x58\x8c\x1b\x57\x69\x63\xe3\x34\xc5\xbc\x12\x16\x85\x90\x04\x61\x51\x33\xe3\xa0\x0b\xb8\x57\x30\x52\x2c\x0c\x33\xdb\x24\x4e\xd3\x16\xf7\xc3\x23\x9e\xa9\x30\x44\xb0\xba\x70\x6b\x66\x68\x84\xad\x6e\x90\x33\x05\x43\x5c\x58\x67\xc4\xa2\x71\x04\xe1\x3c\x11\x53\x3c\xd5\x06\x4b\xcd\x45\xb1\x85\x70\x68\x14\x27\x13\x24\x38\x32\x4b\xdb\xeb\xf9\x30\x79\xc2\x03\x59\x4b\x06\x1f\x48\x91\x61\x12\x8f\xcd\x42\x8a\x1c\x0f\x22\x27\x65\x09\x2c\x14\x54\xfb\xa0\xad\x88\x63\xb1\x0d\x2c\x77\x5e\xca\xac\x93\x82\x3b\xdd\x28\xce\x9c\xd0\x6a\x08\x12\xae\x22\x83\x15\x19\x2b\xb4\xc2\x45\x9f\xaa\x23\x1c\x7a\x3a\x6d\x70\xc2\x9c\x2f\xc0\x40\xd7\x1e\x77\x0a\xa6\xb6\x90\xcc\x1d\xa0\xbf\x68\xca\xa1\x76\x0e\xa1\x82\xb8\x4a\xd7\x04\x57\x31\xe7\x2b\x5f\x0b\x29\xb1\x20\xcf\xd4\x58\x2a\x1a\x39\xc4\xa2\x71\xf8\x72\x3f\xff\xf8\xf9\x69\x8e\xf1\xe4\x19\x5f\xc6\xd3\xe9\x78\x32\x7f\xbe\xc6\x5a\xb8\x4a\x37\x0e\xb4\xa2\x96\x4a\x2c\x6b\x29\x88\x63\xcd\x8c\x61\xca\x6d\xa1\x0b\xcf\xf4\xe9\x76\xfa\xfe\xe3\x78\x32\x1f\xff\x79\xff\x70\x3f\x7f\x86\x36\xb8\xbb\x9f\x4f\x6e\x67\x33\xdc\x7d\x9e\x62\x8c\xc7\xf1\x74\x7e\xff\xfe\xe9\x61\x3c\xc5\xe3\xd3\xf4\xf1\xf3\xec\x36\xc1\x8c\xe8\x3b\xd3\x3d\xd5\x0f\x7c\x2f\xc2\xf5\x19\x5f\xba\x63\x42\xda\xde\x8d\x67\xdd\xc0\x56\xba\x91\x1c\x15\x5b\x11\x0c\xe5\x24\x56\xc4\xc1\x90\xeb\x7a\xfb\xcb\x17\xeb\xb9\x98\xd4\xaa\x0c\x35\xff\xb4\x39\x71\x5f\x40\x69\x37\x84\xa5\x60\xe3\x1f\x95\x73\xf5\x28\x4d\xd7\xeb\x75\x52\xaa\x26\xd1\xa6\x4c\x65\xcb\x6a\xd3\x77\x49\x1c\xfb\x43\xa5\x5e\xcd\x0d\xcb\xc9\x20\xd7\x52\x52\xee\x6c\xc8\x91\x33\x29\x2d\x84\x72\x3a\x2c\x4b\xbd\x22\xa3\x98\xca\x09\xb9\x56\xce\xb0\xdc\x7d\xa7\x4a\x18\xcf\xc6\x29\xd7\xdc\x17\x69\xca\x66\x49\xca\xd9\x21\xac\xd3\x86\x95\x84\xb5\x11\x8e\xec\x30\x5c\x99\xb3\x60\x8a\x83\x8c\xd1\x66\xef\xd8\xed\x86\x2d\x6b\x49\x23\xcf\x03\xbc\x03\xa7\x45\x53\x26\x3e\x17\xcd\x0d\x53\x96\xe5\xbe\x01\x4f\x06\x67\x9b\x24\x49\x06\x43\xbc\x84\x2d\x33\xc2\x60\x5f\xc3\x60\x77\xda\xa2\x5f\xda\x1f\xa0\x30\x7a\x39\x42\x8f\xe9\xa3\x4e\x87\xd8\xd5\x9b\xec\x2a\x7b\x7b\xce\x2f\x29\x7b\x9b\x51\xf6\xe6\xfc\xec\xea\x22\x2b\x32\xbf\xba\x38\x3f\xbb\x3c\xcf\x8a\x8c\x65\x6f\xb3\xab\x03\xb0\x64\xf6\xc9\x12\x1f\xe1\x4d\x76\x71\x79\xb6\x0f\x07\xb7\x46\xf8\x67\x9f\x16\x58\x92\xab\x34\x1f\x61\x60\x1d\xfb\x46\x07\x06\x78\x6f\xec\x08\x2f\xed\x35\xff\x45\xdb\x20\xe5\xec\xb2\x2d\x6a\xc2\x96\x34\xc2\x40\x69\x4e\x83\x21\x92\x24\xd9\x1d\x21\x3b\x2b\x7d\x22\x2b\xb5\x3b\xd4\x85\x15\x93\x0d\xed\xd7\xbb\x80\xfc\x7a\x84\x6c\x4d\xf7\x40\xd5\x26\x98\x79\x55\x7c\x30\xec\xd5\x4c\x34\xa7\x31\xe7\x86\xac\x3d\xd0\xec\x8e\x29\x92\x24\xe9\x17\xbb\xaf\xed\xd7\x2e\x7e\x89\xa3\x34\xed\x9b\xa5\xed\x1c\x29\x6c\x18\xa3\xaf\xb5\x4c\x7b\x70\xc9\x38\xf5\xf3\xc9\x1d\x6e\x36\x89\xa3\xde\xc7\xaf\xc3\x38\x10\x1f\x71\x08\xdf\x8d\x2b\xfd\x8d\x78\x78\x72\xb4\x22\xb3\x0d\x89\x7f\xda\xa4\x49\x1c\x1d\xa2\x23\x14\x8d\x6a\x9b\xc8\x03\x87\xe0\x8b\x53\xbc\xc4\x51\xe4\x2a\x61\x13\x1f\xb2\x49\xdd\xd8\xea\xc4\x7f\x9e\x5e\xc7\xd1\xae\x93\x61\x1d\xd5\xaf\x0b\xd0\xb5\xef\xf7\x76\x86\x79\x09\x7f\x7f\x02\x6d\x28\x6f\x1c\xd9\x24\x8e\x3c\xee\x28\xa9\xd4\x65\x97\x13\x3d\x73\xc1\x1a\xe9\x8e\xa9\xd7\x55\x37\xd0\x58\xee\x1a\x26\x3b\x36\x3f\xa0\x75\x01\xa6\xfa\x84\x45\x3b\x6a\xa2\x80\xff\x79\x0a\x43\xf6\xb5\x1c\xde\x39\xaf\xb8\x25\xb4\xed\x90\x5a\x10\x29\x08\x47\x86\xf9\x29\xed\x8d\x0b\xef\xd4\x90\x6b\x8c\xb2\xc1\x0b\x8f\x29\x84\x62\xb2\x27\xee\x06\x99\xb7\x5b\xa8\x32\x89\xa3\x36\x7e\x24\x2a\x77\x9b\x4e\x54\x1c\x45\x2b\x66\x7a\xe4\x4d\x30\x3f\x6a\x9f\x68\x78\x95\x1f\x69\x73\x92\xbb\x4d\xe2\x43\xa7\x43\xbf\xe9\x5f\x2a\xfe\xbb\xe9\x74\xbb\xb5\x7f\x8b\x1e\xd1\x2d\xc2\x46\xd7\x45\xc0\xe1\x5e\x7d\x7c\x77\x1d\x47\x91\x28\x10\x48\xc2\xe8\xc1\xff\x6e\x6e\xc2\x3f\x6e\x21\x14\xf1\x56\x5f\x27\xbf\x3b\x70\x83\xfd\x61\x8f\xde\xc5\x51\xd4\x9a\xd1\xd5\x70\x1d\x47\xbb\x78\x17\xff\x3b\x00\xc6\x29\xba\x48\x79\x08\x00\x00")
+
+func gov_tracerJsBytes() ([]byte, error) {
+	return bindataRead(
+		_gov_tracerJs,
+		"gov_tracer.js",
+	)
+}
+
+func gov_tracerJs() (*asset, error) {
+	bytes, err := gov_tracerJsBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	info := bindataFileInfo{name: "gov_tracer.js", size: 0, mode: os.FileMode(0), modTime: time.Unix(0, 0)}
+	a := &asset{bytes: bytes, info: info, digest: [32]uint8{0x80, 0x13, 0xd8, 0xc3, 0x9, 0x33, 0x88, 0x27, 0x64, 0xdf, 0x81, 0x9, 0xb7, 0x4b, 0x13, 0x3c, 0xac, 0x8f, 0xd, 0xfb, 0xc, 0x74, 0xa3, 0x98, 0xd1, 0xc, 0x9b, 0x3, 0x17, 0x55, 0x1b, 0x88}}
+	return a, nil
+}
+
 var _noop_tracerJs = []byte("\x1f\x8b\x08\x00\x00\x00\x00\x00\x00\xff\x8c\x93\x4f\x6f\xdb\x46\x10\xc5\xcf\xe6\xa7\x78\xc7\x04\x50\xc5\xfe\x39\x14\x70\x8a\x02\xac\x61\x27\x2a\x1c\xdb\x90\xe8\x06\x3e\x0e\xc9\xa1\xb8\xe9\x6a\x87\x9d\x9d\x95\x22\x18\xfe\xee\xc5\x92\x12\x12\x14\x69\x9b\x9b\xb0\xd2\xfb\xbd\x37\xf3\x46\x65\x89\x2b\x19\x8f\xea\xb6\x83\xe1\xc7\xef\x7f\xf8\x19\xf5\xc0\xd8\xca\x77\x6c\x03\x2b\xa7\x1d\xaa\x64\x83\x68\x2c\xca\x12\xf5\xe0\x22\x7a\xe7\x19\x2e\x62\x24\x35\x48\x0f\xfb\xc7\xef\xbd\x6b\x94\xf4\xb8\x2c\xca\x72\xd6\x7c\xf5\xeb\x4c\xe8\x95\x19\x51\x7a\x3b\x90\xf2\x25\x8e\x92\xd0\x52\x80\x72\xe7\xa2\xa9\x6b\x92\x31\x9c\x81\x42\x57\x8a\x62\x27\x9d\xeb\x8f\x19\xe9\x0c\x29\x74\xac\x93\xb5\xb1\xee\xe2\x39\xc7\xdb\xbb\x47\xdc\x72\x8c\xac\x78\xcb\x81\x95\x3c\x1e\x52\xe3\x5d\x8b\x5b\xd7\x72\x88\x0c\x8a\x18\xf3\x4b\x1c\xb8\x43\x33\xe1\xb2\xf0\x26\x47\xd9\x9c\xa2\xe0\x46\x52\xe8\xc8\x9c\x84\x05\xd8\xe5\xe4\xd8\xb3\x46\x27\x01\x3f\x9d\xad\x4e\xc0\x05\x44\x33\xe4\x15\x59\x1e\x40\x21\x63\xd6\xbd\x06\x85\x23\x3c\xd9\x67\xe9\x37\x2c\xe4\xf3\xdc\x1d\x5c\x98\x6c\x06\x19\x19\x36\x90\xe5\xa9\x0f\xce\x7b\x34\x8c\x14\xb9\x4f\x7e\x91\x69\x4d\x32\x7c\x58\xd5\xef\xee\x1f\x6b\x54\x77\x4f\xf8\x50\xad\xd7\xd5\x5d\xfd\xf4\x06\x07\x67\x83\x24\x03\xef\x79\x46\xb9\xdd\xe8\x1d\x77\x38\x90\x2a\x05\x3b\x42\xfa\x4c\x78\x7f\xbd\xbe\x7a\x57\xdd\xd5\xd5\x6f\xab\xdb\x55\xfd\x04\x51\xdc\xac\xea\xbb\xeb\xcd\x06\x37\xf7\x6b\x54\x78\xa8\xd6\xf5\xea\xea\xf1\xb6\x5a\xe3\xe1\x71\xfd\x70\xbf\xb9\x5e\x62\xc3\x39\x15\x67\xfd\xff\xef\xbc\x9f\xda\x53\x46\xc7\x46\xce\xc7\xf3\x26\x9e\x24\x21\x0e\x92\x7c\x87\x81\xf6\x0c\xe5\x96\xdd\x9e\x3b\x10\x5a\x19\x8f\xdf\x5c\x6a\x66\x91\x97\xb0\x9d\x66\xfe\xd7\x83\xc4\xaa\x47\x10\x5b\x20\x32\xe3\x97\xc1\x6c\xbc\x2c\xcb\xc3\xe1\xb0\xdc\x86\xb4\x14\xdd\x96\x7e\xc6\xc5\xf2\xd7\x65\x91\x99\x41\x64\xac\x95\x5a\xd6\x5c\xce\xc7\x14\x6d\x62\x37\xa4\xdc\x48\x60\x34\xe2\x3c\xeb\x98\x5b\x46\x2b\x5d\x1e\xe0\xaf\xe4\x94\x3b\xf4\x2a\x3b\x10\x7e\xa7\x3d\x6d\x5a\x75\xa3\x65\x9c\x34\x1f\xb9\x35\x98\xcc\x15\x52\xe3\xa7\x73\x24\x98\x52\x88\xd4\xe6\xbb\xc9\x9f\x5b\xd6\x65\xf1\x5c\x5c\x94\x25\xa2\xf1\x98\xbd\x5d\xd8\xcb\x9f\x99\x2b\x9a\xfb\xd4\x23\x64\x9c\x1c\xa7\xcb\xc8\xa1\xfe\x78\x0f\xfe\xc4\x6d\x32\x8e\xcb\xe2\x22\xeb\x2e\xd1\xa7\x30\x41\x5f\x79\xd9\x2e\xd0\x35\xaf\xf1\x8c\x97\x45\x31\x91\x7b\x4a\xde\xbe\x44\x1f\x86\xd3\x99\x50\x6b\x89\xfc\x89\x96\x23\x49\x0f\x0a\x67\xc3\x7e\x2e\xf0\x62\xd2\xff\xb7\x85\x72\xfc\x9a\x07\x79\x3f\xf9\xcc\xc0\x38\x57\xdf\x30\x07\x38\x63\xa5\x7c\xfb\xb2\x67\xcd\x7f\x7b\x28\x5b\xd2\x10\x27\x5c\xd6\xf4\x2e\x90\x3f\x83\x4f\xe7\x91\x37\xe6\xc2\x76\x59\x5c\xcc\xef\x5f\x84\x6a\xed\xd3\x39\xd4\x4c\xc2\xf3\xcb\x1b\xbc\x14\x2f\xc5\xdf\x01\x00\x00\xff\xff\x77\x56\xe7\x1a\xf7\x04\x00\x00")
 
 func noop_tracerJsBytes() ([]byte, error) {
@@ -356,6 +377,8 @@ var _bindata = map[string]func() (*asset, error){
 
 	"evmdis_tracer.js": evmdis_tracerJs,
 
+	"gov_tracer.js": gov_tracerJs,
+
 	"noop_tracer.js": noop_tracerJs,
 
 	"opcount_tracer.js": opcount_tracerJs,
@@ -412,6 +435,7 @@ var _bintree = &bintree{nil, map[string]*bintree{
 	"bigram_tracer.js":   {bigram_tracerJs, map[string]*bintree{}},
 	"call_tracer.js":     {call_tracerJs, map[string]*bintree{}},
 	"evmdis_tracer.js":   {evmdis_tracerJs, map[string]*bintree{}},
+	"gov_tracer.js":      {gov_tracerJs, map[string]*bintree{}},
 	"noop_tracer.js":     {noop_tracerJs, map[string]*bintree{}},
 	"opcount_tracer.js":  {opcount_tracerJs, map[string]*bintree{}},
 	"prestate_tracer.js": {prestate_tracerJs, map[string]*bintree{}},
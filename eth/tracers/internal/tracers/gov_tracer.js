@@ -0,0 +1,65 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+
+// govTracer collects the calls into the governance contract along with their
+// decoded arguments, storage writes, events and errors.
+//
+// Example:
+//   > debug.traceTransaction("0x...", {tracer: "govTracer"})
+//   {
+//     from: "0x...",
+//     to: "0x5765692d4e696e6720536f6e696320426f6a6965",
+//     gasUsed: 76340,
+//     calls: [{
+//       method: "stake",
+//       args: {PublicKey: "0x04...", Name: "node", ...},
+//       storage: [{slot: "0x...", value: "0x..."}, ...],
+//       events: [{name: "Staked", args: {NodeAddress: "0x..."}}],
+//       ...
+//     }]
+//   }
+{
+	// calls is the list of governance contract calls made by the transaction.
+	calls: [],
+
+	// governance is invoked for every call into the governance contract.
+	governance: function(call, db) {
+		this.calls.push(call);
+	},
+
+	// step is invoked for every opcode that the VM executes.
+	step: function(log, db) { },
+
+	// fault is invoked when the actual execution of an opcode fails.
+	fault: function(log, db) { },
+
+	// result is invoked when all the opcodes have been iterated over and returns
+	// the final result of the tracing.
+	result: function(ctx, db) {
+		var result = {
+			from:    toHex(ctx.from),
+			to:      toHex(ctx.to),
+			gasUsed: ctx.gasUsed,
+			calls:   this.calls,
+		};
+		if (ctx.error !== undefined) {
+			result.error = ctx.error;
+		}
+		return result;
+	}
+}
@@ -284,6 +284,8 @@ type Tracer struct {
 	tracerObject int // Stack index of the tracer JavaScript object
 	stateObject  int // Stack index of the global state to pull arguments from
 
+	traceGovernance bool // Whether the tracer exposes a governance function
+
 	opWrapper       *opWrapper       // Wrapper around the VM opcode
 	stackWrapper    *stackWrapper    // Wrapper around the VM stack
 	memoryWrapper   *memoryWrapper   // Wrapper around the VM memory
@@ -306,7 +308,7 @@ type Tracer struct {
 
 // New instantiates a new tracer instance. code specifies a Javascript snippet,
 // which must evaluate to an expression returning an object with 'step', 'fault'
-// and 'result' functions.
+// and 'result' functions, and optionally a 'governance' function.
 func New(code string) (*Tracer, error) {
 	// Resolve any tracers by name and assemble the tracer object
 	if tracer, ok := tracer(code); ok {
@@ -411,6 +413,10 @@ func New(code string) (*Tracer, error) {
 	}
 	tracer.vm.Pop()
 
+	// The governance function is optional, only call it when defined
+	tracer.traceGovernance = tracer.vm.GetPropString(tracer.tracerObject, "governance")
+	tracer.vm.Pop()
+
 	// Tracer is valid, inject the big int library to access large numbers
 	tracer.vm.EvalString(bigIntegerJS)
 	tracer.vm.PutGlobalString("bigInt")
@@ -563,6 +569,39 @@ func (jst *Tracer) CaptureFault(env *vm.EVM, pc uint64, op vm.OpCode, gas, cost
 	return nil
 }
 
+// CaptureGovernance implements the GovernanceTracer interface to trace a call
+// into the governance contract. The decoded call is passed to the governance
+// function of the tracer as a plain JavaScript object.
+func (jst *Tracer) CaptureGovernance(env *vm.EVM, call *vm.GovernanceCall) error {
+	if jst.err == nil && jst.traceGovernance {
+		// Initialize the context if it wasn't done yet
+		if !jst.inited {
+			jst.ctx["block"] = env.BlockNumber.Uint64()
+			jst.inited = true
+		}
+		// If tracing was interrupted, set the error and stop
+		if atomic.LoadUint32(&jst.interrupt) > 0 {
+			jst.err = jst.reason
+			return nil
+		}
+		blob, err := json.Marshal(call)
+		if err != nil {
+			jst.err = wrapError("governance", err)
+			return nil
+		}
+		jst.vm.PushString(string(blob))
+		jst.vm.JsonDecode(-1)
+		jst.vm.PutPropString(jst.stateObject, "call")
+
+		jst.dbWrapper.db = env.StateDB
+
+		if _, err := jst.call("governance", "call", "db"); err != nil {
+			jst.err = wrapError("governance", err)
+		}
+	}
+	return nil
+}
+
 // CaptureEnd is called after the call finishes to finalize the tracing.
 func (jst *Tracer) CaptureEnd(output []byte, gasUsed uint64, t time.Duration, err error) error {
 	jst.ctx["output"] = output
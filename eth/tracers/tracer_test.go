@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
 	"github.com/dexon-foundation/dexon/core/state"
 	"github.com/dexon-foundation/dexon/core/vm"
 	"github.com/dexon-foundation/dexon/params"
@@ -145,3 +146,57 @@ func TestHaltBetweenSteps(t *testing.T) {
 		t.Errorf("Expected timeout error, got %v", err)
 	}
 }
+
+func TestGovernance(t *testing.T) {
+	tracer, err := New("govTracer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	env := vm.NewEVM(vm.Context{BlockNumber: big.NewInt(1)}, &dummyStatedb{}, params.TestChainConfig, vm.Config{Debug: true, Tracer: tracer})
+
+	from := common.HexToAddress("0x1234")
+	tracer.CaptureStart(from, vm.GovernanceContractAddress, false, nil, 100000, big.NewInt(0))
+	tracer.CaptureGovernance(env, &vm.GovernanceCall{
+		From:    from,
+		Value:   (*hexutil.Big)(big.NewInt(0)),
+		Depth:   1,
+		Method:  "unstake",
+		Args:    map[string]interface{}{},
+		Gas:     100000,
+		GasUsed: 21000,
+		Storage: []vm.GovernanceStorage{{Slot: common.Hash{1}, Value: common.Hash{2}}},
+		Events: []*vm.GovernanceEvent{{
+			Name: "Unstaked",
+			Args: map[string]interface{}{"NodeAddress": from},
+		}},
+	})
+	tracer.CaptureEnd(nil, 21000, time.Second, nil)
+
+	ret, err := tracer.GetResult()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var result struct {
+		From  common.Address
+		Calls []vm.GovernanceCall
+	}
+	if err := json.Unmarshal(ret, &result); err != nil {
+		t.Fatalf("failed to unmarshal result %s: %v", ret, err)
+	}
+	if result.From != from {
+		t.Errorf("from mismatch: have %x, want %x", result.From, from)
+	}
+	if len(result.Calls) != 1 {
+		t.Fatalf("call count mismatch: have %d, want 1", len(result.Calls))
+	}
+	call := result.Calls[0]
+	if call.Method != "unstake" || call.GasUsed != 21000 {
+		t.Errorf("call mismatch: %s", ret)
+	}
+	if len(call.Storage) != 1 || call.Storage[0].Value != (common.Hash{2}) {
+		t.Errorf("storage mismatch: %s", ret)
+	}
+	if len(call.Events) != 1 || call.Events[0].Name != "Unstaked" {
+		t.Errorf("events mismatch: %s", ret)
+	}
+}
@@ -18,9 +18,14 @@ package ethdb
 
 import (
 	"errors"
+	"strings"
 	"sync"
 
 	"github.com/dexon-foundation/dexon/common"
+	"github.com/syndtr/goleveldb/leveldb/comparer"
+	"github.com/syndtr/goleveldb/leveldb/iterator"
+	"github.com/syndtr/goleveldb/leveldb/memdb"
+	"github.com/syndtr/goleveldb/leveldb/util"
 )
 
 /*
@@ -80,6 +85,21 @@ func (db *MemDatabase) Keys() [][]byte {
 	return keys
 }
 
+// NewIteratorWithPrefix returns a iterator to iterate over a snapshot of the
+// database content with a particular prefix, in key order.
+func (db *MemDatabase) NewIteratorWithPrefix(prefix []byte) iterator.Iterator {
+	db.lock.RLock()
+	defer db.lock.RUnlock()
+
+	snapshot := memdb.New(comparer.DefaultComparer, 0)
+	for key, value := range db.db {
+		if strings.HasPrefix(key, string(prefix)) {
+			snapshot.Put([]byte(key), value)
+		}
+	}
+	return snapshot.NewIterator(util.BytesPrefix(prefix))
+}
+
 func (db *MemDatabase) Delete(key []byte) error {
 	db.lock.Lock()
 	defer db.lock.Unlock()
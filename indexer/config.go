@@ -1,6 +1,8 @@
 package indexer
 
 import (
+	"errors"
+	"fmt"
 	"plugin"
 )
 
@@ -14,25 +16,78 @@ type Config struct {
 
 	// PluginFlags for construction if needed.
 	PluginFlags string
+
+	// Indexers to run besides the one of Plugin.
+	Indexers []IndexerConfig
+}
+
+// IndexerConfig selects one indexer, either compiled in and registered by
+// Name or loaded from Plugin.
+type IndexerConfig struct {
+	// Name of a registered indexer, or of the plugin for status reports.
+	Name string
+
+	// Plugin path, the indexer is looked up by Name if empty.
+	Plugin string
+
+	// Flags passed to the indexer as Config.PluginFlags.
+	Flags string
+}
+
+// configs returns the configs of all indexers to run.
+func (c Config) configs() []IndexerConfig {
+	configs := c.Indexers
+	if c.Plugin != "" {
+		configs = append([]IndexerConfig{{
+			Name:   c.Plugin,
+			Plugin: c.Plugin,
+			Flags:  c.PluginFlags,
+		}}, configs...)
+	}
+	return configs
 }
 
 // NewIndexerFromConfig initialize exporter according to given config.
-func NewIndexerFromConfig(bc ReadOnlyBlockChain, c Config) (idx Indexer) {
-	if c.Plugin == "" {
-		// default
-		return
+func NewIndexerFromConfig(bc ReadOnlyBlockChain, c Config, ic IndexerConfig) (Indexer, error) {
+	fn, err := newIndexerFunc(ic)
+	if err != nil {
+		return nil, err
 	}
+	c.Plugin = ic.Plugin
+	c.PluginFlags = ic.Flags
+	c.Indexers = nil
+	idx := fn(bc, c)
+	if idx == nil {
+		return nil, fmt.Errorf("indexer %q: nil indexer created", ic.Name)
+	}
+	return idx, nil
+}
 
-	plug, err := plugin.Open(c.Plugin)
+func newIndexerFunc(ic IndexerConfig) (NewIndexerFunc, error) {
+	if ic.Plugin == "" {
+		if ic.Name == "" {
+			return nil, errors.New("indexer has neither name nor plugin")
+		}
+		return lookup(ic.Name)
+	}
+
+	plug, err := plugin.Open(ic.Plugin)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
 	symbol, err := plug.Lookup(NewIndexerFuncName)
 	if err != nil {
-		panic(err)
+		return nil, err
 	}
 
-	idx = symbol.(NewIndexerFunc)(bc, c)
-	return
+	// Plugins export either the function or a variable holding it.
+	switch fn := symbol.(type) {
+	case NewIndexerFunc:
+		return fn, nil
+	case *NewIndexerFunc:
+		return *fn, nil
+	}
+	return nil, fmt.Errorf("plugin %s: %s has type %T, want %T",
+		ic.Plugin, NewIndexerFuncName, symbol, NewIndexerFunc(nil))
 }
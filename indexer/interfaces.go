@@ -17,3 +17,13 @@ type Indexer interface {
 	// terminating.
 	Stop() error
 }
+
+// HealthReporter is implemented by indexers able to report failures after
+// they started, e.g. a lost database connection. Indexers reporting an error
+// are restarted by the supervisor.
+type HealthReporter interface {
+	// Health returns the error the indexer is stuck on, nil if healthy. It
+	// is also called once the indexer is created, an error then being a
+	// permanent one, e.g. invalid flags, that fails the node startup.
+	Health() error
+}
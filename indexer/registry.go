@@ -0,0 +1,49 @@
+package indexer
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+var (
+	registryLock sync.RWMutex
+	registry     = make(map[string]NewIndexerFunc)
+)
+
+// Register makes an indexer compiled into the binary available by name,
+// usually from the init function of the package implementing it. It panics
+// if the name is registered twice.
+func Register(name string, fn NewIndexerFunc) {
+	registryLock.Lock()
+	defer registryLock.Unlock()
+	if fn == nil {
+		panic("indexer: Register function is nil")
+	}
+	if _, dup := registry[name]; dup {
+		panic("indexer: Register called twice for " + name)
+	}
+	registry[name] = fn
+}
+
+// Registered returns the sorted names of the registered indexers.
+func Registered() []string {
+	registryLock.RLock()
+	defer registryLock.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func lookup(name string) (NewIndexerFunc, error) {
+	registryLock.RLock()
+	fn, ok := registry[name]
+	registryLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown indexer %q, registered: %v", name, Registered())
+	}
+	return fn, nil
+}
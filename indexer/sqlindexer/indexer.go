@@ -0,0 +1,377 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package sqlindexer implements an indexer exporting the chain into SQL
+// tables through database/sql. The SQL used is PostgreSQL compatible and
+// also runs on SQLite, the database driver is linked in by the binary or
+// plugin importing this package. Importing the package registers the
+// indexer under Name.
+//
+// DEXON blocks are final once delivered, the indexer assumes the chain never
+// reorganizes: blocks are indexed in order and never rewritten. A block not
+// following the last indexed one stops the indexing with an error.
+package sqlindexer
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+
+	coreTypes "github.com/dexon-foundation/dexon-consensus/core/types"
+
+	"github.com/dexon-foundation/dexon/accounts/abi"
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/indexer"
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/rlp"
+)
+
+// Name is the name the indexer is registered under.
+const Name = "sql"
+
+// chainHeadChanSize is the size of channel listening to ChainHeadEvent.
+const chainHeadChanSize = 10
+
+var (
+	errInvalidFlags = errors.New("plugin flags must be <driver>:<dsn>")
+	errReorg        = errors.New("block does not follow the last indexed block")
+)
+
+// governanceEvents maps the event IDs of the governance contract to names.
+var governanceEvents = func() map[common.Hash]string {
+	govABI, err := abi.JSON(strings.NewReader(vm.GovernanceABIJSON))
+	if err != nil {
+		panic(err)
+	}
+	events := make(map[common.Hash]string)
+	for _, event := range govABI.Events {
+		events[event.Id()] = event.Name
+	}
+	return events
+}()
+
+func init() {
+	indexer.Register(Name, NewIndexer)
+}
+
+// Indexer exports the blocks, transactions, receipts, logs, rounds, lattice
+// positions and governance events of the chain into SQL tables.
+type Indexer struct {
+	bc     indexer.ReadOnlyBlockChain
+	driver string
+	dsn    string
+	err    error // Error of the plugin flags, reported by Start
+
+	db *sql.DB
+
+	// Last indexed block, ok is false if nothing is indexed.
+	last struct {
+		ok     bool
+		number uint64
+		hash   common.Hash
+		round  uint64
+	}
+
+	healthLock sync.Mutex
+	health     error // Error the indexing stopped on
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewIndexer creates an indexer from the plugin flags of the config, which
+// are the database/sql driver name and the data source name separated by
+// the first colon, e.g. "postgres:postgres://user@localhost/dexon".
+func NewIndexer(bc indexer.ReadOnlyBlockChain, c indexer.Config) indexer.Indexer {
+	i := &Indexer{bc: bc}
+	parts := strings.SplitN(c.PluginFlags, ":", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		i.err = errInvalidFlags
+		return i
+	}
+	i.driver, i.dsn = parts[0], parts[1]
+	return i
+}
+
+// Start migrates the schema and starts indexing from the block following
+// the last indexed one.
+func (i *Indexer) Start() error {
+	if i.err != nil {
+		return i.err
+	}
+	db, err := sql.Open(i.driver, i.dsn)
+	if err != nil {
+		return err
+	}
+	if err := migrate(db); err != nil {
+		db.Close()
+		return err
+	}
+	i.db = db
+	if err := i.loadLast(); err != nil {
+		db.Close()
+		return err
+	}
+	if i.last.ok {
+		log.Info("Resuming SQL indexer", "number", i.last.number, "hash", i.last.hash)
+	}
+
+	i.healthLock.Lock()
+	i.health = nil
+	i.healthLock.Unlock()
+
+	i.quit = make(chan struct{})
+	headCh := make(chan core.ChainHeadEvent, chainHeadChanSize)
+	sub := i.bc.SubscribeChainHeadEvent(headCh)
+	i.wg.Add(1)
+	go func() {
+		defer i.wg.Done()
+		defer sub.Unsubscribe()
+		i.loop(headCh, sub.Err())
+	}()
+	return nil
+}
+
+// Stop stops indexing and closes the database.
+func (i *Indexer) Stop() error {
+	if i.quit == nil {
+		return nil
+	}
+	close(i.quit)
+	i.wg.Wait()
+	i.quit = nil
+	return i.db.Close()
+}
+
+func (i *Indexer) loop(headCh <-chan core.ChainHeadEvent, errCh <-chan error) {
+	if err := i.catchUp(i.bc.CurrentBlock().NumberU64()); err != nil {
+		i.fail(err)
+		return
+	}
+	for {
+		select {
+		case ev := <-headCh:
+			if err := i.catchUp(ev.Block.NumberU64()); err != nil {
+				i.fail(err)
+				return
+			}
+		case <-errCh:
+			return
+		case <-i.quit:
+			return
+		}
+	}
+}
+
+func (i *Indexer) fail(err error) {
+	log.Error("Failed to index blocks", "err", err)
+	i.healthLock.Lock()
+	i.health = err
+	i.healthLock.Unlock()
+}
+
+// Health implements indexer.HealthReporter, invalid plugin flags are
+// reported before the indexing stops on an error.
+func (i *Indexer) Health() error {
+	if i.err != nil {
+		return i.err
+	}
+	i.healthLock.Lock()
+	defer i.healthLock.Unlock()
+	return i.health
+}
+
+// loadLast loads the last indexed block.
+func (i *Indexer) loadLast() error {
+	var (
+		number, round int64
+		hash          string
+	)
+	err := i.db.QueryRow(`SELECT number, hash, round FROM blocks
+		ORDER BY number DESC LIMIT 1`).Scan(&number, &hash, &round)
+	switch err {
+	case sql.ErrNoRows:
+		return nil
+	case nil:
+	default:
+		return err
+	}
+	i.last.ok = true
+	i.last.number = uint64(number)
+	i.last.hash = common.HexToHash(hash)
+	i.last.round = uint64(round)
+	return nil
+}
+
+// catchUp indexes the blocks following the last indexed one up to number.
+func (i *Indexer) catchUp(number uint64) error {
+	next := uint64(0)
+	if i.last.ok {
+		next = i.last.number + 1
+	}
+	for ; next <= number; next++ {
+		select {
+		case <-i.quit:
+			return nil
+		default:
+		}
+		block := i.bc.GetBlockByNumber(next)
+		if block == nil {
+			return fmt.Errorf("block #%d not found", next)
+		}
+		if err := i.indexBlock(block); err != nil {
+			return fmt.Errorf("block #%d [%x…]: %v", next, block.Hash().Bytes()[:4], err)
+		}
+	}
+	return nil
+}
+
+// indexBlock writes the block and everything derived from it in a single
+// database transaction, so the last indexed block is always complete.
+func (i *Indexer) indexBlock(block *types.Block) error {
+	if i.last.ok && block.ParentHash() != i.last.hash {
+		return errReorg
+	}
+	receipts := i.bc.GetReceiptsByHash(block.Hash())
+	if len(receipts) != len(block.Transactions()) {
+		return fmt.Errorf("receipts mismatch: have %d, want %d",
+			len(receipts), len(block.Transactions()))
+	}
+	signer := types.MakeSigner(i.bc.Config(), block.Number())
+
+	tx, err := i.db.Begin()
+	if err != nil {
+		return err
+	}
+	if err := i.writeBlock(tx, block, receipts, signer); err != nil {
+		tx.Rollback()
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	i.last.ok = true
+	i.last.number = block.NumberU64()
+	i.last.hash = block.Hash()
+	i.last.round = block.Round()
+	return nil
+}
+
+func (i *Indexer) writeBlock(tx *sql.Tx, block *types.Block,
+	receipts types.Receipts, signer types.Signer) error {
+	number := block.NumberU64()
+	if _, err := tx.Exec(`INSERT INTO blocks (number, hash, parent_hash,
+		round, timestamp, coinbase, gas_limit, gas_used, randomness, tx_count)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+		number, block.Hash().Hex(), block.ParentHash().Hex(), block.Round(),
+		block.Time().Uint64(), block.Coinbase().Hex(), block.GasLimit(), block.GasUsed(),
+		hexutil.Encode(block.Randomness()), len(block.Transactions())); err != nil {
+		return err
+	}
+	if !i.last.ok || block.Round() != i.last.round {
+		if _, err := tx.Exec(`INSERT INTO rounds (round, height)
+			VALUES ($1, $2)`, block.Round(), number); err != nil {
+			return err
+		}
+	}
+	if meta := block.Header().DexconMeta; len(meta) > 0 {
+		var coreBlock coreTypes.Block
+		if err := rlp.DecodeBytes(meta, &coreBlock); err != nil {
+			return err
+		}
+		if _, err := tx.Exec(`INSERT INTO lattice_positions (block_number,
+			core_hash, proposer, round, chain_id, height)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			number, hexutil.Encode(coreBlock.Hash[:]),
+			hexutil.Encode(coreBlock.ProposerID.Hash[:]),
+			coreBlock.Position.Round, coreBlock.Position.ChainID,
+			coreBlock.Position.Height); err != nil {
+			return err
+		}
+	}
+	for index, t := range block.Transactions() {
+		from, err := types.Sender(signer, t)
+		if err != nil {
+			return err
+		}
+		var to *string
+		if t.To() != nil {
+			hex := t.To().Hex()
+			to = &hex
+		}
+		if _, err := tx.Exec(`INSERT INTO transactions (hash, block_number,
+			tx_index, from_address, to_address, value, gas, gas_price, nonce,
+			input) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)`,
+			t.Hash().Hex(), number, index, from.Hex(), to, t.Value().String(),
+			t.Gas(), t.GasPrice().String(), t.Nonce(),
+			hexutil.Encode(t.Data())); err != nil {
+			return err
+		}
+		if err := writeReceipt(tx, number, receipts[index]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeReceipt(tx *sql.Tx, number uint64, receipt *types.Receipt) error {
+	var contract *string
+	if receipt.ContractAddress != (common.Address{}) {
+		hex := receipt.ContractAddress.Hex()
+		contract = &hex
+	}
+	if _, err := tx.Exec(`INSERT INTO receipts (tx_hash, block_number, status,
+		cumulative_gas_used, gas_used, contract_address)
+		VALUES ($1, $2, $3, $4, $5, $6)`,
+		receipt.TxHash.Hex(), number, receipt.Status,
+		receipt.CumulativeGasUsed, receipt.GasUsed, contract); err != nil {
+		return err
+	}
+	for _, l := range receipt.Logs {
+		topics := make([]string, len(l.Topics))
+		for j, topic := range l.Topics {
+			topics[j] = topic.Hex()
+		}
+		joined, data := strings.Join(topics, ","), hexutil.Encode(l.Data)
+		if _, err := tx.Exec(`INSERT INTO logs (block_number, log_index,
+			tx_hash, address, topics, data) VALUES ($1, $2, $3, $4, $5, $6)`,
+			number, l.Index, receipt.TxHash.Hex(), l.Address.Hex(),
+			joined, data); err != nil {
+			return err
+		}
+		if l.Address != vm.GovernanceContractAddress || len(l.Topics) == 0 {
+			continue
+		}
+		name, ok := governanceEvents[l.Topics[0]]
+		if !ok {
+			continue
+		}
+		if _, err := tx.Exec(`INSERT INTO governance_events (block_number,
+			log_index, tx_hash, name, topics, data)
+			VALUES ($1, $2, $3, $4, $5, $6)`,
+			number, l.Index, receipt.TxHash.Hex(), name, joined, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,255 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package sqlindexer
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"io"
+	"math/big"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/consensus/ethash"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/indexer"
+	"github.com/dexon-foundation/dexon/params"
+)
+
+// testDriver is a database/sql driver recording inserted rows, since no SQL
+// database is vendored. It runs no SQL and answers the queries of the
+// indexer only, the SQL itself is tested against SQLite in sqlite_test.go
+// under the sqlite build tag.
+type testDriver struct {
+	lock sync.Mutex
+	dbs  map[string]map[string][][]driver.Value
+}
+
+var testDB = &testDriver{dbs: make(map[string]map[string][][]driver.Value)}
+
+func init() {
+	sql.Register("sqlindexertest", testDB)
+}
+
+func (d *testDriver) rows(dsn, table string) [][]driver.Value {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	return d.dbs[dsn][table]
+}
+
+func (d *testDriver) reset(dsn string) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	delete(d.dbs, dsn)
+}
+
+func (d *testDriver) Open(dsn string) (driver.Conn, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.dbs[dsn] == nil {
+		d.dbs[dsn] = make(map[string][][]driver.Value)
+	}
+	return &testConn{d: d, tables: d.dbs[dsn]}, nil
+}
+
+type testConn struct {
+	d      *testDriver
+	tables map[string][][]driver.Value
+}
+
+func (c *testConn) Prepare(query string) (driver.Stmt, error) {
+	return &testStmt{c: c, query: strings.Join(strings.Fields(query), " ")}, nil
+}
+func (c *testConn) Close() error              { return nil }
+func (c *testConn) Begin() (driver.Tx, error) { return c, nil }
+func (c *testConn) Commit() error             { return nil }
+func (c *testConn) Rollback() error           { return nil }
+
+type testStmt struct {
+	c     *testConn
+	query string
+}
+
+func (s *testStmt) Close() error  { return nil }
+func (s *testStmt) NumInput() int { return -1 }
+
+func (s *testStmt) Exec(args []driver.Value) (driver.Result, error) {
+	s.c.d.lock.Lock()
+	defer s.c.d.lock.Unlock()
+	if strings.HasPrefix(s.query, "INSERT INTO ") {
+		table := strings.Fields(s.query)[2]
+		s.c.tables[table] = append(s.c.tables[table], args)
+	}
+	return driver.RowsAffected(1), nil
+}
+
+func (s *testStmt) Query(args []driver.Value) (driver.Rows, error) {
+	s.c.d.lock.Lock()
+	defer s.c.d.lock.Unlock()
+	rows := &testRows{columns: 3}
+	switch {
+	case strings.HasPrefix(s.query, "SELECT MAX(version) FROM schema_migrations"):
+		var version driver.Value
+		if n := len(s.c.tables["schema_migrations"]); n > 0 {
+			version = s.c.tables["schema_migrations"][n-1][0]
+		}
+		rows.columns, rows.values = 1, [][]driver.Value{{version}}
+	case strings.HasPrefix(s.query, "SELECT number, hash, round FROM blocks"):
+		if n := len(s.c.tables["blocks"]); n > 0 {
+			last := s.c.tables["blocks"][n-1]
+			rows.values = [][]driver.Value{{last[0], last[1], last[3]}}
+		}
+	}
+	return rows, nil
+}
+
+type testRows struct {
+	columns int
+	values  [][]driver.Value
+}
+
+func (r *testRows) Columns() []string { return make([]string, r.columns) }
+func (r *testRows) Close() error      { return nil }
+
+func (r *testRows) Next(dest []driver.Value) error {
+	if len(r.values) == 0 {
+		return io.EOF
+	}
+	copy(dest, r.values[0])
+	r.values = r.values[1:]
+	return nil
+}
+
+var (
+	testBankKey, _ = crypto.GenerateKey()
+	testBank       = crypto.PubkeyToAddress(testBankKey.PublicKey)
+)
+
+func waitIndexed(t *testing.T, dsn string, number int) {
+	for i := 0; i < 100; i++ {
+		if len(testDB.rows(dsn, "blocks")) == number+1 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("indexing timeout: have %d blocks, want %d",
+		len(testDB.rows(dsn, "blocks")), number+1)
+}
+
+// newTestChain creates a blockchain with 5 of the 8 generated blocks, each
+// carrying a transfer, inserted.
+func newTestChain(t *testing.T) (*core.BlockChain, types.Blocks) {
+	gspec := &core.Genesis{
+		Config: params.TestnetChainConfig,
+		Alloc: core.GenesisAlloc{
+			testBank: {
+				Balance:   big.NewInt(1e18),
+				Staked:    big.NewInt(0),
+				PublicKey: crypto.FromECDSAPub(&testBankKey.PublicKey),
+			},
+		},
+	}
+	db := ethdb.NewMemDatabase()
+	genesis := gspec.MustCommit(db)
+	blockchain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, 8,
+		func(i int, block *core.BlockGen) {
+			tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank),
+				common.Address{1}, big.NewInt(1), params.TxGas, nil, nil),
+				types.HomesteadSigner{}, testBankKey)
+			block.AddTx(tx)
+		})
+	if _, err := blockchain.InsertChain(blocks[:5]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	return blockchain, blocks
+}
+
+func TestIndexer(t *testing.T) {
+	const dsn = "TestIndexer"
+	testDB.reset(dsn)
+
+	blockchain, blocks := newTestChain(t)
+	defer blockchain.Stop()
+
+	config := indexer.Config{PluginFlags: "sqlindexertest:" + dsn}
+	idx := NewIndexer(indexer.NewROBlockChain(blockchain), config)
+	if err := idx.Start(); err != nil {
+		t.Fatalf("failed to start indexer: %v", err)
+	}
+	waitIndexed(t, dsn, 5)
+	if err := idx.Stop(); err != nil {
+		t.Fatalf("failed to stop indexer: %v", err)
+	}
+	if n := len(testDB.rows(dsn, "schema_migrations")); n != len(migrations) {
+		t.Errorf("migrations mismatch: have %d, want %d", n, len(migrations))
+	}
+
+	// The indexing resumes from the last indexed block.
+	if _, err := blockchain.InsertChain(blocks[5:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	idx = NewIndexer(indexer.NewROBlockChain(blockchain), config)
+	if err := idx.Start(); err != nil {
+		t.Fatalf("failed to restart indexer: %v", err)
+	}
+	waitIndexed(t, dsn, 8)
+	if err := idx.Stop(); err != nil {
+		t.Fatalf("failed to stop indexer: %v", err)
+	}
+	if n := len(testDB.rows(dsn, "schema_migrations")); n != len(migrations) {
+		t.Errorf("migrations reapplied: have %d, want %d", n, len(migrations))
+	}
+	for i, row := range testDB.rows(dsn, "blocks") {
+		block := blockchain.GetBlockByNumber(uint64(i))
+		if row[0] != int64(i) || row[1] != block.Hash().Hex() {
+			t.Errorf("block #%d mismatch: have %v %v", i, row[0], row[1])
+		}
+	}
+	if n := len(testDB.rows(dsn, "transactions")); n != 8 {
+		t.Errorf("transactions mismatch: have %d, want 8", n)
+	}
+	if n := len(testDB.rows(dsn, "receipts")); n != 8 {
+		t.Errorf("receipts mismatch: have %d, want 8", n)
+	}
+	if rows := testDB.rows(dsn, "rounds"); len(rows) != 1 || rows[0][1] != int64(0) {
+		t.Errorf("rounds mismatch: have %v", rows)
+	}
+}
+
+func TestIndexerInvalidFlags(t *testing.T) {
+	for _, flags := range []string{"", "postgres", "postgres:", ":dsn"} {
+		idx := NewIndexer(nil, indexer.Config{PluginFlags: flags})
+		if err := idx.(indexer.HealthReporter).Health(); err != errInvalidFlags {
+			t.Errorf("flags %q: health mismatch: have %v, want %v", flags, err, errInvalidFlags)
+		}
+		if err := idx.Start(); err != errInvalidFlags {
+			t.Errorf("flags %q: error mismatch: have %v, want %v", flags, err, errInvalidFlags)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+// Package main builds the SQL indexer as a Go plugin:
+//
+//	go build -buildmode=plugin -o sqlindexer.so ./indexer/sqlindexer/plugin
+//
+// The database/sql driver must be linked into the plugin, add its import
+// below before building.
+package main
+
+import (
+	"github.com/dexon-foundation/dexon/indexer"
+	"github.com/dexon-foundation/dexon/indexer/sqlindexer"
+)
+
+// NewIndexer is the symbol looked up by indexer.NewIndexerFromConfig.
+func NewIndexer(bc indexer.ReadOnlyBlockChain, c indexer.Config) indexer.Indexer {
+	return sqlindexer.NewIndexer(bc, c)
+}
+
+// main is unused, plugins are never executed.
+func main() {}
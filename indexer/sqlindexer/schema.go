@@ -0,0 +1,146 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package sqlindexer
+
+import (
+	"database/sql"
+	"fmt"
+
+	"github.com/dexon-foundation/dexon/log"
+)
+
+// migrations are the schema migrations, the version of a migration is its
+// index plus one. Migrations must never be edited once released, changes go
+// into new migrations appended to the list.
+//
+// Hashes, addresses and byte strings are stored as 0x-prefixed hex TEXT and
+// big integers as decimal TEXT, so the schema stays portable between
+// PostgreSQL and SQLite without losing precision.
+var migrations = [][]string{
+	{
+		`CREATE TABLE blocks (
+			number      BIGINT PRIMARY KEY,
+			hash        TEXT NOT NULL UNIQUE,
+			parent_hash TEXT NOT NULL,
+			round       BIGINT NOT NULL,
+			timestamp   BIGINT NOT NULL,
+			coinbase    TEXT NOT NULL,
+			gas_limit   BIGINT NOT NULL,
+			gas_used    BIGINT NOT NULL,
+			randomness  TEXT NOT NULL,
+			tx_count    INTEGER NOT NULL
+		)`,
+		`CREATE INDEX blocks_round ON blocks (round)`,
+		`CREATE TABLE transactions (
+			hash         TEXT PRIMARY KEY,
+			block_number BIGINT NOT NULL,
+			tx_index     INTEGER NOT NULL,
+			from_address TEXT NOT NULL,
+			to_address   TEXT,
+			value        TEXT NOT NULL,
+			gas          BIGINT NOT NULL,
+			gas_price    TEXT NOT NULL,
+			nonce        BIGINT NOT NULL,
+			input        TEXT NOT NULL
+		)`,
+		`CREATE INDEX transactions_block ON transactions (block_number)`,
+		`CREATE INDEX transactions_from ON transactions (from_address)`,
+		`CREATE INDEX transactions_to ON transactions (to_address)`,
+		`CREATE TABLE receipts (
+			tx_hash             TEXT PRIMARY KEY,
+			block_number        BIGINT NOT NULL,
+			status              BIGINT NOT NULL,
+			cumulative_gas_used BIGINT NOT NULL,
+			gas_used            BIGINT NOT NULL,
+			contract_address    TEXT
+		)`,
+		`CREATE TABLE logs (
+			block_number BIGINT NOT NULL,
+			log_index    INTEGER NOT NULL,
+			tx_hash      TEXT NOT NULL,
+			address      TEXT NOT NULL,
+			topics       TEXT NOT NULL,
+			data         TEXT NOT NULL,
+			PRIMARY KEY (block_number, log_index)
+		)`,
+		`CREATE INDEX logs_address ON logs (address)`,
+		`CREATE TABLE rounds (
+			round  BIGINT PRIMARY KEY,
+			height BIGINT NOT NULL
+		)`,
+		`CREATE TABLE lattice_positions (
+			block_number BIGINT PRIMARY KEY,
+			core_hash    TEXT NOT NULL,
+			proposer     TEXT NOT NULL,
+			round        BIGINT NOT NULL,
+			chain_id     BIGINT NOT NULL,
+			height       BIGINT NOT NULL
+		)`,
+		`CREATE TABLE governance_events (
+			block_number BIGINT NOT NULL,
+			log_index    INTEGER NOT NULL,
+			tx_hash      TEXT NOT NULL,
+			name         TEXT NOT NULL,
+			topics       TEXT NOT NULL,
+			data         TEXT NOT NULL,
+			PRIMARY KEY (block_number, log_index)
+		)`,
+		`CREATE INDEX governance_events_name ON governance_events (name)`,
+	},
+}
+
+// migrate brings the schema up to the latest version, each migration is
+// applied in its own transaction.
+func migrate(db *sql.DB) error {
+	if _, err := db.Exec(`CREATE TABLE IF NOT EXISTS schema_migrations (
+		version INTEGER PRIMARY KEY
+	)`); err != nil {
+		return err
+	}
+	var version sql.NullInt64
+	if err := db.QueryRow(
+		`SELECT MAX(version) FROM schema_migrations`).Scan(&version); err != nil {
+		return err
+	}
+	if int(version.Int64) > len(migrations) {
+		return fmt.Errorf("schema version %d newer than supported %d",
+			version.Int64, len(migrations))
+	}
+	for v := int(version.Int64); v < len(migrations); v++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return err
+		}
+		for _, stmt := range migrations[v] {
+			if _, err := tx.Exec(stmt); err != nil {
+				tx.Rollback()
+				return fmt.Errorf("migration %d: %v", v+1, err)
+			}
+		}
+		if _, err := tx.Exec(
+			`INSERT INTO schema_migrations (version) VALUES ($1)`, v+1); err != nil {
+			tx.Rollback()
+			return err
+		}
+		if err := tx.Commit(); err != nil {
+			return err
+		}
+		log.Info("Applied indexer schema migration", "version", v+1)
+	}
+	return nil
+}
@@ -0,0 +1,138 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// +build sqlite
+
+// The SQLite tests need the github.com/mattn/go-sqlite3 driver, which is not
+// vendored, in the GOPATH and cgo enabled:
+//
+//   go get github.com/mattn/go-sqlite3
+//   go test -tags sqlite ./indexer/sqlindexer
+
+package sqlindexer
+
+import (
+	"database/sql"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/dexon-foundation/dexon/indexer"
+)
+
+func countSQLiteRows(t *testing.T, db *sql.DB, table string) int {
+	var n int
+	if err := db.QueryRow(`SELECT COUNT(*) FROM ` + table).Scan(&n); err != nil {
+		t.Fatalf("failed to count %s: %v", table, err)
+	}
+	return n
+}
+
+func waitSQLiteIndexed(t *testing.T, db *sql.DB, number int) {
+	for i := 0; i < 100; i++ {
+		var n int
+		err := db.QueryRow(`SELECT COUNT(*) FROM blocks`).Scan(&n)
+		if err == nil && n == number+1 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("indexing timeout: want %d blocks", number+1)
+}
+
+func TestIndexerSQLite(t *testing.T) {
+	dir, err := ioutil.TempDir("", "sqlindexer")
+	if err != nil {
+		t.Fatalf("failed to create temp dir: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	dsn := filepath.Join(dir, "index.db")
+
+	blockchain, blocks := newTestChain(t)
+	defer blockchain.Stop()
+
+	config := indexer.Config{PluginFlags: "sqlite3:" + dsn}
+	idx := NewIndexer(indexer.NewROBlockChain(blockchain), config)
+	if err := idx.Start(); err != nil {
+		t.Fatalf("failed to start indexer: %v", err)
+	}
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		t.Fatalf("failed to open database: %v", err)
+	}
+	defer db.Close()
+
+	waitSQLiteIndexed(t, db, 5)
+	if err := idx.Stop(); err != nil {
+		t.Fatalf("failed to stop indexer: %v", err)
+	}
+
+	// The indexing resumes from the last indexed block.
+	if _, err := blockchain.InsertChain(blocks[5:]); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	idx = NewIndexer(indexer.NewROBlockChain(blockchain), config)
+	if err := idx.Start(); err != nil {
+		t.Fatalf("failed to restart indexer: %v", err)
+	}
+	waitSQLiteIndexed(t, db, 8)
+	if err := idx.Stop(); err != nil {
+		t.Fatalf("failed to stop indexer: %v", err)
+	}
+
+	if n := countSQLiteRows(t, db, "schema_migrations"); n != len(migrations) {
+		t.Errorf("migrations mismatch: have %d, want %d", n, len(migrations))
+	}
+	rows, err := db.Query(`SELECT number, hash FROM blocks ORDER BY number`)
+	if err != nil {
+		t.Fatalf("failed to query blocks: %v", err)
+	}
+	defer rows.Close()
+	var i uint64
+	for ; rows.Next(); i++ {
+		var (
+			number uint64
+			hash   string
+		)
+		if err := rows.Scan(&number, &hash); err != nil {
+			t.Fatalf("failed to scan block: %v", err)
+		}
+		if block := blockchain.GetBlockByNumber(i); number != i || hash != block.Hash().Hex() {
+			t.Errorf("block #%d mismatch: have %d %s", i, number, hash)
+		}
+	}
+	if i != 9 {
+		t.Errorf("blocks mismatch: have %d, want 9", i)
+	}
+	if n := countSQLiteRows(t, db, "transactions"); n != 8 {
+		t.Errorf("transactions mismatch: have %d, want 8", n)
+	}
+	if n := countSQLiteRows(t, db, "receipts"); n != 8 {
+		t.Errorf("receipts mismatch: have %d, want 8", n)
+	}
+	var round, height uint64
+	if err := db.QueryRow(`SELECT round, height FROM rounds`).Scan(&round, &height); err != nil {
+		t.Fatalf("failed to query rounds: %v", err)
+	}
+	if round != 0 || height != 0 {
+		t.Errorf("round mismatch: have round %d at %d", round, height)
+	}
+}
@@ -0,0 +1,208 @@
+package indexer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon/log"
+)
+
+const (
+	// minRestartDelay is the delay before restarting an indexer whose
+	// Start failed, doubled on every consecutive failure.
+	minRestartDelay = time.Second
+
+	// maxRestartDelay caps the delay between restarts.
+	maxRestartDelay = 5 * time.Minute
+
+	// healthCheckInterval is the interval the health of the running
+	// indexers is checked at.
+	healthCheckInterval = time.Second
+)
+
+// Status is the health of an indexer reported over the admin RPC.
+type Status struct {
+	Name     string    `json:"name"`
+	Running  bool      `json:"running"`
+	Restarts int       `json:"restarts"`
+	Error    string    `json:"error,omitempty"`
+	Since    time.Time `json:"since"` // Time of the last status change
+}
+
+type supervised struct {
+	name    string
+	indexer Indexer
+
+	lock   sync.Mutex
+	status Status
+}
+
+func (s *supervised) restarted() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status.Restarts++
+}
+
+func (s *supervised) setStatus(running bool, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status.Running = running
+	s.status.Error = ""
+	if err != nil {
+		s.status.Error = err.Error()
+	}
+	s.status.Since = time.Now()
+}
+
+// Supervisor runs the configured indexers concurrently, restarting those
+// failing to start or reporting an unhealthy state with exponential backoff.
+type Supervisor struct {
+	indexers []*supervised
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+// NewSupervisor creates the indexers of the config. Indexers created in an
+// unhealthy state, e.g. from invalid flags, are rejected.
+func NewSupervisor(bc ReadOnlyBlockChain, c Config) (*Supervisor, error) {
+	s := &Supervisor{}
+	for _, ic := range c.configs() {
+		idx, err := NewIndexerFromConfig(bc, c, ic)
+		if err != nil {
+			return nil, err
+		}
+		name := ic.Name
+		if name == "" {
+			name = ic.Plugin
+		}
+		if reporter, ok := idx.(HealthReporter); ok {
+			if err := reporter.Health(); err != nil {
+				return nil, fmt.Errorf("indexer %s: %v", name, err)
+			}
+		}
+		s.indexers = append(s.indexers, &supervised{
+			name:    name,
+			indexer: idx,
+			status:  Status{Name: name},
+		})
+	}
+	return s, nil
+}
+
+// Start starts the indexers in the background.
+func (s *Supervisor) Start() error {
+	s.quit = make(chan struct{})
+	for _, idx := range s.indexers {
+		s.wg.Add(1)
+		go s.run(idx)
+	}
+	return nil
+}
+
+// Stop stops the indexers, the error of the first failing is returned.
+func (s *Supervisor) Stop() error {
+	if s.quit == nil {
+		return nil
+	}
+	close(s.quit)
+	s.wg.Wait()
+	s.quit = nil
+
+	var stopErr error
+	for _, idx := range s.indexers {
+		idx.lock.Lock()
+		running := idx.status.Running
+		idx.lock.Unlock()
+		if !running {
+			continue
+		}
+		if err := idx.indexer.Stop(); err != nil {
+			log.Error("Failed to stop indexer", "name", idx.name, "err", err)
+			if stopErr == nil {
+				stopErr = err
+			}
+		}
+		idx.setStatus(false, nil)
+	}
+	return stopErr
+}
+
+// Status returns the status of the indexers.
+func (s *Supervisor) Status() []Status {
+	statuses := make([]Status, len(s.indexers))
+	for i, idx := range s.indexers {
+		idx.lock.Lock()
+		statuses[i] = idx.status
+		idx.lock.Unlock()
+		if !statuses[i].Running {
+			continue
+		}
+		if reporter, ok := idx.indexer.(HealthReporter); ok {
+			if err := reporter.Health(); err != nil {
+				statuses[i].Error = err.Error()
+			}
+		}
+	}
+	return statuses
+}
+
+// run starts the indexer and watches its health, restarting it until the
+// supervisor stops.
+func (s *Supervisor) run(idx *supervised) {
+	defer s.wg.Done()
+	delay := minRestartDelay
+	for {
+		err := idx.indexer.Start()
+		if err == nil {
+			log.Info("Indexer started", "name", idx.name)
+			idx.setStatus(true, nil)
+			if err = s.watch(idx); err == nil {
+				return
+			}
+			log.Error("Indexer failed", "name", idx.name, "err", err,
+				"retry", minRestartDelay)
+			if err := idx.indexer.Stop(); err != nil {
+				log.Error("Failed to stop indexer", "name", idx.name, "err", err)
+			}
+			// The indexer did run, restart it as quickly as the first time.
+			delay = minRestartDelay
+		} else {
+			log.Error("Failed to start indexer", "name", idx.name, "err", err,
+				"retry", delay)
+		}
+		idx.setStatus(false, err)
+		select {
+		case <-time.After(delay):
+		case <-s.quit:
+			return
+		}
+		if delay *= 2; delay > maxRestartDelay {
+			delay = maxRestartDelay
+		}
+		idx.restarted()
+	}
+}
+
+// watch blocks until the running indexer reports an error, which is
+// returned, or the supervisor stops.
+func (s *Supervisor) watch(idx *supervised) error {
+	reporter, ok := idx.indexer.(HealthReporter)
+	if !ok {
+		<-s.quit
+		return nil
+	}
+	ticker := time.NewTicker(healthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := reporter.Health(); err != nil {
+				return err
+			}
+		case <-s.quit:
+			return nil
+		}
+	}
+}
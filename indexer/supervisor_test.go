@@ -0,0 +1,151 @@
+package indexer
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+var (
+	errTestStart  = errors.New("test start failure")
+	errTestHealth = errors.New("test health failure")
+)
+
+// testIndexer fails to start the number of times given by its flags.
+type testIndexer struct {
+	failures int
+	started  chan struct{}
+}
+
+func (i *testIndexer) Start() error {
+	if i.failures > 0 {
+		i.failures--
+		return errTestStart
+	}
+	close(i.started)
+	return nil
+}
+
+func (i *testIndexer) Stop() error { return nil }
+
+func (i *testIndexer) Health() error { return nil }
+
+// unhealthyIndexer reports an error after its first start, or right from
+// its creation if invalid.
+type unhealthyIndexer struct {
+	invalid bool
+
+	lock   sync.Mutex
+	starts int
+	health error
+}
+
+func (i *unhealthyIndexer) Start() error {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.starts++
+	i.health = nil
+	if i.starts == 1 {
+		i.health = errTestHealth
+	}
+	return nil
+}
+
+func (i *unhealthyIndexer) Stop() error { return nil }
+
+func (i *unhealthyIndexer) Health() error {
+	if i.invalid {
+		return errTestHealth
+	}
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.health
+}
+
+func (i *unhealthyIndexer) startCount() int {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	return i.starts
+}
+
+func TestSupervisor(t *testing.T) {
+	var created *testIndexer
+	Register("test", func(bc ReadOnlyBlockChain, c Config) Indexer {
+		created = &testIndexer{started: make(chan struct{})}
+		if c.PluginFlags == "fail" {
+			created.failures = 1
+		}
+		return created
+	})
+
+	if _, err := NewSupervisor(nil, Config{
+		Indexers: []IndexerConfig{{Name: "unknown"}},
+	}); err == nil {
+		t.Fatalf("unknown indexer accepted")
+	}
+
+	s, err := NewSupervisor(nil, Config{
+		Indexers: []IndexerConfig{{Name: "test", Flags: "fail"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create supervisor: %v", err)
+	}
+	s.Start()
+	select {
+	case <-created.started:
+	case <-time.After(3 * minRestartDelay):
+		t.Fatalf("indexer not restarted")
+	}
+	// The status is set right after Start returns.
+	for i := 0; i < 100 && !s.Status()[0].Running; i++ {
+		time.Sleep(10 * time.Millisecond)
+	}
+	status := s.Status()[0]
+	if !status.Running || status.Restarts != 1 || status.Error != "" {
+		t.Errorf("status mismatch: %+v", status)
+	}
+	if err := s.Stop(); err != nil {
+		t.Fatalf("failed to stop supervisor: %v", err)
+	}
+	if s.Status()[0].Running {
+		t.Errorf("indexer running after stop")
+	}
+}
+
+func TestSupervisorUnhealthy(t *testing.T) {
+	var created *unhealthyIndexer
+	Register("unhealthy", func(bc ReadOnlyBlockChain, c Config) Indexer {
+		created = &unhealthyIndexer{invalid: c.PluginFlags == "invalid"}
+		return created
+	})
+
+	// Indexers created unhealthy fail the supervisor creation.
+	if _, err := NewSupervisor(nil, Config{
+		Indexers: []IndexerConfig{{Name: "unhealthy", Flags: "invalid"}},
+	}); err == nil {
+		t.Fatalf("invalid indexer accepted")
+	}
+
+	// Indexers turning unhealthy are restarted.
+	s, err := NewSupervisor(nil, Config{
+		Indexers: []IndexerConfig{{Name: "unhealthy"}},
+	})
+	if err != nil {
+		t.Fatalf("failed to create supervisor: %v", err)
+	}
+	s.Start()
+	deadline := time.Now().Add(3*healthCheckInterval + 3*minRestartDelay)
+	for created.startCount() < 2 || !s.Status()[0].Running {
+		if time.Now().After(deadline) {
+			t.Fatalf("unhealthy indexer not restarted: %+v", s.Status()[0])
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if status := s.Status()[0]; status.Restarts != 1 || status.Error != "" {
+		t.Errorf("status mismatch: %+v", status)
+	}
+	if err := s.Stop(); err != nil {
+		t.Fatalf("failed to stop supervisor: %v", err)
+	}
+}
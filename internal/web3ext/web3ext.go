@@ -23,6 +23,7 @@ var Modules = map[string]string{
 	"clique":     Clique_JS,
 	"ethash":     Ethash_JS,
 	"debug":      Debug_JS,
+	"dexon":      Dexon_JS,
 	"eth":        Eth_JS,
 	"miner":      Miner_JS,
 	"net":        Net_JS,
@@ -228,6 +229,14 @@ web3._extend({
 			name: 'isProposing',
 			getter: 'admin_isProposing'
 		}),
+		new web3._extend.Property({
+			name: 'peerSets',
+			getter: 'admin_peerSets'
+		}),
+		new web3._extend.Property({
+			name: 'indexers',
+			getter: 'admin_indexers'
+		}),
 	]
 });
 `
@@ -463,6 +472,43 @@ web3._extend({
 });
 `
 
+const Dexon_JS = `
+web3._extend({
+	property: 'dexon',
+	methods: [
+		new web3._extend.Method({
+			name: 'getBlockRandomness',
+			call: 'dexon_getBlockRandomness',
+			params: 1,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter]
+		}),
+		new web3._extend.Method({
+			name: 'getTransactionRandomness',
+			call: 'dexon_getTransactionRandomness',
+			params: 1
+		}),
+		new web3._extend.Method({
+			name: 'computeRand',
+			call: 'dexon_computeRand',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputAddressFormatter, web3._extend.utils.toHex, web3._extend.utils.toHex]
+		}),
+		new web3._extend.Method({
+			name: 'getGovernanceEvents',
+			call: 'dexon_getGovernanceEvents',
+			params: 4,
+			inputFormatter: [web3._extend.formatters.inputBlockNumberFormatter, web3._extend.formatters.inputBlockNumberFormatter, null, null]
+		}),
+		new web3._extend.Method({
+			name: 'getRoundTimeline',
+			call: 'dexon_getRoundTimeline',
+			params: 2,
+			inputFormatter: [web3._extend.utils.toHex, null]
+		}),
+	]
+});
+`
+
 const Eth_JS = `
 web3._extend({
 	property: 'eth',
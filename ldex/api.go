@@ -0,0 +1,184 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package ldex
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/big"
+	"time"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	gmath "github.com/dexon-foundation/dexon/common/math"
+	"github.com/dexon-foundation/dexon/consensus"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/internal/ethapi"
+	"github.com/dexon-foundation/dexon/light"
+	"github.com/dexon-foundation/dexon/params"
+	"github.com/dexon-foundation/dexon/rpc"
+)
+
+// callTimeout is the maximum execution time of eth_call.
+const callTimeout = 5 * time.Second
+
+// PublicLightAPI provides the state of the verified headers, retrieved on
+// demand from the light servers.
+type PublicLightAPI struct {
+	ld *LightDexon
+}
+
+// NewPublicLightAPI creates a new light client API.
+func NewPublicLightAPI(ld *LightDexon) *PublicLightAPI {
+	return &PublicLightAPI{ld: ld}
+}
+
+func (api *PublicLightAPI) stateAndHeaderByNumber(ctx context.Context,
+	blockNr rpc.BlockNumber) (*state.StateDB, *types.Header, error) {
+	var header *types.Header
+	if blockNr == rpc.LatestBlockNumber || blockNr == rpc.PendingBlockNumber {
+		header = api.ld.CurrentHeader()
+	} else {
+		header = api.ld.GetHeaderByNumber(uint64(blockNr))
+	}
+	if header == nil {
+		return nil, nil, fmt.Errorf("header #%d not found", blockNr)
+	}
+	return light.NewState(ctx, header, api.ld.odr), header, nil
+}
+
+// BlockNumber returns the number of the latest verified header.
+func (api *PublicLightAPI) BlockNumber() hexutil.Uint64 {
+	return hexutil.Uint64(api.ld.CurrentHeader().Number.Uint64())
+}
+
+// GetBalance returns the amount of wei for the given address in the state of
+// the given block number.
+func (api *PublicLightAPI) GetBalance(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*hexutil.Big, error) {
+	state, _, err := api.stateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	return (*hexutil.Big)(state.GetBalance(address)), state.Error()
+}
+
+// GetTransactionCount returns the number of transactions the given address
+// has sent for the given block number.
+func (api *PublicLightAPI) GetTransactionCount(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (*hexutil.Uint64, error) {
+	state, _, err := api.stateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	nonce := state.GetNonce(address)
+	return (*hexutil.Uint64)(&nonce), state.Error()
+}
+
+// GetCode returns the code stored at the given address in the state for the
+// given block number.
+func (api *PublicLightAPI) GetCode(ctx context.Context, address common.Address, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
+	state, _, err := api.stateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	code := state.GetCode(address)
+	return code, state.Error()
+}
+
+// GetStorageAt returns the storage from the state at the given address, key
+// and block number.
+func (api *PublicLightAPI) GetStorageAt(ctx context.Context, address common.Address, key string, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
+	state, _, err := api.stateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	res := state.GetState(address, common.HexToHash(key))
+	return res[:], state.Error()
+}
+
+// Call executes the given transaction on the state for the given block
+// number, retrieving the state accessed on demand.
+func (api *PublicLightAPI) Call(ctx context.Context, args ethapi.CallArgs, blockNr rpc.BlockNumber) (hexutil.Bytes, error) {
+	ctx, cancel := context.WithTimeout(ctx, callTimeout)
+	defer cancel()
+
+	state, header, err := api.stateAndHeaderByNumber(ctx, blockNr)
+	if err != nil {
+		return nil, err
+	}
+	gas, gasPrice := uint64(args.Gas), args.GasPrice.ToInt()
+	if gas == 0 {
+		gas = math.MaxUint64 / 2
+	}
+	if gasPrice.Sign() == 0 {
+		gasPrice = new(big.Int).SetUint64(params.GWei)
+	}
+	msg := types.NewMessage(args.From, args.To, 0, args.Value.ToInt(), gas, gasPrice, args.Data, false)
+	state.SetBalance(msg.From(), gmath.MaxBig256)
+
+	context := core.NewEVMContext(msg, header, &chainContext{ctx: ctx, ld: api.ld}, nil)
+	evm := vm.NewEVM(context, state, api.ld.chainConfig, vm.Config{})
+	// Cancel the EVM if the call times out.
+	go func() {
+		<-ctx.Done()
+		evm.Cancel()
+	}()
+	gp := new(core.GasPool).AddGas(math.MaxUint64)
+	res, _, _, err := core.ApplyMessage(evm, msg, gp)
+	if err != nil {
+		return nil, err
+	}
+	if err := state.Error(); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// chainContext implements core.ChainContext over the verified headers, with
+// the states retrieved on demand.
+type chainContext struct {
+	ctx context.Context
+	ld  *LightDexon
+}
+
+func (c *chainContext) Engine() consensus.Engine {
+	return c.ld.engine
+}
+
+func (c *chainContext) GetHeader(hash common.Hash, number uint64) *types.Header {
+	if header := c.ld.GetHeaderByNumber(number); header != nil && header.Hash() == hash {
+		return header
+	}
+	return nil
+}
+
+func (c *chainContext) GetHeaderByNumber(number uint64) *types.Header {
+	return c.ld.GetHeaderByNumber(number)
+}
+
+func (c *chainContext) StateAt(root common.Hash) (*state.StateDB, error) {
+	header := &types.Header{Root: root, Number: new(big.Int)}
+	return state.New(root, light.NewStateDatabase(c.ctx, header, c.ld.odr))
+}
+
+func (c *chainContext) GetRoundHeight(round uint64) (uint64, bool) {
+	return c.ld.GetRoundHeight(round)
+}
@@ -0,0 +1,267 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package ldex
+
+import (
+	"context"
+	"sort"
+	"sync"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/consensus"
+	"github.com/dexon-foundation/dexon/consensus/dexcon"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/state"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/verifier"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/internal/ethapi"
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/node"
+	"github.com/dexon-foundation/dexon/p2p"
+	"github.com/dexon-foundation/dexon/params"
+	"github.com/dexon-foundation/dexon/rlp"
+	"github.com/dexon-foundation/dexon/rpc"
+)
+
+// checkpointKey is the database key of the trusted headers the verification
+// resumes from on restart.
+var checkpointKey = []byte("ldex-checkpoint")
+
+// LightDexon implements the DEXON light client service.
+type LightDexon struct {
+	config      *Config
+	chainConfig *params.ChainConfig
+	chainDb     ethdb.Database
+	engine      consensus.Engine
+	genesis     *types.Header
+
+	peers      *peerSet
+	dispatcher *dispatcher
+	odr        *odrBackend
+
+	// verifier and checkpoint are only accessed by the sync loop.
+	verifier   *verifier.Verifier
+	checkpoint []*types.HeaderWithGovState
+
+	headLock sync.RWMutex
+	head     *types.Header
+
+	syncCh   chan struct{}
+	quitSync chan struct{}
+	wg       sync.WaitGroup
+
+	protocols     []p2p.Protocol
+	netRPCService *ethapi.PublicNetAPI
+}
+
+// New creates a new light client service.
+func New(ctx *node.ServiceContext, config *Config) (*LightDexon, error) {
+	chainDb, err := ctx.OpenDatabase("lightchaindata", config.DatabaseCache, config.DatabaseHandles)
+	if err != nil {
+		return nil, err
+	}
+	return newLightDexon(chainDb, config)
+}
+
+func newLightDexon(chainDb ethdb.Database, config *Config) (*LightDexon, error) {
+	chainConfig, genesisHash, genesisErr := core.SetupGenesisBlock(chainDb,
+		config.Genesis)
+	if _, ok := genesisErr.(*params.ConfigCompatError); genesisErr != nil && !ok {
+		return nil, genesisErr
+	}
+	log.Info("Initialised chain configuration", "config", chainConfig)
+
+	if config.RequestTimeout == 0 {
+		config.RequestTimeout = DefaultConfig.RequestTimeout
+	}
+	ld := &LightDexon{
+		config:      config,
+		chainConfig: chainConfig,
+		chainDb:     chainDb,
+		engine:      dexcon.New(),
+		genesis:     rawdb.ReadHeader(chainDb, genesisHash, 0),
+		peers:       newPeerSet(0),
+		dispatcher:  newDispatcher(config.RequestTimeout),
+		syncCh:      make(chan struct{}, 1),
+		quitSync:    make(chan struct{}),
+	}
+	ld.odr = &odrBackend{db: chainDb, peers: ld.peers, dispatcher: ld.dispatcher}
+
+	checkpoint, err := ld.loadCheckpoint()
+	if err != nil {
+		return nil, err
+	}
+	ld.verifier, err = verifier.New(verifier.Checkpoint{Headers: checkpoint})
+	if err != nil {
+		return nil, err
+	}
+	ld.checkpoint = checkpoint
+	ld.head = ld.verifier.Head()
+	ld.protocols = makeProtocols(ld.peers, ld.quitSync, &ld.wg, ld.handle)
+	return ld, nil
+}
+
+// loadCheckpoint loads the trusted headers stored by the last run, or the
+// genesis with its governance state.
+func (ld *LightDexon) loadCheckpoint() ([]*types.HeaderWithGovState, error) {
+	if data, err := ld.chainDb.Get(checkpointKey); err == nil {
+		var checkpoint []*types.HeaderWithGovState
+		if err := rlp.DecodeBytes(data, &checkpoint); err != nil {
+			return nil, err
+		}
+		return checkpoint, nil
+	}
+	statedb, err := state.New(ld.genesis.Root, state.NewDatabase(ld.chainDb))
+	if err != nil {
+		return nil, err
+	}
+	govState, err := state.GetGovState(statedb, ld.genesis, vm.GovernanceContractAddress)
+	if err != nil {
+		return nil, err
+	}
+	return []*types.HeaderWithGovState{{Header: ld.genesis, GovState: govState}}, nil
+}
+
+// updateCheckpoint keeps the verified headers with governance states rounds
+// start at and the latest one, to resume the verification from on restart.
+func (ld *LightDexon) updateCheckpoint(headers []*types.HeaderWithGovState) {
+	for _, header := range headers {
+		if header.GovState == nil {
+			continue
+		}
+		// The latest header is replaced unless a round starts at it.
+		last := ld.checkpoint[len(ld.checkpoint)-1]
+		if len(ld.checkpoint) > 1 && !ld.isRoundStart(last.Header) {
+			ld.checkpoint = ld.checkpoint[:len(ld.checkpoint)-1]
+		}
+		ld.checkpoint = append(ld.checkpoint, header)
+	}
+	data, err := rlp.EncodeToBytes(ld.checkpoint)
+	if err != nil {
+		log.Error("Failed to encode checkpoint", "err", err)
+		return
+	}
+	if err := ld.chainDb.Put(checkpointKey, data); err != nil {
+		log.Error("Failed to store checkpoint", "err", err)
+	}
+}
+
+func (ld *LightDexon) isRoundStart(header *types.Header) bool {
+	number := header.Number.Uint64()
+	if number == 0 {
+		return true
+	}
+	parent := rawdb.ReadHeader(ld.chainDb, header.ParentHash, number-1)
+	return parent == nil || parent.Round != header.Round
+}
+
+// CurrentHeader returns the latest verified header.
+func (ld *LightDexon) CurrentHeader() *types.Header {
+	ld.headLock.RLock()
+	defer ld.headLock.RUnlock()
+
+	return ld.head
+}
+
+// GetHeaderByNumber retrieves a verified canonical header by number.
+func (ld *LightDexon) GetHeaderByNumber(number uint64) *types.Header {
+	if number > ld.CurrentHeader().Number.Uint64() {
+		return nil
+	}
+	hash := rawdb.ReadCanonicalHash(ld.chainDb, number)
+	if hash == (common.Hash{}) {
+		return nil
+	}
+	return rawdb.ReadHeader(ld.chainDb, hash, number)
+}
+
+// GetRoundHeight returns the height of the first verified header of the
+// round.
+func (ld *LightDexon) GetRoundHeight(round uint64) (uint64, bool) {
+	head := ld.CurrentHeader()
+	if head.Round < round {
+		return 0, false
+	}
+	number := uint64(sort.Search(int(head.Number.Uint64())+1, func(i int) bool {
+		header := ld.GetHeaderByNumber(uint64(i))
+		return header == nil || header.Round >= round
+	}))
+	header := ld.GetHeaderByNumber(number)
+	if header == nil || header.Round != round {
+		return 0, false
+	}
+	return number, true
+}
+
+// Protocols implements node.Service, returning the p2p network protocols
+// used by the light client.
+func (ld *LightDexon) Protocols() []p2p.Protocol {
+	return ld.protocols
+}
+
+// APIs implements node.Service, returning the RPC APIs of the light client.
+func (ld *LightDexon) APIs() []rpc.API {
+	return []rpc.API{
+		{
+			Namespace: "eth",
+			Version:   "1.0",
+			Service:   NewPublicLightAPI(ld),
+			Public:    true,
+		}, {
+			Namespace: "net",
+			Version:   "1.0",
+			Service:   ld.netRPCService,
+			Public:    true,
+		},
+	}
+}
+
+// Start implements node.Service, starting the header synchronisation.
+func (ld *LightDexon) Start(srvr *p2p.Server) error {
+	ld.netRPCService = ethapi.NewPublicNetAPI(srvr, ld.config.NetworkId)
+	ld.wg.Add(1)
+	go ld.syncer()
+	return nil
+}
+
+// Stop implements node.Service, terminating all internal goroutines used by
+// the light client.
+func (ld *LightDexon) Stop() error {
+	close(ld.quitSync)
+	ld.peers.Close()
+	ld.wg.Wait()
+	ld.chainDb.Close()
+	log.Info("Light client stopped")
+	return nil
+}
+
+// newContext returns a context cancelled when the light client stops.
+func (ld *LightDexon) newContext() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		select {
+		case <-ld.quitSync:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return ctx, cancel
+}
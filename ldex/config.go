@@ -0,0 +1,49 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package ldex
+
+import (
+	"time"
+
+	"github.com/dexon-foundation/dexon/core"
+)
+
+// Config contains the configuration options of the light client.
+type Config struct {
+	// The genesis block, which is inserted if the database is empty.
+	// If nil, the DEXON main net block is used.
+	Genesis *core.Genesis `toml:",omitempty"`
+
+	// Protocol options
+	NetworkId uint64 // Network ID to use for selecting peers to connect to
+
+	// Database options
+	DatabaseHandles int `toml:"-"`
+	DatabaseCache   int
+
+	// RequestTimeout is how long a request to a light server may stay
+	// unanswered.
+	RequestTimeout time.Duration
+}
+
+// DefaultConfig contains default settings for use on the DEXON main net.
+var DefaultConfig = Config{
+	NetworkId:      237,
+	DatabaseCache:  768,
+	RequestTimeout: 5 * time.Second,
+}
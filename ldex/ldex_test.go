@@ -0,0 +1,235 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package ldex
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/common/hexutil"
+	"github.com/dexon-foundation/dexon/consensus/ethash"
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/vm"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/internal/ethapi"
+	"github.com/dexon-foundation/dexon/p2p"
+	"github.com/dexon-foundation/dexon/p2p/enode"
+	"github.com/dexon-foundation/dexon/params"
+	"github.com/dexon-foundation/dexon/rpc"
+)
+
+var (
+	testBankKey, _ = crypto.HexToECDSA("3cf5bdee098cc34536a7b0e80d85e07a380efca76fc12136299b9e5ba24193c8")
+	testBank       = crypto.PubkeyToAddress(testBankKey.PublicKey)
+	testRecipient  = common.HexToAddress("0x1000000000000000000000000000000000000001")
+
+	// testContract returns 42 when called.
+	testContract     = common.HexToAddress("0x2000000000000000000000000000000000000002")
+	testContractCode = common.FromHex("602a60005260206000f3")
+)
+
+// newTestServer creates a light server of a chain of n blocks, each
+// transferring 1 wei to testRecipient.
+func newTestServer(t *testing.T, n int) (*Server, *core.Genesis) {
+	ether := big.NewInt(1e18)
+	gspec := &core.Genesis{
+		Config: params.TestnetChainConfig,
+		Alloc: core.GenesisAlloc{
+			testBank: {
+				Balance:   new(big.Int).Mul(big.NewInt(1000), ether),
+				Staked:    new(big.Int).Mul(big.NewInt(500), ether),
+				PublicKey: crypto.FromECDSAPub(&testBankKey.PublicKey),
+			},
+			testContract: {
+				Balance: big.NewInt(0),
+				Staked:  big.NewInt(0),
+				Code:    testContractCode,
+			},
+		},
+	}
+	db := ethdb.NewMemDatabase()
+	genesis := gspec.MustCommit(db)
+	blockchain, err := core.NewBlockChain(db, nil, gspec.Config, ethash.NewFaker(), vm.Config{}, nil)
+	if err != nil {
+		t.Fatalf("failed to create blockchain: %v", err)
+	}
+	signer := types.HomesteadSigner{}
+	blocks, _ := core.GenerateChain(gspec.Config, genesis, ethash.NewFaker(), db, n,
+		func(i int, block *core.BlockGen) {
+			tx, _ := types.SignTx(types.NewTransaction(block.TxNonce(testBank),
+				testRecipient, big.NewInt(1), params.TxGas, nil, nil), signer, testBankKey)
+			block.AddTx(tx)
+		})
+	if _, err := blockchain.InsertChain(blocks); err != nil {
+		t.Fatalf("failed to insert chain: %v", err)
+	}
+	return NewServer(blockchain, 1, 0), gspec
+}
+
+func newTestClient(t *testing.T, db ethdb.Database, gspec *core.Genesis) *LightDexon {
+	ld, err := newLightDexon(db, &Config{
+		Genesis:        gspec,
+		NetworkId:      1,
+		RequestTimeout: time.Second,
+	})
+	if err != nil {
+		t.Fatalf("failed to create light client: %v", err)
+	}
+	return ld
+}
+
+// connect connects the light client to the light server.
+func connect(s *Server, ld *LightDexon) {
+	app, net := p2p.MsgPipe()
+	go s.handle(newPeer(ldex1, p2p.NewPeer(enode.ID{1}, "client", nil), net))
+	go ld.handle(newPeer(ldex1, p2p.NewPeer(enode.ID{2}, "server", nil), app))
+}
+
+func waitSync(t *testing.T, ld *LightDexon, number uint64) {
+	for i := 0; i < 100; i++ {
+		if ld.CurrentHeader().Number.Uint64() == number {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	t.Fatalf("sync timeout: got #%d, want #%d", ld.CurrentHeader().Number, number)
+}
+
+func TestSyncAndODR(t *testing.T) {
+	const blocks = 5
+
+	s, gspec := newTestServer(t, blocks)
+	db := ethdb.NewMemDatabase()
+	ld := newTestClient(t, db, gspec)
+	if err := ld.Start(nil); err != nil {
+		t.Fatalf("failed to start light client: %v", err)
+	}
+	connect(s, ld)
+	waitSync(t, ld, blocks)
+
+	if hash := ld.CurrentHeader().Hash(); hash != s.blockchain.CurrentBlock().Hash() {
+		t.Fatalf("head mismatch: got %x, want %x", hash, s.blockchain.CurrentBlock().Hash())
+	}
+
+	api := NewPublicLightAPI(ld)
+	ctx := context.Background()
+	for _, number := range []rpc.BlockNumber{2, rpc.LatestBlockNumber} {
+		want := int64(number)
+		if number == rpc.LatestBlockNumber {
+			want = blocks
+		}
+		balance, err := api.GetBalance(ctx, testRecipient, number)
+		if err != nil {
+			t.Fatalf("failed to get balance: %v", err)
+		}
+		if balance.ToInt().Int64() != want {
+			t.Errorf("balance mismatch at #%d: got %v, want %d", number, balance.ToInt(), want)
+		}
+	}
+	nonce, err := api.GetTransactionCount(ctx, testBank, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to get transaction count: %v", err)
+	}
+	if *nonce != blocks {
+		t.Errorf("nonce mismatch: got %d, want %d", *nonce, blocks)
+	}
+	code, err := api.GetCode(ctx, testContract, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to get code: %v", err)
+	}
+	if !bytes.Equal(code, testContractCode) {
+		t.Errorf("code mismatch: got %x, want %x", code, testContractCode)
+	}
+	result, err := api.Call(ctx, ethapi.CallArgs{
+		From: testBank,
+		To:   &testContract,
+	}, rpc.LatestBlockNumber)
+	if err != nil {
+		t.Fatalf("failed to call: %v", err)
+	}
+	if want := common.BigToHash(big.NewInt(42)); !bytes.Equal(result, want[:]) {
+		t.Errorf("call result mismatch: got %x, want %x", result, want)
+	}
+	if err := ld.Stop(); err != nil {
+		t.Fatalf("failed to stop light client: %v", err)
+	}
+
+	// The verification resumes from the stored checkpoint.
+	ld = newTestClient(t, db, gspec)
+	if number := ld.CurrentHeader().Number.Uint64(); number != blocks {
+		t.Errorf("head mismatch after restart: got #%d, want #%d", number, blocks)
+	}
+}
+
+func TestInsertInvalidHeaders(t *testing.T) {
+	s, gspec := newTestServer(t, 2)
+	ld := newTestClient(t, ethdb.NewMemDatabase(), gspec)
+
+	headers := s.headers(1, 2)
+	if len(headers) != 2 || headers[1].GovState == nil {
+		t.Fatalf("served headers mismatch: %d headers", len(headers))
+	}
+	tampered := *headers[1].GovState
+	tampered.Storage = tampered.Storage[1:]
+	headers[1] = &types.HeaderWithGovState{Header: headers[1].Header, GovState: &tampered}
+
+	if err := ld.insertHeaders(nil, headers); err == nil {
+		t.Fatalf("tampered governance state accepted")
+	}
+	if number := ld.CurrentHeader().Number.Uint64(); number != 1 {
+		t.Errorf("head mismatch: got #%d, want #1", number)
+	}
+}
+
+func TestDispatcher(t *testing.T) {
+	d := newDispatcher(50 * time.Millisecond)
+	p := &peer{id: "peer"}
+
+	// Responses of other peers are not delivered.
+	var reqID uint64
+	_, err := d.request(context.Background(), p, func(id uint64) error {
+		reqID = id
+		if d.deliver("other", id, hexutil.Bytes{}) {
+			t.Errorf("response of another peer delivered")
+		}
+		return nil
+	})
+	if err != errTimeout {
+		t.Errorf("error mismatch: got %v, want %v", err, errTimeout)
+	}
+	if d.deliver(p.id, reqID, nil) {
+		t.Errorf("response of timed out request delivered")
+	}
+
+	resp, err := d.request(context.Background(), p, func(id uint64) error {
+		go d.deliver(p.id, id, []byte{1})
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("request failed: %v", err)
+	}
+	if !bytes.Equal(resp.([]byte), []byte{1}) {
+		t.Errorf("response mismatch: got %v", resp)
+	}
+}
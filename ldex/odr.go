@@ -0,0 +1,201 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package ldex
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/crypto"
+	"github.com/dexon-foundation/dexon/ethdb"
+	"github.com/dexon-foundation/dexon/light"
+	"github.com/dexon-foundation/dexon/trie"
+)
+
+var (
+	errTimeout            = errors.New("request timed out")
+	errUnexpectedResponse = errors.New("unexpected response")
+	errInvalidCode        = errors.New("contract code does not match hash")
+	errUnsupportedRequest = errors.New("unsupported ODR request")
+)
+
+type pendingRequest struct {
+	peer string
+	ch   chan interface{}
+}
+
+// dispatcher matches the responses of the light servers with the pending
+// requests by request ID.
+type dispatcher struct {
+	timeout time.Duration
+
+	lock    sync.Mutex
+	nextID  uint64
+	pending map[uint64]*pendingRequest
+}
+
+func newDispatcher(timeout time.Duration) *dispatcher {
+	return &dispatcher{
+		timeout: timeout,
+		pending: make(map[uint64]*pendingRequest),
+	}
+}
+
+// request sends a request to the peer by send and waits for the response.
+func (d *dispatcher) request(ctx context.Context, p *peer,
+	send func(reqID uint64) error) (interface{}, error) {
+	d.lock.Lock()
+	d.nextID++
+	reqID := d.nextID
+	req := &pendingRequest{peer: p.id, ch: make(chan interface{}, 1)}
+	d.pending[reqID] = req
+	d.lock.Unlock()
+
+	defer func() {
+		d.lock.Lock()
+		delete(d.pending, reqID)
+		d.lock.Unlock()
+	}()
+
+	if err := send(reqID); err != nil {
+		return nil, err
+	}
+	timeout := time.NewTimer(d.timeout)
+	defer timeout.Stop()
+
+	select {
+	case resp := <-req.ch:
+		return resp, nil
+	case <-timeout.C:
+		return nil, errTimeout
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// deliver delivers the response of a request sent to the peer, it returns
+// false if no such request is pending.
+func (d *dispatcher) deliver(peerID string, reqID uint64, resp interface{}) bool {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+
+	req, ok := d.pending[reqID]
+	if !ok || req.peer != peerID {
+		return false
+	}
+	delete(d.pending, reqID)
+	req.ch <- resp
+	return true
+}
+
+// odrBackend retrieves the state tries and contract codes from the light
+// servers. It implements light.OdrBackend.
+type odrBackend struct {
+	db         ethdb.Database
+	peers      *peerSet
+	dispatcher *dispatcher
+}
+
+func (o *odrBackend) Database() ethdb.Database {
+	return o.db
+}
+
+// ChtIndexer returns nil, the headers are verified by their randomness
+// instead of canonical hash tries.
+func (o *odrBackend) ChtIndexer() *core.ChainIndexer {
+	return nil
+}
+
+func (o *odrBackend) BloomTrieIndexer() *core.ChainIndexer {
+	return nil
+}
+
+func (o *odrBackend) BloomIndexer() *core.ChainIndexer {
+	return nil
+}
+
+func (o *odrBackend) IndexerConfig() *light.IndexerConfig {
+	return light.DefaultClientIndexerConfig
+}
+
+// Retrieve retrieves the requested data from the light servers, verifies it
+// and stores it in the local database.
+func (o *odrBackend) Retrieve(ctx context.Context, req light.OdrRequest) error {
+	peers := o.peers.AllPeers()
+	if len(peers) == 0 {
+		return light.ErrNoPeers
+	}
+	var err error
+	for _, p := range peers {
+		if err = o.retrieve(ctx, p, req); err == nil {
+			req.StoreResult(o.db)
+			return nil
+		}
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		p.Log().Debug("Failed to retrieve ODR request", "err", err)
+	}
+	return err
+}
+
+func (o *odrBackend) retrieve(ctx context.Context, p *peer, req light.OdrRequest) error {
+	switch req := req.(type) {
+	case *light.TrieRequest:
+		resp, err := o.dispatcher.request(ctx, p, func(reqID uint64) error {
+			return p.RequestProofs(reqID, req.Id.Root, req.Key)
+		})
+		if err != nil {
+			return err
+		}
+		nodes, ok := resp.(light.NodeList)
+		if !ok {
+			return errUnexpectedResponse
+		}
+		nodeSet := nodes.NodeSet()
+		if _, _, err := trie.VerifyProof(req.Id.Root, req.Key, nodeSet); err != nil {
+			return fmt.Errorf("merkle proof verification failed: %v", err)
+		}
+		req.Proof = nodeSet
+		return nil
+
+	case *light.CodeRequest:
+		resp, err := o.dispatcher.request(ctx, p, func(reqID uint64) error {
+			return p.RequestCode(reqID, req.Hash)
+		})
+		if err != nil {
+			return err
+		}
+		code, ok := resp.([]byte)
+		if !ok {
+			return errUnexpectedResponse
+		}
+		if crypto.Keccak256Hash(code) != req.Hash {
+			return errInvalidCode
+		}
+		req.Data = code
+		return nil
+
+	default:
+		return fmt.Errorf("%v: %T", errUnsupportedRequest, req)
+	}
+}
@@ -0,0 +1,305 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package ldex
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/light"
+	"github.com/dexon-foundation/dexon/p2p"
+)
+
+var (
+	errClosed            = errors.New("peer set is closed")
+	errAlreadyRegistered = errors.New("peer is already registered")
+	errNotRegistered     = errors.New("peer is not registered")
+	errTooManyPeers      = errors.New("too many peers")
+)
+
+const handshakeTimeout = 5 * time.Second
+
+type peer struct {
+	*p2p.Peer
+	rw p2p.MsgReadWriter
+
+	id      string
+	version int
+
+	lock   sync.RWMutex
+	head   common.Hash
+	number uint64
+}
+
+func newPeer(version int, p *p2p.Peer, rw p2p.MsgReadWriter) *peer {
+	return &peer{
+		Peer:    p,
+		rw:      rw,
+		id:      p.ID().String(),
+		version: version,
+	}
+}
+
+// Head retrieves a copy of the current head hash and number of the peer.
+func (p *peer) Head() (hash common.Hash, number uint64) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+
+	copy(hash[:], p.head[:])
+	return hash, p.number
+}
+
+// SetHead updates the head hash and number of the peer.
+func (p *peer) SetHead(hash common.Hash, number uint64) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+
+	copy(p.head[:], hash[:])
+	p.number = number
+}
+
+// SendAnnounce announces a new head to the peer.
+func (p *peer) SendAnnounce(hash common.Hash, number uint64) error {
+	return p2p.Send(p.rw, AnnounceMsg, &announceData{Hash: hash, Number: number})
+}
+
+// RequestHeaders fetches amount canonical headers from the origin height.
+func (p *peer) RequestHeaders(reqID, origin, amount uint64) error {
+	return p2p.Send(p.rw, GetHeadersMsg, &getHeadersData{
+		ReqID:  reqID,
+		Origin: origin,
+		Amount: amount,
+	})
+}
+
+// SendHeaders sends a batch of headers to the peer.
+func (p *peer) SendHeaders(reqID uint64, headers []*types.HeaderWithGovState) error {
+	return p2p.Send(p.rw, HeadersMsg, &headersData{ReqID: reqID, Headers: headers})
+}
+
+// RequestProofs fetches the Merkle proof of the key in the trie of the root.
+func (p *peer) RequestProofs(reqID uint64, root common.Hash, key []byte) error {
+	return p2p.Send(p.rw, GetProofsMsg, &getProofsData{
+		ReqID: reqID,
+		Root:  root,
+		Key:   key,
+	})
+}
+
+// SendProofs sends a Merkle proof to the peer.
+func (p *peer) SendProofs(reqID uint64, nodes light.NodeList) error {
+	return p2p.Send(p.rw, ProofsMsg, &proofsData{ReqID: reqID, Nodes: nodes})
+}
+
+// RequestCode fetches the contract code of the hash.
+func (p *peer) RequestCode(reqID uint64, hash common.Hash) error {
+	return p2p.Send(p.rw, GetCodeMsg, &getCodeData{ReqID: reqID, Hash: hash})
+}
+
+// SendCode sends a contract code to the peer.
+func (p *peer) SendCode(reqID uint64, code []byte) error {
+	return p2p.Send(p.rw, CodeMsg, &codeData{ReqID: reqID, Code: code})
+}
+
+// RequestGovState fetches the governance state of the header of the hash.
+func (p *peer) RequestGovState(reqID uint64, hash common.Hash) error {
+	return p2p.Send(p.rw, GetGovStateMsg, &getGovStateData{ReqID: reqID, Hash: hash})
+}
+
+// SendGovState sends a governance state to the peer, nil if unavailable.
+func (p *peer) SendGovState(reqID uint64, govState *types.GovState) error {
+	return p2p.Send(p.rw, GovStateMsg, &govStateData{ReqID: reqID, GovState: govState})
+}
+
+// Handshake executes the ldex protocol handshake, negotiating version number,
+// network IDs, head and genesis blocks.
+func (p *peer) Handshake(network uint64, number uint64, head common.Hash, genesis common.Hash) error {
+	// Send out own handshake in a new thread
+	errc := make(chan error, 2)
+	var status statusData // safe to read after two values have been received from errc
+
+	go func() {
+		errc <- p2p.Send(p.rw, StatusMsg, &statusData{
+			ProtocolVersion: uint32(p.version),
+			NetworkId:       network,
+			Number:          number,
+			CurrentBlock:    head,
+			GenesisBlock:    genesis,
+		})
+	}()
+	go func() {
+		errc <- p.readStatus(network, &status, genesis)
+	}()
+	timeout := time.NewTimer(handshakeTimeout)
+	defer timeout.Stop()
+	for i := 0; i < 2; i++ {
+		select {
+		case err := <-errc:
+			if err != nil {
+				return err
+			}
+		case <-timeout.C:
+			return p2p.DiscReadTimeout
+		}
+	}
+	p.number, p.head = status.Number, status.CurrentBlock
+	return nil
+}
+
+func (p *peer) readStatus(network uint64, status *statusData, genesis common.Hash) (err error) {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Code != StatusMsg {
+		return errResp(ErrNoStatusMsg, "first msg has code %x (!= %x)", msg.Code, StatusMsg)
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	// Decode the handshake and make sure everything matches
+	if err := msg.Decode(&status); err != nil {
+		return errResp(ErrDecode, "msg %v: %v", msg, err)
+	}
+	if status.GenesisBlock != genesis {
+		return errResp(ErrGenesisBlockMismatch, "%x (!= %x)", status.GenesisBlock[:8], genesis[:8])
+	}
+	if status.NetworkId != network {
+		return errResp(ErrNetworkIdMismatch, "%d (!= %d)", status.NetworkId, network)
+	}
+	if int(status.ProtocolVersion) != p.version {
+		return errResp(ErrProtocolVersionMismatch, "%d (!= %d)", status.ProtocolVersion, p.version)
+	}
+	return nil
+}
+
+// String implements fmt.Stringer.
+func (p *peer) String() string {
+	return fmt.Sprintf("Peer %s [%s]", p.id,
+		fmt.Sprintf("ldex/%d", p.version),
+	)
+}
+
+// peerSet represents the collection of active peers of the ldex protocol.
+type peerSet struct {
+	peers    map[string]*peer
+	maxPeers int
+	lock     sync.RWMutex
+	closed   bool
+}
+
+// newPeerSet creates a new peer set holding up to maxPeers peers, or an
+// unlimited number of peers if maxPeers is 0.
+func newPeerSet(maxPeers int) *peerSet {
+	return &peerSet{
+		peers:    make(map[string]*peer),
+		maxPeers: maxPeers,
+	}
+}
+
+// Register injects a new peer into the working set, or returns an error if
+// the peer is already known or the set is full.
+func (ps *peerSet) Register(p *peer) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if ps.closed {
+		return errClosed
+	}
+	if _, ok := ps.peers[p.id]; ok {
+		return errAlreadyRegistered
+	}
+	if ps.maxPeers > 0 && len(ps.peers) >= ps.maxPeers {
+		return errTooManyPeers
+	}
+	ps.peers[p.id] = p
+	return nil
+}
+
+// Unregister removes a remote peer from the active set.
+func (ps *peerSet) Unregister(id string) error {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	if _, ok := ps.peers[id]; !ok {
+		return errNotRegistered
+	}
+	delete(ps.peers, id)
+	return nil
+}
+
+// Peer retrieves the registered peer with the given id.
+func (ps *peerSet) Peer(id string) *peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	return ps.peers[id]
+}
+
+// Len returns if the current number of peers in the set.
+func (ps *peerSet) Len() int {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	return len(ps.peers)
+}
+
+// AllPeers retrieves all the peers in the set.
+func (ps *peerSet) AllPeers() []*peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	list := make([]*peer, 0, len(ps.peers))
+	for _, p := range ps.peers {
+		list = append(list, p)
+	}
+	return list
+}
+
+// BestPeer retrieves the known peer with the highest head.
+func (ps *peerSet) BestPeer() *peer {
+	ps.lock.RLock()
+	defer ps.lock.RUnlock()
+
+	var (
+		bestPeer   *peer
+		bestNumber uint64
+	)
+	for _, p := range ps.peers {
+		if _, number := p.Head(); bestPeer == nil || number > bestNumber {
+			bestPeer, bestNumber = p, number
+		}
+	}
+	return bestPeer
+}
+
+// Close disconnects all peers.
+// No new peers can be registered after Close has returned.
+func (ps *peerSet) Close() {
+	ps.lock.Lock()
+	defer ps.lock.Unlock()
+
+	for _, p := range ps.peers {
+		p.Disconnect(p2p.DiscQuitting)
+	}
+	ps.closed = true
+}
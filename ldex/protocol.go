@@ -0,0 +1,161 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+// Package ldex implements the DEXON light client protocol. Light clients
+// follow the chain by headers, verify them from the genesis by the threshold
+// signatures of the DKG sets with the governance states attached to the
+// headers, and retrieve the state on demand with Merkle proofs.
+package ldex
+
+import (
+	"fmt"
+
+	"github.com/dexon-foundation/dexon/common"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/light"
+)
+
+// Constants to match up protocol versions and messages
+const (
+	ldex1 = 1
+)
+
+// ProtocolName is the official short name of the protocol used during capability negotiation.
+var ProtocolName = "ldex"
+
+// ProtocolVersions are the supported versions of the ldex protocol (first is primary).
+var ProtocolVersions = []uint{ldex1}
+
+// ProtocolLengths are the number of implemented message corresponding to different protocol versions.
+var ProtocolLengths = []uint64{10}
+
+const ProtocolMaxMsgSize = 10 * 1024 * 1024 // Maximum cap on the size of a protocol message
+
+// ldex protocol message codes
+const (
+	StatusMsg      = 0x00
+	AnnounceMsg    = 0x01
+	GetHeadersMsg  = 0x02
+	HeadersMsg     = 0x03
+	GetProofsMsg   = 0x04
+	ProofsMsg      = 0x05
+	GetCodeMsg     = 0x06
+	CodeMsg        = 0x07
+	GetGovStateMsg = 0x08
+	GovStateMsg    = 0x09
+)
+
+const (
+	// maxHeadersServe is the maximum number of headers served per request.
+	maxHeadersServe = 192
+)
+
+type errCode int
+
+const (
+	ErrMsgTooLarge = iota
+	ErrDecode
+	ErrInvalidMsgCode
+	ErrProtocolVersionMismatch
+	ErrNetworkIdMismatch
+	ErrGenesisBlockMismatch
+	ErrNoStatusMsg
+	ErrExtraStatusMsg
+	ErrUnexpectedResponse
+)
+
+func (e errCode) String() string {
+	return errorToString[int(e)]
+}
+
+var errorToString = map[int]string{
+	ErrMsgTooLarge:             "Message too long",
+	ErrDecode:                  "Invalid message",
+	ErrInvalidMsgCode:          "Invalid message code",
+	ErrProtocolVersionMismatch: "Protocol version mismatch",
+	ErrNetworkIdMismatch:       "NetworkId mismatch",
+	ErrGenesisBlockMismatch:    "Genesis block mismatch",
+	ErrNoStatusMsg:             "No status message",
+	ErrExtraStatusMsg:          "Extra status message",
+	ErrUnexpectedResponse:      "Unexpected response",
+}
+
+func errResp(code errCode, format string, v ...interface{}) error {
+	return fmt.Errorf("%v - %v", code, fmt.Sprintf(format, v...))
+}
+
+// statusData is the network packet for the status message.
+type statusData struct {
+	ProtocolVersion uint32
+	NetworkId       uint64
+	Number          uint64
+	CurrentBlock    common.Hash
+	GenesisBlock    common.Hash
+}
+
+// announceData is the network packet announcing a new head of the server.
+type announceData struct {
+	Hash   common.Hash
+	Number uint64
+}
+
+// getHeadersData requests Amount canonical headers from the Origin height.
+// The governance states of the headers rounds start at and of the last
+// header are attached to the response.
+type getHeadersData struct {
+	ReqID  uint64
+	Origin uint64
+	Amount uint64
+}
+
+type headersData struct {
+	ReqID   uint64
+	Headers []*types.HeaderWithGovState
+}
+
+// getProofsData requests the Merkle proof of the key in the trie of the root,
+// the key is the hashed key of a state or storage trie.
+type getProofsData struct {
+	ReqID uint64
+	Root  common.Hash
+	Key   []byte
+}
+
+type proofsData struct {
+	ReqID uint64
+	Nodes light.NodeList
+}
+
+type getCodeData struct {
+	ReqID uint64
+	Hash  common.Hash
+}
+
+type codeData struct {
+	ReqID uint64
+	Code  []byte
+}
+
+type getGovStateData struct {
+	ReqID uint64
+	Hash  common.Hash
+}
+
+type govStateData struct {
+	ReqID    uint64
+	GovState *types.GovState `rlp:"nil"`
+}
@@ -0,0 +1,258 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package ldex
+
+import (
+	"sync"
+
+	"github.com/dexon-foundation/dexon/core"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/event"
+	"github.com/dexon-foundation/dexon/light"
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/p2p"
+	"github.com/dexon-foundation/dexon/trie"
+)
+
+// Server serves light clients from a full node.
+type Server struct {
+	blockchain *core.BlockChain
+	networkID  uint64
+	peers      *peerSet
+
+	chainHeadCh  chan core.ChainHeadEvent
+	chainHeadSub event.Subscription
+
+	quitSync chan struct{}
+	wg       sync.WaitGroup
+
+	protocols []p2p.Protocol
+}
+
+// NewServer creates a light server serving up to maxPeers light clients.
+func NewServer(blockchain *core.BlockChain, networkID uint64, maxPeers int) *Server {
+	s := &Server{
+		blockchain: blockchain,
+		networkID:  networkID,
+		peers:      newPeerSet(maxPeers),
+		quitSync:   make(chan struct{}),
+	}
+	s.protocols = makeProtocols(s.peers, s.quitSync, &s.wg, s.handle)
+	return s
+}
+
+// makeProtocols creates a sub-protocol for every implemented version,
+// running handle on the connected peers.
+func makeProtocols(peers *peerSet, quit chan struct{}, wg *sync.WaitGroup,
+	handle func(*peer) error) []p2p.Protocol {
+	protocols := make([]p2p.Protocol, 0, len(ProtocolVersions))
+	for i, version := range ProtocolVersions {
+		version := version // Closure for the run
+		protocols = append(protocols, p2p.Protocol{
+			Name:    ProtocolName,
+			Version: version,
+			Length:  ProtocolLengths[i],
+			Run: func(p *p2p.Peer, rw p2p.MsgReadWriter) error {
+				select {
+				case <-quit:
+					return p2p.DiscQuitting
+				default:
+				}
+				wg.Add(1)
+				defer wg.Done()
+				return handle(newPeer(int(version), p, rw))
+			},
+		})
+	}
+	return protocols
+}
+
+// Protocols returns the light server sub-protocols.
+func (s *Server) Protocols() []p2p.Protocol {
+	return s.protocols
+}
+
+// Start starts announcing new heads to the light clients.
+func (s *Server) Start() {
+	s.chainHeadCh = make(chan core.ChainHeadEvent, 10)
+	s.chainHeadSub = s.blockchain.SubscribeChainHeadEvent(s.chainHeadCh)
+	go s.announceLoop()
+}
+
+// Stop disconnects the light clients.
+func (s *Server) Stop() {
+	s.chainHeadSub.Unsubscribe()
+	close(s.quitSync)
+	s.peers.Close()
+	s.wg.Wait()
+	log.Info("Light server stopped")
+}
+
+func (s *Server) announceLoop() {
+	for {
+		select {
+		case ev := <-s.chainHeadCh:
+			hash, number := ev.Block.Hash(), ev.Block.NumberU64()
+			for _, p := range s.peers.AllPeers() {
+				if err := p.SendAnnounce(hash, number); err != nil {
+					p.Log().Debug("Light client announcement failed", "err", err)
+				}
+			}
+		// Err() channel will be closed when unsubscribing.
+		case <-s.chainHeadSub.Err():
+			return
+		}
+	}
+}
+
+func (s *Server) handle(p *peer) error {
+	p.Log().Debug("Light client connected", "name", p.Name())
+
+	var (
+		genesis = s.blockchain.Genesis()
+		head    = s.blockchain.CurrentBlock()
+	)
+	if err := p.Handshake(s.networkID, head.NumberU64(), head.Hash(), genesis.Hash()); err != nil {
+		p.Log().Debug("Light client handshake failed", "err", err)
+		return err
+	}
+	if err := s.peers.Register(p); err != nil {
+		p.Log().Debug("Light client registration failed", "err", err)
+		if err == errTooManyPeers {
+			return p2p.DiscTooManyPeers
+		}
+		return err
+	}
+	defer s.peers.Unregister(p.id)
+
+	for {
+		if err := s.handleMsg(p); err != nil {
+			p.Log().Debug("Light client message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// handleMsg is invoked whenever an inbound message is received from a light
+// client. The remote connection is torn down upon returning any error.
+func (s *Server) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	switch msg.Code {
+	case StatusMsg:
+		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
+
+	case GetHeadersMsg:
+		var req getHeadersData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		return p.SendHeaders(req.ReqID, s.headers(req.Origin, req.Amount))
+
+	case GetProofsMsg:
+		var req getProofsData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		nodes := light.NewNodeSet()
+		t, err := trie.New(req.Root, s.blockchain.StateCache().TrieDB())
+		if err == nil {
+			err = t.Prove(req.Key, 0, nodes)
+		}
+		if err != nil {
+			p.Log().Debug("Failed to prove trie node", "root", req.Root, "err", err)
+		}
+		return p.SendProofs(req.ReqID, nodes.NodeList())
+
+	case GetCodeMsg:
+		var req getCodeData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		code, err := s.blockchain.StateCache().TrieDB().Node(req.Hash)
+		if err != nil {
+			p.Log().Debug("Failed to retrieve contract code", "hash", req.Hash, "err", err)
+		}
+		return p.SendCode(req.ReqID, code)
+
+	case GetGovStateMsg:
+		var req getGovStateData
+		if err := msg.Decode(&req); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		govState, err := s.blockchain.GetGovStateByHash(req.Hash)
+		if err != nil {
+			p.Log().Debug("Failed to retrieve governance state", "hash", req.Hash, "err", err)
+		}
+		return p.SendGovState(req.ReqID, govState)
+
+	default:
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+	}
+}
+
+// headers retrieves the canonical headers from the origin height, up to the
+// current block. The governance states of the headers rounds start at, which
+// the clients need to verify the following rounds, and of the last header are
+// attached.
+func (s *Server) headers(origin, amount uint64) []*types.HeaderWithGovState {
+	if amount > maxHeadersServe {
+		amount = maxHeadersServe
+	}
+	current := s.blockchain.CurrentBlock().NumberU64()
+	if origin == 0 || origin > current {
+		return []*types.HeaderWithGovState{}
+	}
+	if current-origin+1 < amount {
+		amount = current - origin + 1
+	}
+	parent := s.blockchain.GetHeaderByNumber(origin - 1)
+	if parent == nil {
+		return []*types.HeaderWithGovState{}
+	}
+	headers := make([]*types.HeaderWithGovState, 0, amount)
+	for number := origin; number < origin+amount; number++ {
+		header := s.blockchain.GetHeaderByNumber(number)
+		if header == nil {
+			break
+		}
+		h := &types.HeaderWithGovState{Header: header}
+		if header.Round != parent.Round || number == origin+amount-1 {
+			govState, err := s.blockchain.GetGovStateByHash(header.Hash())
+			if err != nil {
+				// Without the governance state the client can't verify
+				// the following headers, stop here.
+				log.Warn("Failed to retrieve governance state", "number", number, "err", err)
+				if header.Round != parent.Round {
+					break
+				}
+			}
+			h.GovState = govState
+		}
+		headers = append(headers, h)
+		parent = header
+	}
+	return headers
+}
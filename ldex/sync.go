@@ -0,0 +1,268 @@
+// Copyright 2018 The dexon-consensus Authors
+// This file is part of the dexon-consensus library.
+//
+// The dexon-consensus library is free software: you can redistribute it
+// and/or modify it under the terms of the GNU Lesser General Public License as
+// published by the Free Software Foundation, either version 3 of the License,
+// or (at your option) any later version.
+//
+// The dexon-consensus library is distributed in the hope that it will be
+// useful, but WITHOUT ANY WARRANTY; without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE. See the GNU Lesser
+// General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with the dexon-consensus library. If not, see
+// <http://www.gnu.org/licenses/>.
+
+package ldex
+
+import (
+	"errors"
+	"time"
+
+	"github.com/dexon-foundation/dexon/core/rawdb"
+	"github.com/dexon-foundation/dexon/core/types"
+	"github.com/dexon-foundation/dexon/core/verifier"
+	"github.com/dexon-foundation/dexon/log"
+	"github.com/dexon-foundation/dexon/p2p"
+)
+
+const (
+	// maxHeaderFetch is the number of headers requested at a time.
+	maxHeaderFetch = maxHeadersServe
+
+	// forceSyncCycle is the interval the light client syncs with the best
+	// peer without announcements.
+	forceSyncCycle = 10 * time.Second
+)
+
+var errNoGovState = errors.New("governance state not available")
+
+func (ld *LightDexon) handle(p *peer) error {
+	p.Log().Debug("Light server connected", "name", p.Name())
+
+	head := ld.CurrentHeader()
+	if err := p.Handshake(ld.config.NetworkId, head.Number.Uint64(), head.Hash(), ld.genesis.Hash()); err != nil {
+		p.Log().Debug("Light server handshake failed", "err", err)
+		return err
+	}
+	if err := ld.peers.Register(p); err != nil {
+		p.Log().Debug("Light server registration failed", "err", err)
+		return err
+	}
+	defer ld.peers.Unregister(p.id)
+
+	ld.notifySync()
+	for {
+		if err := ld.handleMsg(p); err != nil {
+			p.Log().Debug("Light server message handling failed", "err", err)
+			return err
+		}
+	}
+}
+
+// handleMsg is invoked whenever an inbound message is received from a light
+// server. The remote connection is torn down upon returning any error.
+func (ld *LightDexon) handleMsg(p *peer) error {
+	msg, err := p.rw.ReadMsg()
+	if err != nil {
+		return err
+	}
+	if msg.Size > ProtocolMaxMsgSize {
+		return errResp(ErrMsgTooLarge, "%v > %v", msg.Size, ProtocolMaxMsgSize)
+	}
+	defer msg.Discard()
+
+	var (
+		reqID uint64
+		resp  interface{}
+	)
+	switch msg.Code {
+	case StatusMsg:
+		return errResp(ErrExtraStatusMsg, "uncontrolled status message")
+
+	case AnnounceMsg:
+		var announce announceData
+		if err := msg.Decode(&announce); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		p.SetHead(announce.Hash, announce.Number)
+		ld.notifySync()
+		return nil
+
+	case HeadersMsg:
+		var data headersData
+		if err := msg.Decode(&data); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		reqID, resp = data.ReqID, data.Headers
+
+	case ProofsMsg:
+		var data proofsData
+		if err := msg.Decode(&data); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		reqID, resp = data.ReqID, data.Nodes
+
+	case CodeMsg:
+		var data codeData
+		if err := msg.Decode(&data); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		reqID, resp = data.ReqID, data.Code
+
+	case GovStateMsg:
+		var data govStateData
+		if err := msg.Decode(&data); err != nil {
+			return errResp(ErrDecode, "msg %v: %v", msg, err)
+		}
+		reqID, resp = data.ReqID, data.GovState
+
+	default:
+		return errResp(ErrInvalidMsgCode, "%v", msg.Code)
+	}
+	if !ld.dispatcher.deliver(p.id, reqID, resp) {
+		// The request may have timed out.
+		p.Log().Debug("Unrequested response", "code", msg.Code, "reqid", reqID)
+	}
+	return nil
+}
+
+// notifySync triggers a synchronisation without blocking.
+func (ld *LightDexon) notifySync() {
+	select {
+	case ld.syncCh <- struct{}{}:
+	default:
+	}
+}
+
+// syncer is responsible for periodically synchronising the headers with the
+// best light server.
+func (ld *LightDexon) syncer() {
+	defer ld.wg.Done()
+
+	forceSync := time.NewTicker(forceSyncCycle)
+	defer forceSync.Stop()
+
+	for {
+		select {
+		case <-ld.syncCh:
+		case <-forceSync.C:
+		case <-ld.quitSync:
+			return
+		}
+		if p := ld.peers.BestPeer(); p != nil {
+			ld.synchronise(p)
+		}
+	}
+}
+
+// synchronise fetches and verifies the headers up to the head of the peer.
+// A peer serving headers failing verification is disconnected.
+func (ld *LightDexon) synchronise(p *peer) {
+	ctx, cancel := ld.newContext()
+	defer cancel()
+
+	for {
+		head := ld.verifier.Head()
+		if _, number := p.Head(); number <= head.Number.Uint64() {
+			return
+		}
+		resp, err := ld.dispatcher.request(ctx, p, func(reqID uint64) error {
+			return p.RequestHeaders(reqID, head.Number.Uint64()+1, maxHeaderFetch)
+		})
+		if err != nil {
+			p.Log().Debug("Header request failed", "err", err)
+			return
+		}
+		headers, ok := resp.([]*types.HeaderWithGovState)
+		if !ok || len(headers) == 0 {
+			return
+		}
+		if err := ld.insertHeaders(p, headers); err != nil {
+			p.Log().Warn("Light server served invalid headers", "err", err)
+			p.Disconnect(p2p.DiscUselessPeer)
+			return
+		}
+	}
+}
+
+// insertHeaders verifies the headers and writes the verified ones into the
+// database. When the governance states attached are not recent enough, the
+// governance state of the latest verified header is fetched once.
+func (ld *LightDexon) insertHeaders(p *peer, headers []*types.HeaderWithGovState) error {
+	verified, err := ld.verify(headers)
+	ld.writeHeaders(headers[:verified])
+	if !verifier.NeedGovState(err) {
+		return err
+	}
+	headers = headers[verified:]
+
+	head := ld.verifier.Head()
+	govState, err := ld.requestGovState(p, head)
+	if err != nil {
+		return err
+	}
+	if err := ld.verifier.StoreGovState(govState); err != nil {
+		return err
+	}
+	ld.updateCheckpoint([]*types.HeaderWithGovState{{Header: head, GovState: govState}})
+
+	verified, err = ld.verify(headers)
+	ld.writeHeaders(headers[:verified])
+	return err
+}
+
+// verify verifies the headers, it returns the number of headers verified.
+func (ld *LightDexon) verify(headers []*types.HeaderWithGovState) (int, error) {
+	i, err := ld.verifier.Verify(headers)
+	if err != nil {
+		return i, err
+	}
+	return len(headers), nil
+}
+
+func (ld *LightDexon) requestGovState(p *peer, header *types.Header) (*types.GovState, error) {
+	ctx, cancel := ld.newContext()
+	defer cancel()
+
+	resp, err := ld.dispatcher.request(ctx, p, func(reqID uint64) error {
+		return p.RequestGovState(reqID, header.Hash())
+	})
+	if err != nil {
+		return nil, err
+	}
+	govState, ok := resp.(*types.GovState)
+	if !ok {
+		return nil, errUnexpectedResponse
+	}
+	if govState == nil {
+		return nil, errNoGovState
+	}
+	return govState, nil
+}
+
+// writeHeaders writes the verified headers as the canonical chain.
+func (ld *LightDexon) writeHeaders(headers []*types.HeaderWithGovState) {
+	if len(headers) == 0 {
+		return
+	}
+	batch := ld.chainDb.NewBatch()
+	for _, header := range headers {
+		rawdb.WriteHeader(batch, header.Header)
+		rawdb.WriteCanonicalHash(batch, header.Hash(), header.Number.Uint64())
+	}
+	head := headers[len(headers)-1].Header
+	rawdb.WriteHeadHeaderHash(batch, head.Hash())
+	if err := batch.Write(); err != nil {
+		log.Crit("Failed to write headers", "err", err)
+	}
+	ld.updateCheckpoint(headers)
+
+	ld.headLock.Lock()
+	ld.head = head
+	ld.headLock.Unlock()
+	log.Debug("Imported verified headers", "count", len(headers),
+		"number", head.Number, "hash", head.Hash(), "round", head.Round)
+}
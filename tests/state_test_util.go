@@ -121,6 +121,14 @@ func (t *StateTest) Subtests() []StateSubtest {
 
 // Run executes a specific subtest.
 func (t *StateTest) Run(subtest StateSubtest, vmconfig vm.Config) (*state.StateDB, error) {
+	return t.RunWithHook(subtest, vmconfig, nil)
+}
+
+// RunWithHook executes a specific subtest, letting the hook adjust the EVM
+// context and the pre state before the transaction is applied, e.g. to set
+// the DEXON specific context the test files don't carry.
+func (t *StateTest) RunWithHook(subtest StateSubtest, vmconfig vm.Config,
+	hook func(*vm.Context, *state.StateDB)) (*state.StateDB, error) {
 	config, ok := Forks[subtest.Fork]
 	if !ok {
 		return nil, UnsupportedForkError{subtest.Fork}
@@ -135,6 +143,9 @@ func (t *StateTest) Run(subtest StateSubtest, vmconfig vm.Config) (*state.StateD
 	}
 	context := core.NewEVMContext(msg, block.Header(), nil, &t.json.Env.Coinbase)
 	context.GetHash = vmTestBlockHash
+	if hook != nil {
+		hook(&context, statedb)
+	}
 	evm := vm.NewEVM(context, statedb, config, vmconfig)
 
 	gaspool := new(core.GasPool)